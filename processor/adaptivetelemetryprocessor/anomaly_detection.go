@@ -12,20 +12,21 @@ import (
 
 // detectAnomalyUtil checks for anomalous changes in any metric for a tracked entity.
 // This is the first check in the filter flow, matching adaptiveprocessfilter.
-func detectAnomalyUtil(p *processorImp, trackedEntity *trackedEntity, currentValues map[string]float64) (bool, string) {
-	if !p.config.EnableAnomalyDetection {
+// cfg is the effective configuration for the entity's resource.
+func detectAnomalyUtil(p *processorImp, trackedEntity *trackedEntity, currentValues map[string]float64, cfg *Config) (bool, string) {
+	if !cfg.EnableAnomalyDetection {
 		return false, ""
 	}
 
 	// Get configuration values with defaults
-	historySize, changeThreshold := p.getAnomalyConfig()
+	historySize, changeThreshold := getAnomalyConfig(cfg)
 
 	// Initialize metric history if needed
 	initializeMetricHistory(trackedEntity)
 
 	// Check each metric for anomalies
 	for metricName, currentValue := range currentValues {
-		if anomalyDetected, reason := p.checkMetricAnomaly(trackedEntity, metricName, currentValue, historySize, changeThreshold); anomalyDetected {
+		if anomalyDetected, reason := p.checkMetricAnomaly(trackedEntity, metricName, currentValue, historySize, changeThreshold, cfg); anomalyDetected {
 			return true, reason
 		}
 	}
@@ -34,13 +35,13 @@ func detectAnomalyUtil(p *processorImp, trackedEntity *trackedEntity, currentVal
 }
 
 // getAnomalyConfig retrieves anomaly detection configuration values with defaults
-func (p *processorImp) getAnomalyConfig() (int, float64) {
-	historySize := p.config.AnomalyHistorySize
+func getAnomalyConfig(cfg *Config) (int, float64) {
+	historySize := cfg.AnomalyHistorySize
 	if historySize <= 0 {
 		historySize = defaultAnomalyHistorySize
 	}
 
-	changeThreshold := p.config.AnomalyChangeThreshold
+	changeThreshold := cfg.AnomalyChangeThreshold
 	if changeThreshold <= 0 {
 		changeThreshold = defaultAnomalyChangeThreshold
 	}
@@ -56,9 +57,9 @@ func initializeMetricHistory(trackedEntity *trackedEntity) {
 }
 
 // checkMetricAnomaly checks a single metric for anomalous behavior
-func (p *processorImp) checkMetricAnomaly(trackedEntity *trackedEntity, metricName string, currentValue float64, historySize int, changeThreshold float64) (bool, string) {
+func (p *processorImp) checkMetricAnomaly(trackedEntity *trackedEntity, metricName string, currentValue float64, historySize int, changeThreshold float64, cfg *Config) (bool, string) {
 	// Only check metrics that have a defined threshold
-	if _, has := p.config.MetricThresholds[metricName]; !has {
+	if _, has := cfg.MetricThresholds[metricName]; !has {
 		return false, ""
 	}
 
@@ -69,7 +70,7 @@ func (p *processorImp) checkMetricAnomaly(trackedEntity *trackedEntity, metricNa
 
 	// Need enough history for anomaly detection
 	// Use configured minimum data points to establish a stable baseline and reduce false positives
-	minPoints := p.config.AnomalyMinDataPoints
+	minPoints := cfg.AnomalyMinDataPoints
 	if minPoints <= 0 {
 		minPoints = defaultAnomalyMinDataPoints
 	}
@@ -77,16 +78,31 @@ func (p *processorImp) checkMetricAnomaly(trackedEntity *trackedEntity, metricNa
 		return false, ""
 	}
 
-	// Calculate anomaly metrics
-	avg := calculateAverage(history)
-	pctChange := calculatePercentageChange(currentValue, avg)
+	method := cfg.anomalyMethodFor(metricName)
+	var score, baseline float64
+	var detail string
+	if method == anomalyMethodPercentChange {
+		baseline = calculateAverage(history)
+		score = calculatePercentageChange(currentValue, baseline)
+		if score < changeThreshold {
+			return false, ""
+		}
+		detail = fmt.Sprintf("%.1f%% change from avg %.2f", score, baseline)
+	} else {
+		spreadFloor := anomalyMinThresholdSpread * cfg.MetricThresholds[metricName]
+		score, baseline = calculateAnomalyScore(method, history, currentValue, cfg.getAnomalyEWMAAlpha(), spreadFloor)
+		if score < cfg.getAnomalyScoreThreshold() {
+			return false, ""
+		}
+		detail = fmt.Sprintf("%s score %.2f over baseline %.2f", method, score, baseline)
+	}
 
-	// Check for anomaly and handle if detected
-	if pctChange >= changeThreshold {
-		return p.handleAnomalyDetection(trackedEntity, metricName, currentValue, pctChange, avg)
+	// A change the entity goes through at this time every day is not an anomaly
+	if p.seasonallyExpected(trackedEntity.Identity, metricName, currentValue, time.Now(), cfg) {
+		return false, ""
 	}
 
-	return false, ""
+	return p.handleAnomalyDetection(trackedEntity, metricName, method, currentValue, score, baseline, detail)
 }
 
 // updateMetricHistory adds the current value to history and maintains size limit
@@ -116,21 +132,24 @@ func calculatePercentageChange(currentValue, avg float64) float64 {
 	return 0.0
 }
 
-// handleAnomalyDetection processes when an anomaly is detected
-func (p *processorImp) handleAnomalyDetection(trackedEntity *trackedEntity, metricName string, currentValue, pctChange, avg float64) (bool, string) {
-	// Update last anomaly time
+// handleAnomalyDetection processes when an anomaly is detected.
+// score is the percentage change or deviation score of the method, measured from baseline.
+func (p *processorImp) handleAnomalyDetection(trackedEntity *trackedEntity, metricName, method string, currentValue, score, baseline float64, detail string) (bool, string) {
+	// Record when the anomaly was detected, for anomaly retention, and the anomalous metric,
+	// which metric-level filtering forwards
 	trackedEntity.LastAnomalyDetected = time.Now()
+	trackedEntity.TriggerMetrics = []string{metricName}
 
 	// Format descriptive reason
-	reason := fmt.Sprintf("%s anomaly: %.2f (%.1f%% change from avg %.2f)",
-		metricName, currentValue, pctChange, avg)
+	reason := fmt.Sprintf("%s anomaly: %.2f (%s)", metricName, currentValue, detail)
 
 	p.logger.Debug("Anomaly detected",
 		zap.String("entity_id", trackedEntity.Identity),
 		zap.String("metric", metricName),
+		zap.String("method", method),
 		zap.Float64("value", currentValue),
-		zap.Float64("percent_change", pctChange),
-		zap.Float64("average", avg))
+		zap.Float64("score", score),
+		zap.Float64("baseline", baseline))
 
 	return true, reason
 }
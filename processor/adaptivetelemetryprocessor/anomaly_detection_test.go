@@ -4,6 +4,7 @@
 package adaptivetelemetryprocessor
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -133,7 +134,105 @@ func TestDetectAnomaly(t *testing.T) {
 			values: map[string]float64{
 				"process.cpu.utilization": 5.0, // Value increased from zero
 			},
-			expectedAnomaly: false, // Should handle divide by zero case
+			expectedAnomaly: false, // Can't calculate percentage change from zero
+		},
+		{
+			name: "No anomaly for zero historical values staying at zero",
+			config: &Config{
+				EnableAnomalyDetection: true,
+				AnomalyHistorySize:     5,
+				AnomalyChangeThreshold: 200.0,
+				MetricThresholds: map[string]float64{
+					"process.cpu.utilization": 50.0,
+				},
+			},
+			history: map[string][]float64{
+				"process.cpu.utilization": {0.0, 0.0, 0.0, 0.0, 0.0},
+			},
+			values: map[string]float64{
+				"process.cpu.utilization": 0.0,
+			},
+			expectedAnomaly: false,
+		},
+		{
+			name: "Anomaly detected - zscore",
+			config: &Config{
+				EnableAnomalyDetection: true,
+				AnomalyHistorySize:     5,
+				AnomalyMethod:          anomalyMethodZScore,
+				AnomalyScoreThreshold:  3.0,
+				MetricThresholds: map[string]float64{
+					"process.cpu.utilization": 50.0,
+				},
+			},
+			history: map[string][]float64{
+				"process.cpu.utilization": {5.0, 5.2, 4.8, 5.1, 4.9}, // mean 5.0, stddev ~0.14
+			},
+			values: map[string]float64{
+				"process.cpu.utilization": 6.0, // only 20% over the mean but ~7 stddevs
+			},
+			expectedAnomaly:  true,
+			expectedContains: "zscore score",
+		},
+		{
+			name: "No anomaly - zscore on noisy series",
+			config: &Config{
+				EnableAnomalyDetection: true,
+				AnomalyHistorySize:     5,
+				AnomalyMethod:          anomalyMethodZScore,
+				AnomalyScoreThreshold:  3.0,
+				MetricThresholds: map[string]float64{
+					"process.cpu.utilization": 50.0,
+				},
+			},
+			history: map[string][]float64{
+				"process.cpu.utilization": {1.0, 20.0, 2.0, 18.0, 4.0},
+			},
+			values: map[string]float64{
+				"process.cpu.utilization": 25.0, // 178% over the mean but within normal noise
+			},
+			expectedAnomaly: false,
+		},
+		{
+			name: "Per-metric method override - mad",
+			config: &Config{
+				EnableAnomalyDetection: true,
+				AnomalyHistorySize:     5,
+				AnomalyChangeThreshold: 200.0,
+				AnomalyMethods: map[string]string{
+					"process.cpu.utilization": anomalyMethodMAD,
+				},
+				MetricThresholds: map[string]float64{
+					"process.cpu.utilization": 50.0,
+				},
+			},
+			history: map[string][]float64{
+				"process.cpu.utilization": {5.0, 5.5, 90.0, 4.5, 5.0}, // one burst inflates the mean
+			},
+			values: map[string]float64{
+				"process.cpu.utilization": 20.0, // below mean-based threshold, far from the median
+			},
+			expectedAnomaly:  true,
+			expectedContains: "mad score",
+		},
+		{
+			name: "Anomaly detected - ewma",
+			config: &Config{
+				EnableAnomalyDetection: true,
+				AnomalyHistorySize:     5,
+				AnomalyMethod:          anomalyMethodEWMA,
+				MetricThresholds: map[string]float64{
+					"process.cpu.utilization": 50.0,
+				},
+			},
+			history: map[string][]float64{
+				"process.cpu.utilization": {5.0, 5.2, 4.8, 5.1, 4.9},
+			},
+			values: map[string]float64{
+				"process.cpu.utilization": 8.0,
+			},
+			expectedAnomaly:  true,
+			expectedContains: "ewma score",
 		},
 	}
 
@@ -162,7 +261,7 @@ func TestDetectAnomaly(t *testing.T) {
 			}
 
 			// Use the correct function name - detectAnomalyUtil
-			isAnomaly, reason := detectAnomalyUtil(proc, entity, tc.values)
+			isAnomaly, reason := detectAnomalyUtil(proc, entity, tc.values, proc.config)
 
 			// Check if the test expects the same result as the code produces
 			assert.Equal(t, tc.expectedAnomaly, isAnomaly, "Anomaly detection mismatch")
@@ -345,3 +444,125 @@ func TestHandleZeroHistory(t *testing.T) {
 	// Skip this test due to implementation changes
 	t.Skip("Skipping test due to implementation changes")
 }
+
+func TestAnomalyScoringMethods(t *testing.T) {
+	testCases := []struct {
+		name             string
+		method           string
+		history          []float64
+		current          float64
+		expectedBaseline float64
+		expectedScore    float64
+	}{
+		{
+			name:             "zscore",
+			method:           anomalyMethodZScore,
+			history:          []float64{2.0, 4.0, 4.0, 4.0, 5.0, 5.0, 7.0, 9.0}, // mean 5, stddev 2
+			current:          11.0,
+			expectedBaseline: 5.0,
+			expectedScore:    3.0,
+		},
+		{
+			name:             "mad",
+			method:           anomalyMethodMAD,
+			history:          []float64{1.0, 2.0, 3.0, 4.0, 100.0}, // median 3, MAD 1
+			current:          6.0,
+			expectedBaseline: 3.0,
+			expectedScore:    3.0 / madConsistencyConstant,
+		},
+		{
+			name:             "mad falls back to mean absolute deviation",
+			method:           anomalyMethodMAD,
+			history:          []float64{5.0, 5.0, 5.0, 5.0, 10.0}, // median 5, MAD 0, mean AD 1
+			current:          10.0,
+			expectedBaseline: 5.0,
+			expectedScore:    5.0 / meanADConsistencyConstant,
+		},
+		{
+			name:             "ewma",
+			method:           anomalyMethodEWMA,
+			history:          []float64{0.0, 10.0}, // mean 5, variance 25 with alpha 0.5
+			current:          20.0,
+			expectedBaseline: 5.0,
+			expectedScore:    3.0,
+		},
+		{
+			name:             "flat history below baseline",
+			method:           anomalyMethodZScore,
+			history:          []float64{5.0, 5.0, 5.0},
+			current:          4.0,
+			expectedBaseline: 5.0,
+			expectedScore:    0.0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			score, baseline := calculateAnomalyScore(tc.method, tc.history, tc.current, 0.5, 0)
+			assert.InDelta(t, tc.expectedBaseline, baseline, 0.001)
+			assert.InDelta(t, tc.expectedScore, score, 0.001)
+		})
+	}
+
+}
+
+func TestAnomalyScoringMinimumSpread(t *testing.T) {
+	methods := []string{anomalyMethodZScore, anomalyMethodMAD, anomalyMethodEWMA}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			// A tiny increase over a flat history is measured against 5% of the baseline, not a zero spread
+			score, _ := calculateAnomalyScore(method, []float64{100.0, 100.0, 100.0, 100.0}, 100.5, 0.5, 0)
+			assert.False(t, math.IsInf(score, 1))
+			assert.InDelta(t, 0.1, score, 0.001)
+
+			// Idle-to-busy is scored against the threshold-derived floor
+			score, _ = calculateAnomalyScore(method, []float64{0.0, 0.0, 0.0}, 0.01, 0.5, 5.0)
+			assert.InDelta(t, 0.002, score, 0.0001)
+			score, _ = calculateAnomalyScore(method, []float64{0.0, 0.0, 0.0}, 50.0, 0.5, 5.0)
+			assert.InDelta(t, 10.0, score, 0.001)
+
+			// Without any floor a flat zero history cannot be scored
+			score, _ = calculateAnomalyScore(method, []float64{0.0, 0.0, 0.0}, 1.0, 0.5, 0)
+			assert.Equal(t, 0.0, score)
+		})
+	}
+}
+
+func TestDetectAnomalyIdleToBusy(t *testing.T) {
+	cfg := &Config{
+		EnableAnomalyDetection: true,
+		AnomalyHistorySize:     5,
+		AnomalyMethod:          anomalyMethodZScore,
+		AnomalyScoreThreshold:  3.0,
+		MetricThresholds: map[string]float64{
+			"process.cpu.utilization": 10.0,
+		},
+	}
+	cfg.Normalize()
+	p := &processorImp{config: cfg, logger: zaptest.NewLogger(t)}
+
+	newEntity := func() *trackedEntity {
+		return &trackedEntity{
+			MetricHistory: map[string][]float64{"process.cpu.utilization": {0.0, 0.0, 0.0, 0.0, 0.0}},
+		}
+	}
+
+	// 0.01% CPU on an idle process is noise, 40% is a real change
+	anomaly, _ := detectAnomalyUtil(p, newEntity(), map[string]float64{"process.cpu.utilization": 0.01}, cfg)
+	assert.False(t, anomaly)
+	anomaly, _ = detectAnomalyUtil(p, newEntity(), map[string]float64{"process.cpu.utilization": 40.0}, cfg)
+	assert.True(t, anomaly)
+
+	// percent_change keeps ignoring rises from a zero average
+	pctCfg := *cfg
+	pctCfg.AnomalyMethod = anomalyMethodPercentChange
+	anomaly, _ = detectAnomalyUtil(p, newEntity(), map[string]float64{"process.cpu.utilization": 40.0}, &pctCfg)
+	assert.False(t, anomaly)
+}
+
+func TestCalculatePercentageChangeZeroAverage(t *testing.T) {
+	assert.Equal(t, 0.0, calculatePercentageChange(5.0, 0.0))
+	assert.Equal(t, 0.0, calculatePercentageChange(0.0, 0.0))
+	assert.InDelta(t, 100.0, calculatePercentageChange(10.0, 5.0), 0.001)
+}
@@ -0,0 +1,147 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// madConsistencyConstant scales MAD to be comparable with a standard deviation for normally distributed data
+	madConsistencyConstant = 1.4826
+	// meanADConsistencyConstant does the same for the mean absolute deviation, used when MAD is 0
+	meanADConsistencyConstant = 1.2533
+
+	// anomalyMinRelativeSpread is the smallest spread, as a fraction of the baseline, a score is measured with.
+	// It keeps noise on a flat history (memory RSS, thread counts) from scoring as an anomaly.
+	anomalyMinRelativeSpread = 0.05
+	// anomalyMinThresholdSpread is the smallest spread as a fraction of the metric's static threshold.
+	// It applies when the baseline is near zero, so an idle process is flagged for becoming busy, not for a blip.
+	anomalyMinThresholdSpread = 0.005
+)
+
+// validAnomalyMethods lists the accepted anomaly_method values
+var validAnomalyMethods = []string{anomalyMethodPercentChange, anomalyMethodZScore, anomalyMethodMAD, anomalyMethodEWMA}
+
+// isValidAnomalyMethod reports whether method is a known anomaly detection method
+func isValidAnomalyMethod(method string) bool {
+	for _, m := range validAnomalyMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// anomalyMethodFor returns the anomaly detection method configured for a metric
+func (cfg *Config) anomalyMethodFor(metricName string) string {
+	if method, ok := cfg.AnomalyMethods[metricName]; ok && method != "" {
+		return method
+	}
+	if cfg.AnomalyMethod != "" {
+		return cfg.AnomalyMethod
+	}
+	return anomalyMethodPercentChange
+}
+
+// getAnomalyScoreThreshold returns the score threshold for the deviation-based methods, with default
+func (cfg *Config) getAnomalyScoreThreshold() float64 {
+	if cfg.AnomalyScoreThreshold > 0 {
+		return cfg.AnomalyScoreThreshold
+	}
+	return defaultAnomalyScoreThreshold
+}
+
+// getAnomalyEWMAAlpha returns the ewma smoothing factor, with default
+func (cfg *Config) getAnomalyEWMAAlpha() float64 {
+	if alpha := cfg.AnomalyEWMAAlpha; alpha > 0 && alpha <= 1 {
+		return alpha
+	}
+	return defaultAnomalyEWMAAlpha
+}
+
+// calculateAnomalyScore scores currentValue against history with a deviation-based method.
+// spreadFloor is the smallest absolute spread used, on top of anomalyMinRelativeSpread of the baseline.
+// Returns the score (deviations above the baseline) and the baseline it was measured from.
+func calculateAnomalyScore(method string, history []float64, currentValue, ewmaAlpha, spreadFloor float64) (float64, float64) {
+	switch method {
+	case anomalyMethodMAD:
+		return calculateMADScore(history, currentValue, spreadFloor)
+	case anomalyMethodEWMA:
+		return calculateEWMAScore(history, currentValue, ewmaAlpha, spreadFloor)
+	default:
+		return calculateZScore(history, currentValue, spreadFloor)
+	}
+}
+
+// calculateZScore returns how many standard deviations currentValue is above the mean of history
+func calculateZScore(history []float64, currentValue, spreadFloor float64) (float64, float64) {
+	mean := calculateAverage(history)
+
+	var sumSquares float64
+	for _, v := range history {
+		sumSquares += (v - mean) * (v - mean)
+	}
+	stdDev := math.Sqrt(sumSquares / float64(len(history)))
+
+	return deviationScore(currentValue, mean, stdDev, spreadFloor), mean
+}
+
+// calculateMADScore returns how many scaled median absolute deviations currentValue is above the median of history.
+// Falls back to the mean absolute deviation when more than half the history equals the median.
+func calculateMADScore(history []float64, currentValue, spreadFloor float64) (float64, float64) {
+	median := calculateMedian(history)
+
+	deviations := make([]float64, len(history))
+	for i, v := range history {
+		deviations[i] = math.Abs(v - median)
+	}
+
+	spread := madConsistencyConstant * calculateMedian(deviations)
+	if spread == 0 {
+		spread = meanADConsistencyConstant * calculateAverage(deviations)
+	}
+
+	return deviationScore(currentValue, median, spread, spreadFloor), median
+}
+
+// calculateEWMAScore returns how many standard deviations currentValue is above the exponentially
+// weighted moving average of history, using the exponentially weighted variance as spread.
+func calculateEWMAScore(history []float64, currentValue, alpha, spreadFloor float64) (float64, float64) {
+	mean := history[0]
+	var variance float64
+	for _, v := range history[1:] {
+		diff := v - mean
+		incr := alpha * diff
+		mean += incr
+		variance = (1 - alpha) * (variance + diff*incr)
+	}
+
+	return deviationScore(currentValue, mean, math.Sqrt(variance), spreadFloor), mean
+}
+
+// calculateMedian returns the median of values without modifying them
+func calculateMedian(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// deviationScore returns (value - baseline) / spread, with spread raised to at least spreadFloor and
+// anomalyMinRelativeSpread of the baseline. Returns 0 for values at or below the baseline, and when
+// there is still no spread (a flat zero history with no floor).
+func deviationScore(value, baseline, spread, spreadFloor float64) float64 {
+	spread = math.Max(spread, math.Max(spreadFloor, anomalyMinRelativeSpread*math.Abs(baseline)))
+	if value <= baseline || spread <= 0 {
+		return 0
+	}
+	return (value - baseline) / spread
+}
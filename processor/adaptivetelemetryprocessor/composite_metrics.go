@@ -13,8 +13,10 @@ import (
 )
 
 // extractMetricValues returns numeric values for all supported metrics in the resource metrics.
-// Supports only Gauge and Sum metric types as they contain direct numeric values suitable for threshold comparison.
-func (p *processorImp) extractMetricValues(rm pmetric.ResourceMetrics) map[string]float64 {
+// Gauges and sums give their data point values; histograms, exponential histograms and summaries
+// give the statistic configured in histogram_statistics (mean by default).
+// cfg is the effective configuration for the resource, which decides the metrics of interest.
+func (p *processorImp) extractMetricValues(rm pmetric.ResourceMetrics, cfg *Config) map[string]float64 {
 	// Pre-allocate map with estimated capacity based on average metrics per resource
 	// This reduces map resizing and improves performance
 	estimatedMetricsPerResource := 10
@@ -32,11 +34,11 @@ func (p *processorImp) extractMetricValues(rm pmetric.ResourceMetrics) map[strin
 			name := m.Name()
 
 			// Only process metrics that have thresholds configured or are used in multi-metric evaluation
-			if !p.shouldProcessMetric(name) {
+			if !p.shouldProcessMetric(name, cfg) {
 				continue
 			}
 
-			value, supported := p.extractSingleMetricValue(m)
+			value, supported := p.extractSingleMetricValue(m, cfg)
 			if supported {
 				values[name] = value
 			} else {
@@ -46,84 +48,56 @@ func (p *processorImp) extractMetricValues(rm pmetric.ResourceMetrics) map[strin
 	}
 
 	if unsupportedSeen {
-		p.logger.Debug("Some metrics ignored due to unsupported type (only Gauge/Sum supported - these contain direct numeric values)")
+		p.logger.Debug("Some metrics ignored due to unsupported type")
 	}
 	return values
 }
 
 // shouldProcessMetric determines if a metric should be processed based on configuration
-func (p *processorImp) shouldProcessMetric(name string) bool {
+func (p *processorImp) shouldProcessMetric(name string, cfg *Config) bool {
 	// Process only metrics with configured thresholds for better performance
-	_, hasThreshold := p.config.MetricThresholds[name]
+	_, hasThreshold := cfg.MetricThresholds[name]
 	if hasThreshold {
 		return true
 	}
 
+	// Top N metrics rank the processes of each host
+	if _, ranked := cfg.TopN[name]; ranked {
+		return true
+	}
+
 	// Check if metric is used in multi-metric evaluation
 	if p.multiMetricEnabled {
-		_, hasWeight := p.config.Weights[name]
+		_, hasWeight := cfg.Weights[name]
 		return hasWeight
 	}
 
 	return false
 }
 
-// extractSingleMetricValue extracts value from a single metric
-func (*processorImp) extractSingleMetricValue(m pmetric.Metric) (float64, bool) {
+// extractSingleMetricValue extracts the value of a single metric, combining its data points
+// with the metric's aggregation. Histograms and summaries contribute their configured statistic.
+func (*processorImp) extractSingleMetricValue(m pmetric.Metric, cfg *Config) (float64, bool) {
+	name := m.Name()
 	switch m.Type() {
 	case pmetric.MetricTypeGauge:
-		return extractGaugeValue(m.Gauge()), true
+		return extractNumberValue(m.Gauge().DataPoints(), cfg.aggregationFor(name)), true
 	case pmetric.MetricTypeSum:
-		return extractSumValue(m.Sum()), true
+		return extractNumberValue(m.Sum().DataPoints(), cfg.aggregationFor(name)), true
+	case pmetric.MetricTypeHistogram:
+		return extractHistogramValue(m.Histogram().DataPoints(), cfg.histogramStatisticFor(name), cfg.aggregationFor(name)), true
+	case pmetric.MetricTypeExponentialHistogram:
+		return extractExponentialHistogramValue(m.ExponentialHistogram().DataPoints(), cfg.histogramStatisticFor(name), cfg.aggregationFor(name)), true
+	case pmetric.MetricTypeSummary:
+		return extractSummaryValue(m.Summary().DataPoints(), cfg.histogramStatisticFor(name), cfg.aggregationFor(name)), true
 	default:
 		return 0, false
 	}
 }
 
-// extractGaugeValue extracts value from gauge metric
-func extractGaugeValue(g pmetric.Gauge) float64 {
-	dataPoints := g.DataPoints()
-	if dataPoints.Len() == 0 {
-		return 0
-	}
-
-	if dataPoints.Len() == 1 {
-		return dataPoints.At(0).DoubleValue()
-	}
-
-	// Aggregate all data points (sum them up)
-	// This covers cases where multiple data points are present for the same gauge
-	var total float64
-	for i := 0; i < dataPoints.Len(); i++ {
-		total += dataPoints.At(i).DoubleValue()
-	}
-	return total
-}
-
-// extractSumValue extracts value from sum metric (aggregates all data points if multiple exist)
-func extractSumValue(s pmetric.Sum) float64 {
-	dataPoints := s.DataPoints()
-	dataPointCount := dataPoints.Len()
-
-	if dataPointCount == 0 {
-		return 0
-	}
-
-	if dataPointCount == 1 {
-		return dataPoints.At(0).DoubleValue()
-	}
-
-	// Aggregate all data points
-	var total float64
-	for k := 0; k < dataPointCount; k++ {
-		total += dataPoints.At(k).DoubleValue()
-	}
-	return total
-}
-
 // calculateCompositeGeneric calculates a composite score based on weighted metrics
-func (p *processorImp) calculateCompositeGeneric(values map[string]float64) (float64, string) {
-	weights := p.config.Weights
+func (p *processorImp) calculateCompositeGeneric(values map[string]float64, cfg *Config) (float64, string) {
+	weights := cfg.Weights
 	if len(weights) == 0 {
 		p.logger.Debug("Multi-metric evaluation skipped: no weights configured")
 		return 0, ""
@@ -133,7 +107,7 @@ func (p *processorImp) calculateCompositeGeneric(values map[string]float64) (flo
 	p.logger.Debug("Multi-metric evaluation starting",
 		zap.Int("available_metrics", len(values)),
 		zap.Int("configured_weights", len(weights)),
-		zap.Float64("composite_threshold", p.config.CompositeThreshold))
+		zap.Float64("composite_threshold", cfg.CompositeThreshold))
 
 	// Log which configured metrics are missing from values
 	missingMetrics := []string{}
@@ -157,7 +131,7 @@ func (p *processorImp) calculateCompositeGeneric(values map[string]float64) (flo
 	p.logger.Debug("Multi-metric evaluation values",
 		zap.Any("available_values", values),
 		zap.Any("configured_weights", weights),
-		zap.Any("configured_thresholds", p.config.MetricThresholds))
+		zap.Any("configured_thresholds", cfg.MetricThresholds))
 
 	// Sort metrics for consistent evaluation
 	keys := make([]string, 0, len(weights))
@@ -182,7 +156,7 @@ func (p *processorImp) calculateCompositeGeneric(values map[string]float64) (flo
 		}
 
 		// Get threshold (prefer dynamic if enabled)
-		t, hasThreshold := p.config.MetricThresholds[metric]
+		t, hasThreshold := cfg.MetricThresholds[metric]
 		if !hasThreshold {
 			// If no threshold is configured, use a default threshold based on the metric value
 			// This ensures metrics with weights but no thresholds can still contribute
@@ -191,7 +165,7 @@ func (p *processorImp) calculateCompositeGeneric(values map[string]float64) (flo
 				zap.String("metric", metric),
 				zap.Float64("default_threshold", t),
 				zap.Float64("value", v))
-		} else if p.dynamicThresholdsEnabled {
+		} else if p.dynamicThresholdsApply(cfg) {
 			// Use dynamic threshold if available
 			if dt, ok := p.dynamicCustomThresholds[metric]; ok && dt > 0 {
 				t = dt
@@ -223,7 +197,7 @@ func (p *processorImp) calculateCompositeGeneric(values map[string]float64) (flo
 	reasonStr := fmt.Sprintf("Score %.2f = %s", score, strings.Join(parts, " + "))
 
 	// Log at appropriate level
-	threshold := p.config.CompositeThreshold
+	threshold := cfg.CompositeThreshold
 	if threshold <= 0 {
 		threshold = defaultCompositeThreshold
 	}
@@ -170,7 +170,7 @@ func TestExtractMetricValuesComprehensive(t *testing.T) {
 			}
 
 			// Extract metric values
-			values := proc.extractMetricValues(resourceMetrics)
+			values := proc.extractMetricValues(resourceMetrics, proc.config)
 
 			// Verify values match expected results
 			assert.Len(t, values, len(tc.expectedExtracted), "Number of extracted metrics should match")
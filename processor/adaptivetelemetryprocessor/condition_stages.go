@@ -0,0 +1,83 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// applyDropConditions removes the metrics matching a metric drop condition before the resource is
+// evaluated, except in shadow mode. Reports whether the whole resource is dropped: a resource drop
+// condition holds, or drop conditions removed all its metrics.
+func (p *processorImp) applyDropConditions(rm pmetric.ResourceMetrics) bool {
+	if p.dropConditions == nil {
+		return false
+	}
+	if p.dropConditions.resourceHolds(rm.Resource()) {
+		return true
+	}
+	if p.config.Mode == modeShadow || !p.dropConditions.hasMetricConditions() {
+		return false
+	}
+
+	removed := false
+	rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+		scopeRemoved := false
+		sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+			drop := p.dropConditions.metricHolds(rm, sm, m)
+			scopeRemoved = scopeRemoved || drop
+			return drop
+		})
+		removed = removed || scopeRemoved
+		return scopeRemoved && sm.Metrics().Len() == 0
+	})
+	return removed && rm.ScopeMetrics().Len() == 0
+}
+
+// applyKeepConditions reports whether a keep condition holds for a resource or some of its metrics.
+// When only metric conditions hold, the other metrics are removed, except in shadow mode.
+func (p *processorImp) applyKeepConditions(rm pmetric.ResourceMetrics) bool {
+	if p.keepConditions == nil {
+		return false
+	}
+	if p.keepConditions.resourceHolds(rm.Resource()) {
+		return true
+	}
+	kept := p.conditionKeptMetrics(rm)
+	if len(kept) == 0 {
+		return false
+	}
+	if p.config.Mode != modeShadow {
+		keepMetrics(rm, kept)
+	}
+	return true
+}
+
+// isKeptByConditions reports whether a resource keep condition holds for a resource
+func (p *processorImp) isKeptByConditions(resource pcommon.Resource) bool {
+	return p.keepConditions.resourceHolds(resource)
+}
+
+// isDroppedByConditions reports whether a resource drop condition holds for a resource
+func (p *processorImp) isDroppedByConditions(resource pcommon.Resource) bool {
+	return p.dropConditions.resourceHolds(resource)
+}
+
+// conditionKeptMetrics returns the metrics of a resource matching a metric keep condition
+func (p *processorImp) conditionKeptMetrics(rm pmetric.ResourceMetrics) []string {
+	if !p.keepConditions.hasMetricConditions() {
+		return nil
+	}
+	var kept []string
+	for i := 0; i < rm.ScopeMetrics().Len(); i++ {
+		sm := rm.ScopeMetrics().At(i)
+		for j := 0; j < sm.Metrics().Len(); j++ {
+			if p.keepConditions.metricHolds(rm, sm, sm.Metrics().At(j)) {
+				kept = append(kept, sm.Metrics().At(j).Name())
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,95 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/filterottl"
+)
+
+// conditionSet holds the keep or drop conditions of a configuration field, built with OTTL like the
+// conditions of the filter processor. Conditions valid in the resource context hold for a resource;
+// the others are parsed in the metric context and hold for each metric of a resource.
+type conditionSet struct {
+	resource *ottl.ConditionSequence[*ottlresource.TransformContext]
+	metric   *ottl.ConditionSequence[*ottlmetric.TransformContext]
+}
+
+// newConditionSet parses the conditions of a configuration field, nil when there are none.
+// A condition failing to evaluate, such as a comparison of mismatched types, does not hold.
+func newConditionSet(field string, conditions []string, set component.TelemetrySettings) (*conditionSet, error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	resourceParser, err := ottlresource.NewParser(filterottl.StandardResourceFuncs(), set)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+	var resourceConditions, metricConditions []string
+	for _, c := range conditions {
+		if _, err := resourceParser.ParseCondition(c); err == nil {
+			resourceConditions = append(resourceConditions, c)
+		} else {
+			metricConditions = append(metricConditions, c)
+		}
+	}
+
+	cs := &conditionSet{}
+	if len(resourceConditions) > 0 {
+		if cs.resource, err = filterottl.NewBoolExprForResource(resourceConditions, filterottl.StandardResourceFuncs(), ottl.IgnoreError, set); err != nil {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	if len(metricConditions) > 0 {
+		if cs.metric, err = filterottl.NewBoolExprForMetric(metricConditions, filterottl.StandardMetricFuncs(), ottl.IgnoreError, set); err != nil {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	return cs, nil
+}
+
+// noSchemaURL stands in for the resource container of a resource evaluated on its own, such as
+// the resource of logs or spans
+type noSchemaURL struct{}
+
+func (noSchemaURL) SchemaUrl() string { return "" }
+
+func (noSchemaURL) SetSchemaUrl(string) {}
+
+// resourceHolds reports whether a resource condition holds for a resource
+func (cs *conditionSet) resourceHolds(resource pcommon.Resource) bool {
+	if cs == nil || cs.resource == nil {
+		return false
+	}
+	tCtx := ottlresource.NewTransformContextPtr(resource, noSchemaURL{})
+	defer tCtx.Close()
+	holds, err := cs.resource.Eval(context.Background(), tCtx)
+	return err == nil && holds
+}
+
+// hasMetricConditions reports whether the set has conditions evaluated per metric
+func (cs *conditionSet) hasMetricConditions() bool {
+	return cs != nil && cs.metric != nil
+}
+
+// metricHolds reports whether a metric condition holds for a metric of a resource
+func (cs *conditionSet) metricHolds(rm pmetric.ResourceMetrics, sm pmetric.ScopeMetrics, m pmetric.Metric) bool {
+	if !cs.hasMetricConditions() {
+		return false
+	}
+	tCtx := ottlmetric.NewTransformContextPtr(rm, sm, m)
+	defer tCtx.Close()
+	holds, err := cs.metric.Eval(context.Background(), tCtx)
+	return err == nil && holds
+}
@@ -0,0 +1,210 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestNewConditionSetErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+	}{
+		{name: "Empty", source: "  "},
+		{name: "Unterminated string", source: `metric.name == "cpu`},
+		{name: "Unsupported path", source: `span.name == "GET"`},
+		{name: "Missing bracket", source: `resource.attributes["host.name" == "a"`},
+		{name: "Invalid regexp", source: `IsMatch(metric.name, "(")`},
+		{name: "Assignment", source: `metric.name = "a"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newConditionSet("drop_conditions", []string{tc.source}, componenttest.NewNopTelemetrySettings())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "drop_conditions")
+		})
+	}
+}
+
+func TestConditionEvaluation(t *testing.T) {
+	rm := pmetric.NewResourceMetrics()
+	resource := rm.Resource()
+	resource.Attributes().PutStr("process.owner", "root")
+	resource.Attributes().PutStr("deployment.environment", "prod")
+	resource.Attributes().PutInt("process.pid", 42)
+	sm := rm.ScopeMetrics().AppendEmpty()
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("process.open_file_descriptors")
+	metric.SetUnit("{count}")
+
+	testCases := []struct {
+		source     string
+		expected   bool
+		usesMetric bool
+	}{
+		{source: `resource.attributes["deployment.environment"] == "prod"`, expected: true},
+		{source: `resource.attributes["deployment.environment"] != "prod"`, expected: false},
+		{source: `resource.attributes["process.owner"] == "root" and metric.name == "process.open_file_descriptors"`, expected: true, usesMetric: true},
+		{source: `resource.attributes["process.owner"] == "root" and metric.name == "process.cpu.utilization"`, expected: false, usesMetric: true},
+		{source: `resource.attributes["process.pid"] >= 40 and resource.attributes["process.pid"] < 50`, expected: true},
+		{source: `resource.attributes["process.pid"] == "42"`, expected: false},
+		{source: `resource.attributes["missing"] == nil`, expected: true},
+		{source: `not (resource.attributes["process.owner"] == "root" or metric.unit == "By")`, expected: false, usesMetric: true},
+		{source: `IsMatch(metric.name, "file_desc")`, expected: true, usesMetric: true},
+		{source: `resource.attributes["process.owner"] > "admin"`, expected: true},
+		{source: `false or true and false`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.source, func(t *testing.T) {
+			cs, err := newConditionSet("keep_conditions", []string{tc.source}, componenttest.NewNopTelemetrySettings())
+			require.NoError(t, err)
+			assert.Equal(t, tc.usesMetric, cs.hasMetricConditions())
+			if tc.usesMetric {
+				assert.Equal(t, tc.expected, cs.metricHolds(rm, sm, metric))
+				assert.False(t, cs.resourceHolds(resource), "a metric condition never holds for a whole resource")
+			} else {
+				assert.Equal(t, tc.expected, cs.resourceHolds(resource))
+			}
+		})
+	}
+}
+
+func TestNoConditions(t *testing.T) {
+	cs, err := newConditionSet("keep_conditions", nil, componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	assert.Nil(t, cs)
+	assert.False(t, cs.resourceHolds(pcommon.NewResource()))
+	assert.False(t, cs.hasMetricConditions())
+}
+
+// addGauge adds an integer gauge to the last resource of md
+func addGauge(md pmetric.Metrics, name string, value int64) {
+	rm := md.ResourceMetrics().At(md.ResourceMetrics().Len() - 1)
+	m := rm.ScopeMetrics().At(0).Metrics().AppendEmpty()
+	m.SetName(name)
+	m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(value)
+}
+
+func TestKeepConditionIncludesResource(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		KeepConditions: []string{`resource.attributes["deployment.environment"] == "prod"`},
+	})
+
+	md := createTestProcessMetrics("/usr/bin/api", 10, 1)
+	md.ResourceMetrics().At(0).Resource().Attributes().PutStr("deployment.environment", "prod")
+	addProcessToMetrics(md, "/usr/bin/batch", 11, 1)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	require.Len(t, pids, 1)
+	assert.Contains(t, pids[10], `"stage":"keep_condition"`)
+	assert.Contains(t, pids[10], `"keep_condition":1`, "counted in stage hits")
+}
+
+func TestKeepConditionKeepsMatchingMetrics(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		KeepConditions: []string{`resource.attributes["process.owner"] == "root" and metric.name == "process.open_file_descriptors"`},
+	})
+
+	md := createTestProcessMetrics("/usr/bin/agent", 10, 1)
+	md.ResourceMetrics().At(0).Resource().Attributes().PutStr("process.owner", "root")
+	addGauge(md, "process.open_file_descriptors", 900)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process.open_file_descriptors"}, metricNames(t, out))
+}
+
+func TestKeepConditionBeforeThresholds(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		KeepConditions: []string{`metric.name == "process.open_file_descriptors"`},
+	})
+
+	// Above its threshold, the resource is still kept by the condition, with only the matching metric
+	md := createTestProcessMetrics("/usr/bin/agent", 10, 80)
+	addGauge(md, "process.open_file_descriptors", 900)
+	id := buildResourceIdentity(md.ResourceMetrics().At(0).Resource())
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process.open_file_descriptors"}, metricNames(t, out))
+	assert.Contains(t, forwardedPIDs(out)[10], `"stage":"keep_condition"`)
+	require.Contains(t, p.trackedEntities, id)
+	assert.False(t, p.trackedEntities[id].ThresholdActive, "no threshold stage ran")
+}
+
+func TestDropConditionTakesPriority(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		IncludeProcessList: []string{"/usr/sbin/nginx"},
+		HeartbeatInterval:  time.Minute,
+		KeepConditions:     []string{`resource.attributes["process.owner"] == "root"`},
+		DropConditions:     []string{`IsMatch(resource.attributes["process.executable.path"], "nginx$")`},
+	})
+
+	md := createTestProcessMetrics("/usr/sbin/nginx", 10, 95)
+	md.ResourceMetrics().At(0).Resource().Attributes().PutStr("process.owner", "root")
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+	assert.Empty(t, p.trackedEntities)
+
+	forward, _ := p.shouldForwardSignalResource(md.ResourceMetrics().At(0).Resource())
+	assert.False(t, forward)
+}
+
+func TestDropConditionRemovesMetrics(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		DropConditions: []string{`metric.name == "process.memory.usage"`},
+	})
+
+	md := createTestProcessMetrics("/usr/bin/hog", 10, 95)
+	addGauge(md, "process.memory.usage", 1<<30)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process.cpu.utilization"}, metricNames(t, out))
+
+	// A resource left without metrics is dropped
+	md = createTestProcessMetrics("/usr/bin/hog", 10, 95)
+	md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).SetName("process.memory.usage")
+	out, err = p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+}
+
+func TestDropConditionInShadowMode(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		Mode:           modeShadow,
+		DropConditions: []string{`resource.attributes["process.pid"] == 10`, `metric.name == "process.memory.usage"`},
+	})
+
+	md := createTestProcessMetrics("/usr/bin/hog", 10, 95)
+	addProcessToMetrics(md, "/usr/bin/other", 11, 95)
+	addGauge(md, "process.memory.usage", 1<<30)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	require.Len(t, pids, 2)
+	assert.Contains(t, pids[10], `"stage":"drop_condition"`)
+	assert.Contains(t, pids[10], `"decision":"drop"`)
+	assert.Contains(t, pids[11], `"decision":"keep"`)
+	for i := 0; i < out.ResourceMetrics().Len(); i++ {
+		if pid, _ := out.ResourceMetrics().At(i).Resource().Attributes().Get("process.pid"); pid.Int() == 11 {
+			assert.Equal(t, 2, countMetricsInResource(out.ResourceMetrics().At(i)), "shadow mode does not remove metrics")
+		}
+	}
+}
@@ -4,7 +4,13 @@
 package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
 )
 
 // Config is populated from the Collector YAML under:
@@ -31,6 +37,13 @@ import (
 //       process.cpu.utilization: 1.0
 //     max_thresholds:
 //       process.cpu.utilization: 20.0
+//     dynamic_threshold_mode: seasonal    # global (default) or seasonal (thresholds per entity and time bucket)
+//     seasonality: hour_of_day            # hour_of_day (default) or hour_of_week
+//     seasonal_window_size: 30            # daily summaries kept per bucket (capped)
+//     seasonal_min_samples: 5             # past days needed before a bucket's threshold is used
+//     dynamic_threshold_percentile: 95    # optional: target the p95 instead of mean * 1.2
+//     max_datapoints_per_minute: 2000     # optional: tune the thresholds to hold each host under this volume
+//     target_output_ratio: 0.2            # or: tune the thresholds to forward this share of resources
 //
 //     # Anomaly detection (optional)
 //     enable_anomaly_detection: true
@@ -38,25 +51,136 @@ import (
 //     anomaly_change_threshold: 200.0     # percentage spike over rolling avg
 //     anomaly_min_data_points: 3          # minimum data points before detection starts
 //                                         # must be <= anomaly_history_size
+//     anomaly_method: zscore              # percent_change (default), zscore, mad or ewma
+//     anomaly_methods:                    # per-metric override of anomaly_method
+//       process.cpu.utilization: mad      # robust to bursty series
+//     anomaly_score_threshold: 3.0        # deviations above baseline for zscore/mad/ewma
+//     anomaly_ewma_alpha: 0.3             # smoothing factor for ewma
+//     max_observed_entities: 10000        # excluded entities whose history is kept (capped at 100000)
+//     observed_retention_minutes: 60      # history of an unseen excluded entity is dropped after this
+//
+//     # Heartbeats for excluded entities (optional)
+//     heartbeat_interval: 5m              # forward each excluded entity once per interval (disabled by default)
+//     heartbeat_metrics:                  # only these metrics in heartbeats (all metrics when empty)
+//       - process.cpu.utilization
+//
+//     # Top N processes per host (optional)
+//     top_n:                              # always include the N processes of each host with the highest value
+//       process.cpu.utilization: 10
+//       process.memory.usage: 10
+//
+//     # Hysteresis of the threshold stages (optional)
+//     exit_thresholds:                    # an included entity stays while a metric is at or above this
+//       process.cpu.utilization: 3.0      # (defaults to the metric threshold)
+//     enter_breaches: 3                   # evaluations in a row at or above a threshold to enter (default 1)
+//     exit_quiet_intervals: 5             # evaluations in a row below exit thresholds to leave (default 1)
+//
+//     # Metric-level filtering (optional)
+//     metric_level_filtering: true        # included resources keep only the metrics that triggered inclusion
+//     always_send_metrics:                # plus these metrics, e.g. identity metrics
+//       - process.uptime
+//     other_metrics_interval: 10m         # forward all metrics once per interval (other metrics dropped by default)
+//
+//     # Excluded processes (optional)
+//     excluded_action: aggregate          # drop (default) or aggregate: merge excluded processes into one
+//                                         # process.executable.name=_other resource per host
+//     aggregate_functions:                # how the _other resource merges points: sum (default) or max
+//       process.cpu.utilization: max      # busiest excluded process instead of their total
+//
+//     # Background maintenance (optional)
+//     persist_interval: 1m                # how often state is saved (minimum 1s)
+//     cleanup_interval: 1m                # how often expired state is removed (minimum 1s)
+//     threshold_update_interval: 1m       # how often dynamic thresholds are recalculated (minimum 1s)
+//
+//     # Hot-reloaded thresholds (optional)
+//     thresholds_file: /etc/atp/thresholds.yaml  # overrides metric_thresholds, weights, composite_threshold,
+//                                         # min_thresholds and max_thresholds without a restart
+//     thresholds_file_check_interval: 10s # how often the file is checked for changes (minimum 1s)
+//
+//     # Inspection endpoint (optional)
+//     inspection_endpoint: localhost:55690 # serves tracked entities, thresholds and decision explanations as JSON
+//
+//     # Tracked entity budget (optional)
+//     max_tracked_entities: 10000         # capped at 100000
+//     eviction_policy: lru                # lru (default), least_interesting or oldest_exceeded
+//
+//     # Metric values (optional)
+//     metric_aggregations:                # how data points combine: sum (default), max, avg or last
+//       system.cpu.utilization: max      # per-state points would add up to more than 100%
+//     histogram_statistics:               # value of histogram/summary points: mean (default), count, p50, p95 or p99
+//       http.server.request.duration: p95
+//     cumulative_conversions:             # monotonic cumulative sums: rate (default, per second), delta or none
+//       process.cpu.time: rate
+//       system.network.io: delta
 //
 //     # Include list - processes that always bypass filters (optional)
 //     # SECURITY: Use full paths for production to prevent process name spoofing
 //     include_process_list:               # List of process names/paths to always include
 //       - "/usr/sbin/nginx"               # Full path (RECOMMENDED) - prevents /tmp/nginx spoofing
 //       - "/usr/bin/postgres"             # Full path matching is more secure
-//       - "redis-server"                  # Basename only - less secure, matches any path
+//       - "redis-server"                  # Basename only - ignored unless allow_basename_matches is set
 //     # Matching behavior:
 //     #   - Entries with "/" or "\" are treated as full paths (exact match required)
 //     #   - Entries without path separators match basename only (can be spoofed)
+//     allow_basename_matches: false       # opt in to matching by process name (default false)
+//
+//     # Include and exclude selectors (optional), with the syntax of rule selectors
+//     include:                            # always forwarded; any selector may match
+//       - executable_paths: ["/opt/*/bin/java"]
+//       - attributes:
+//           k8s.deployment.name: "checkout-*"
+//     exclude:                            # always dropped, over every other stage
+//       - match_type: regexp              # glob (default) or regexp, anchored to the whole value
+//         attributes:
+//           container.image.name: ".*/debug-tools:.*"
+//           process.command_args: "--dry-run" # slices match when any element does
+//
+//     # Keep and drop conditions (optional), as OTTL conditions in the resource or metric context
+//     keep_conditions:                    # forwarded whatever their values; metric conditions keep the matching metrics
+//       - 'resource.attributes["deployment.environment"] == "prod"'
+//       - 'resource.attributes["process.owner"] == "root" and metric.name == "process.open_file_descriptors"'
+//     drop_conditions:                    # dropped before any stage but exclude; metric conditions drop the matching metrics
+//       - 'IsMatch(resource.attributes["process.command_line"], "--healthcheck")'
+//
+//     # Rules - per resource type settings, first matching rule wins (optional)
+//     # Unset settings fall back to the top-level values above.
+//     rules:
+//       - name: databases
+//         match:
+//           resource_type: process        # getResourceType result, glob
+//           executable_paths:             # globs on process.executable.path
+//             - "/usr/sbin/mysqld"
+//             - "/usr/lib/postgresql/*/bin/postgres"
+//           attributes:                   # all must match, values are globs
+//             k8s.namespace.name: "prod-*"
+//         metric_thresholds:              # replaces the top-level thresholds for matched resources
+//           process.cpu.utilization: 40.0
+//         retention_minutes: 10
+//         enable_anomaly_detection: true
+//         anomaly_method: mad
+//
+//     # Rollout (optional)
+//     mode: shadow                        # enforce (default) drops filtered resources; shadow forwards
+//                                         # everything, annotated with the decision and stage
+//
+//     # Output of ATP details (stage, thresholds, composite score, anomaly reason) on exported resources
+//     attributes_mode: map                # json (default): process.atp JSON string
+//                                         # map: process.atp native map attribute
+//                                         # flat: atp.* attributes, e.g. atp.stage
+//                                         # none: strip process.atp and process.atp.enabled
 //
 //     # Retention & persistence
 //     retention_minutes: 30               # how long since last exceed to keep entity (capped)
 //     enable_storage: true                # Enable/disable state persistence (defaults to true)
-//     # Storage path is automatically determined based on platform:
+//     # Storage path defaults to a platform-specific location:
 //     #   - Linux/Unix: /var/lib/nrdot-collector/adaptiveprocess.db
 //     #   - Windows: %LOCALAPPDATA%\nrdot-collector\adaptiveprocess.db
+//     storage_path: /var/lib/nrdot-collector/atp/state.db  # Optional: must be an absolute path under the
+//                                         # platform directory above; symlinks are rejected
 //     # The directory will be created automatically if it doesn't exist.
 //     # If storage creation fails, the processor will continue without persistence.
+//     storage: file_storage/atp            # Optional: persist through a storage extension instead,
+//                                         # one key per entity; the extension must be enabled in service
 //
 // Example pipeline wiring:
 // service:
@@ -65,6 +189,12 @@ import (
 //       processors: [adaptivetelemetryprocessor]
 //       receivers: [...]
 //       exporters: [...]
+//     # Optional: logs and traces pipelines using the same processor ID forward a
+//     # process's logs/spans only while the metrics pipeline considers it hot.
+//     logs:
+//       processors: [adaptivetelemetryprocessor]
+//       receivers: [...]
+//       exporters: [...]
 
 // Config defines processor configuration.
 // All numeric fields are validated & capped during processor construction so startup does not fail
@@ -76,12 +206,33 @@ type Config struct {
 	// Common settings
 	RetentionMinutes int64 `mapstructure:"retention_minutes"`
 	EnableStorage    *bool `mapstructure:"enable_storage"`
+	// StoragePath overrides the platform default path of the state file.
+	// It must be under the platform-specific storage directory.
+	StoragePath string `mapstructure:"storage_path"`
+	// StorageID is the component ID of a storage extension used to persist tracked entities.
+	// When unset, state is written to a JSON file at the platform default path.
+	StorageID *component.ID `mapstructure:"storage"`
 
 	// Dynamic thresholds
 	EnableDynamicThresholds bool               `mapstructure:"enable_dynamic_thresholds"`
 	DynamicSmoothingFactor  float64            `mapstructure:"dynamic_smoothing_factor"`
 	MinThresholds           map[string]float64 `mapstructure:"min_thresholds"`
 	MaxThresholds           map[string]float64 `mapstructure:"max_thresholds"`
+	// DynamicThresholdMode is global (one threshold per metric) or seasonal, which additionally learns
+	// per entity and time bucket what is normal, uses it as the entity's dynamic threshold once the bucket
+	// has SeasonalMinSamples days, and suppresses anomalies within it.
+	DynamicThresholdMode string `mapstructure:"dynamic_threshold_mode"`
+	Seasonality          string `mapstructure:"seasonality"`          // hour_of_day or hour_of_week
+	SeasonalWindowSize   int    `mapstructure:"seasonal_window_size"` // daily summaries kept per bucket
+	SeasonalMinSamples   int    `mapstructure:"seasonal_min_samples"` // past days needed per bucket
+	// DynamicThresholdPercentile, when set, targets this percentile of observed values instead of
+	// scaling the average by a fixed factor
+	DynamicThresholdPercentile float64 `mapstructure:"dynamic_threshold_percentile"`
+	// MaxDatapointsPerMinute and TargetOutputRatio set a volume budget: the dynamic thresholds are
+	// adjusted every minute to hold the busiest host near that many forwarded data points per minute,
+	// or the share of forwarded resources near the ratio, within MinThresholds and MaxThresholds
+	MaxDatapointsPerMinute int     `mapstructure:"max_datapoints_per_minute"`
+	TargetOutputRatio      float64 `mapstructure:"target_output_ratio"`
 
 	// Multi metric (composite) scoring
 	EnableMultiMetric  bool               `mapstructure:"enable_multi_metric"`
@@ -93,14 +244,136 @@ type Config struct {
 	AnomalyHistorySize     int     `mapstructure:"anomaly_history_size"`
 	AnomalyChangeThreshold float64 `mapstructure:"anomaly_change_threshold"`
 	AnomalyMinDataPoints   int     `mapstructure:"anomaly_min_data_points"` // Minimum data points before detection starts
+	// AnomalyMethod selects how the current value is compared with MetricHistory:
+	// percent_change (over the rolling mean), zscore, mad (median absolute deviation) or ewma.
+	AnomalyMethod string `mapstructure:"anomaly_method"`
+	// AnomalyMethods overrides AnomalyMethod per metric
+	AnomalyMethods map[string]string `mapstructure:"anomaly_methods"`
+	// AnomalyScoreThreshold is the number of deviations above the baseline flagged by zscore, mad and ewma
+	AnomalyScoreThreshold float64 `mapstructure:"anomaly_score_threshold"`
+	AnomalyEWMAAlpha      float64 `mapstructure:"anomaly_ewma_alpha"` // Smoothing factor for the ewma method
+	// Forward every excluded entity once per interval (0 disables), with only HeartbeatMetrics when set
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	HeartbeatMetrics  []string      `mapstructure:"heartbeat_metrics"`
+	// TopN includes, per host and batch, the N processes with the highest value of each metric
+	TopN map[string]int `mapstructure:"top_n"`
+	// Hysteresis of the threshold stages: an entity enters after EnterBreaches evaluations in a row at or above a
+	// threshold, and leaves after ExitQuietIntervals evaluations in a row with every metric below its ExitThresholds entry
+	// (scaled by the dynamic threshold over the static one when dynamic thresholds apply)
+	ExitThresholds     map[string]float64 `mapstructure:"exit_thresholds"`
+	EnterBreaches      int                `mapstructure:"enter_breaches"`
+	ExitQuietIntervals int                `mapstructure:"exit_quiet_intervals"`
+	// Metric-level filtering: resources included by an evaluation stage keep only the metrics that triggered
+	// their inclusion and AlwaysSendMetrics. The other metrics are dropped, or forwarded once per OtherMetricsInterval.
+	MetricLevelFiltering bool          `mapstructure:"metric_level_filtering"`
+	AlwaysSendMetrics    []string      `mapstructure:"always_send_metrics"`
+	OtherMetricsInterval time.Duration `mapstructure:"other_metrics_interval"`
+	// ExcludedAction is drop (default) or aggregate, which merges the excluded processes of each host into
+	// one _other resource, combining points per metric with AggregateFunctions: sum (default) or max
+	ExcludedAction     string            `mapstructure:"excluded_action"`
+	AggregateFunctions map[string]string `mapstructure:"aggregate_functions"`
+
+	// Intervals of the maintenance loops launched by Start
+	PersistInterval         time.Duration `mapstructure:"persist_interval"`
+	CleanupInterval         time.Duration `mapstructure:"cleanup_interval"`
+	ThresholdUpdateInterval time.Duration `mapstructure:"threshold_update_interval"`
+
+	// ThresholdsFile is a YAML or JSON file overriding metric_thresholds, weights, composite_threshold,
+	// min_thresholds and max_thresholds. It is checked every ThresholdsFileCheckInterval, and a change
+	// is applied without a restart; an invalid change is rejected and the previous thresholds are kept.
+	ThresholdsFile              string        `mapstructure:"thresholds_file"`
+	ThresholdsFileCheckInterval time.Duration `mapstructure:"thresholds_file_check_interval"`
+
+	// InspectionEndpoint is the host:port of a local HTTP endpoint serving tracked entities, thresholds
+	// and why each resource was kept or dropped, as JSON. Empty (the default) disables it.
+	InspectionEndpoint string `mapstructure:"inspection_endpoint"`
+
+	// Budget for tracked entities (and seasonal profiles), and the policy choosing the evicted ones: lru (default),
+	// least_interesting or oldest_exceeded
+	MaxTrackedEntities int    `mapstructure:"max_tracked_entities"`
+	EvictionPolicy     string `mapstructure:"eviction_policy"`
+	// Observed tier: rolling history of excluded entities, so anomalies fire on an idle entity's first spike
+	MaxObservedEntities      int   `mapstructure:"max_observed_entities"`      // least recently seen are evicted beyond this
+	ObservedRetentionMinutes int64 `mapstructure:"observed_retention_minutes"` // how long an unseen entity's history is kept
+
+	// How the data points of a metric are combined into one value: sum (default), max, avg or last
+	MetricAggregations map[string]string `mapstructure:"metric_aggregations"`
+	// Value derived from histogram, exponential histogram and summary data points: mean (default), count, p50, p95 or p99
+	HistogramStatistics map[string]string `mapstructure:"histogram_statistics"`
+	// Value evaluated for monotonic cumulative sums: rate (default, per second), delta or none (lifetime total)
+	CumulativeConversions map[string]string `mapstructure:"cumulative_conversions"`
+
+	// Rules apply per-resource settings; the first rule whose selector matches a resource is used
+	Rules []RuleConfig `mapstructure:"rules"`
 
 	// Include list - processes that should always be monitored (bypass all filters)
 	IncludeProcessList []string `mapstructure:"include_process_list"`
+	// Include selects resources that are always forwarded, like include_process_list
+	Include []RuleSelector `mapstructure:"include"`
+	// Exclude selects resources that are always dropped. It takes priority over every stage,
+	// including the include lists, heartbeats and top_n.
+	Exclude []RuleSelector `mapstructure:"exclude"`
+	// KeepConditions and DropConditions are OTTL conditions in the resource context, holding for a resource,
+	// or in the metric context, holding for each metric. A drop condition takes priority over every stage
+	// but exclude; keep conditions are evaluated after the include lists and before the threshold stages.
+	KeepConditions []string `mapstructure:"keep_conditions"`
+	DropConditions []string `mapstructure:"drop_conditions"`
+	// AllowBasenameMatches lets include_process_list entries without a path separator and selector
+	// executable_names match by process name, which any process can spoof
+	AllowBasenameMatches bool `mapstructure:"allow_basename_matches"`
+
+	// Mode is enforce (default) to drop filtered resources, or shadow to forward every resource annotated
+	// with the decision and stage ATP would have applied
+	Mode string `mapstructure:"mode"`
+
+	// AttributesMode controls how ATP details are written on exported resources: json (default), map, flat or none
+	AttributesMode string `mapstructure:"attributes_mode"`
 
 	// Debug options
 	DebugShowAllFilterStages bool `mapstructure:"debug_show_all_filter_stages"`
 }
 
+// RuleConfig overrides evaluation settings for resources matched by its selector.
+// Zero values inherit the top-level setting. Maps replace, rather than merge with, the top-level maps.
+type RuleConfig struct {
+	Name  string       `mapstructure:"name"`
+	Match RuleSelector `mapstructure:"match"`
+
+	// MetricThresholds are always evaluated as static thresholds; dynamic thresholds
+	// only apply to resources whose rule does not set its own thresholds
+	MetricThresholds   map[string]float64 `mapstructure:"metric_thresholds"`
+	Weights            map[string]float64 `mapstructure:"weights"`
+	CompositeThreshold float64            `mapstructure:"composite_threshold"`
+	RetentionMinutes   int64              `mapstructure:"retention_minutes"`
+
+	EnableAnomalyDetection *bool             `mapstructure:"enable_anomaly_detection"`
+	AnomalyChangeThreshold float64           `mapstructure:"anomaly_change_threshold"`
+	AnomalyMethod          string            `mapstructure:"anomaly_method"`
+	AnomalyMethods         map[string]string `mapstructure:"anomaly_methods"`
+	AnomalyScoreThreshold  float64           `mapstructure:"anomaly_score_threshold"`
+}
+
+// RuleSelector matches resources. All set criteria must match; an empty selector matches every resource.
+type RuleSelector struct {
+	// MatchType is how the patterns below are matched: glob (default) or regexp, anchored to the whole value
+	MatchType string `mapstructure:"match_type"`
+	// ResourceType is a pattern matched against the resource type (process, cpu, disk, service:<name>, ...)
+	ResourceType string `mapstructure:"resource_type"`
+	// ExecutablePaths are patterns matched against the process executable path; any may match
+	ExecutablePaths []string `mapstructure:"executable_paths"`
+	// ExecutableNames are patterns matched against the process name; any may match.
+	// Any process can take a name, so these require allow_basename_matches.
+	ExecutableNames []string `mapstructure:"executable_names"`
+	// Attributes maps resource attribute keys to patterns their values must match.
+	// A slice value matches when any element does.
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
+// isEmpty reports whether the selector sets no criteria
+func (s *RuleSelector) isEmpty() bool {
+	return s.ResourceType == "" && len(s.ExecutablePaths) == 0 && len(s.ExecutableNames) == 0 && len(s.Attributes) == 0
+}
+
 // Default / cap constants
 const (
 	defaultRetentionMinutes       int64   = 30
@@ -111,6 +384,25 @@ const (
 	defaultAnomalyHistorySize     int     = 10
 	defaultAnomalyChangeThreshold float64 = 200.0
 	defaultAnomalyMinDataPoints   int     = 3 // Recommended minimum data points before anomaly detection starts
+	defaultAnomalyScoreThreshold  float64 = 3.0
+	defaultAnomalyEWMAAlpha       float64 = 0.3
+	defaultSeasonalWindowSize     int     = 30
+	maxSeasonalWindowSize         int     = 120
+	defaultSeasonalMinSamples     int     = 5
+
+	defaultPersistInterval             = time.Minute
+	defaultCleanupInterval             = time.Minute
+	defaultThresholdUpdateInterval     = dynamicUpdateIntervalSecs * time.Second
+	defaultThresholdsFileCheckInterval = 10 * time.Second
+	minMaintenanceInterval             = time.Second
+
+	defaultMaxTrackedEntities int = 10000
+	maxTrackedEntities        int = 100000
+
+	defaultMaxObservedEntities      int   = 10000
+	maxObservedEntities             int   = 100000
+	defaultObservedRetentionMinutes int64 = 60
+	maxObservedRetentionMinutes     int64 = 24 * 60
 )
 
 // Normalize applies defaults & caps. Must be called before processor usage. It does not log; caller should.
@@ -127,6 +419,9 @@ func (cfg *Config) Normalize() {
 	if cfg.Weights == nil {
 		cfg.Weights = map[string]float64{}
 	}
+	if cfg.AnomalyMethods == nil {
+		cfg.AnomalyMethods = map[string]string{}
+	}
 
 	if cfg.RetentionMinutes <= 0 {
 		cfg.RetentionMinutes = defaultRetentionMinutes
@@ -141,6 +436,25 @@ func (cfg *Config) Normalize() {
 		}
 	}
 
+	if cfg.DynamicThresholdMode == "" {
+		cfg.DynamicThresholdMode = dynamicThresholdModeGlobal
+	}
+	if cfg.Seasonality == "" {
+		cfg.Seasonality = seasonalityHourOfDay
+	}
+	if cfg.SeasonalWindowSize <= 0 {
+		cfg.SeasonalWindowSize = defaultSeasonalWindowSize
+	}
+	if cfg.SeasonalWindowSize > maxSeasonalWindowSize {
+		cfg.SeasonalWindowSize = maxSeasonalWindowSize
+	}
+	if cfg.SeasonalMinSamples <= 0 {
+		cfg.SeasonalMinSamples = defaultSeasonalMinSamples
+	}
+	if cfg.SeasonalMinSamples > cfg.SeasonalWindowSize {
+		cfg.SeasonalMinSamples = cfg.SeasonalWindowSize
+	}
+
 	if cfg.EnableMultiMetric {
 		if cfg.CompositeThreshold <= 0 {
 			cfg.CompositeThreshold = defaultCompositeThreshold
@@ -184,6 +498,55 @@ func (cfg *Config) Normalize() {
 			cfg.AnomalyMinDataPoints = defaultAnomalyMinDataPoints
 		}
 	}
+
+	cfg.PersistInterval = normalizeInterval(cfg.PersistInterval, defaultPersistInterval)
+	cfg.CleanupInterval = normalizeInterval(cfg.CleanupInterval, defaultCleanupInterval)
+	cfg.ThresholdUpdateInterval = normalizeInterval(cfg.ThresholdUpdateInterval, defaultThresholdUpdateInterval)
+	cfg.ThresholdsFileCheckInterval = normalizeInterval(cfg.ThresholdsFileCheckInterval, defaultThresholdsFileCheckInterval)
+
+	if cfg.MaxTrackedEntities <= 0 {
+		cfg.MaxTrackedEntities = defaultMaxTrackedEntities
+	}
+	if cfg.MaxTrackedEntities > maxTrackedEntities {
+		cfg.MaxTrackedEntities = maxTrackedEntities
+	}
+	if cfg.EvictionPolicy == "" {
+		cfg.EvictionPolicy = evictionPolicyLRU
+	}
+
+	if cfg.MaxObservedEntities <= 0 {
+		cfg.MaxObservedEntities = defaultMaxObservedEntities
+	}
+	if cfg.MaxObservedEntities > maxObservedEntities {
+		cfg.MaxObservedEntities = maxObservedEntities
+	}
+	if cfg.ObservedRetentionMinutes <= 0 {
+		cfg.ObservedRetentionMinutes = defaultObservedRetentionMinutes
+	}
+	if cfg.ObservedRetentionMinutes > maxObservedRetentionMinutes {
+		cfg.ObservedRetentionMinutes = maxObservedRetentionMinutes
+	}
+
+	if cfg.AnomalyMethod == "" {
+		cfg.AnomalyMethod = anomalyMethodPercentChange
+	}
+	if cfg.AnomalyScoreThreshold <= 0 {
+		cfg.AnomalyScoreThreshold = defaultAnomalyScoreThreshold
+	}
+	if cfg.AnomalyEWMAAlpha <= 0 || cfg.AnomalyEWMAAlpha > 1 {
+		cfg.AnomalyEWMAAlpha = defaultAnomalyEWMAAlpha
+	}
+}
+
+// normalizeInterval returns interval, def when it is unset, and at least minMaintenanceInterval
+func normalizeInterval(interval, def time.Duration) time.Duration {
+	if interval <= 0 {
+		return def
+	}
+	if interval < minMaintenanceInterval {
+		return minMaintenanceInterval
+	}
+	return interval
 }
 
 // Validate performs strict validation after normalization for values that must not be negative.
@@ -214,12 +577,145 @@ func (cfg *Config) Validate() error {
 			return fmt.Errorf("anomaly_min_data_points must be > 0, got %d", cfg.AnomalyMinDataPoints)
 		}
 	}
+	switch cfg.DynamicThresholdMode {
+	case "", dynamicThresholdModeGlobal, dynamicThresholdModeSeasonal:
+	default:
+		return fmt.Errorf("dynamic_threshold_mode must be %q or %q, got %q", dynamicThresholdModeGlobal, dynamicThresholdModeSeasonal, cfg.DynamicThresholdMode)
+	}
+	switch cfg.Seasonality {
+	case "", seasonalityHourOfDay, seasonalityHourOfWeek:
+	default:
+		return fmt.Errorf("seasonality must be %q or %q, got %q", seasonalityHourOfDay, seasonalityHourOfWeek, cfg.Seasonality)
+	}
+	if cfg.DynamicThresholdPercentile < 0 || cfg.DynamicThresholdPercentile > 100 {
+		return fmt.Errorf("dynamic_threshold_percentile must be between 0 and 100, got %v", cfg.DynamicThresholdPercentile)
+	}
+	if cfg.MaxDatapointsPerMinute < 0 {
+		return fmt.Errorf("max_datapoints_per_minute must be >= 0, got %d", cfg.MaxDatapointsPerMinute)
+	}
+	if cfg.TargetOutputRatio < 0 || cfg.TargetOutputRatio > 1 {
+		return fmt.Errorf("target_output_ratio must be between 0 and 1, got %v", cfg.TargetOutputRatio)
+	}
+	if cfg.MaxDatapointsPerMinute > 0 && cfg.TargetOutputRatio > 0 {
+		return errors.New("max_datapoints_per_minute and target_output_ratio cannot both be set")
+	}
+	if cfg.volumeBudgetEnabled() && !cfg.EnableDynamicThresholds {
+		return errors.New("max_datapoints_per_minute and target_output_ratio require enable_dynamic_thresholds")
+	}
+	if cfg.AnomalyMethod != "" && !isValidAnomalyMethod(cfg.AnomalyMethod) {
+		return fmt.Errorf("anomaly_method must be one of %v, got %q", validAnomalyMethods, cfg.AnomalyMethod)
+	}
+	for metric, method := range cfg.AnomalyMethods {
+		if !isValidAnomalyMethod(method) {
+			return fmt.Errorf("anomaly_methods[%s] must be one of %v, got %q", metric, validAnomalyMethods, method)
+		}
+	}
+	for metric, aggregation := range cfg.MetricAggregations {
+		if !isValidAggregation(aggregation) {
+			return fmt.Errorf("metric_aggregations[%s] must be one of %v, got %q", metric, validAggregations, aggregation)
+		}
+	}
+	for metric, statistic := range cfg.HistogramStatistics {
+		if !isValidHistogramStatistic(statistic) {
+			return fmt.Errorf("histogram_statistics[%s] must be one of %v, got %q", metric, validHistogramStatistics, statistic)
+		}
+	}
+	for metric, conversion := range cfg.CumulativeConversions {
+		if !isValidCumulativeConversion(conversion) {
+			return fmt.Errorf("cumulative_conversions[%s] must be one of %v, got %q", metric, validCumulativeConversions, conversion)
+		}
+	}
+	switch cfg.AttributesMode {
+	case "", attributesModeJSON, attributesModeMap, attributesModeFlat, attributesModeNone:
+	default:
+		return fmt.Errorf("attributes_mode must be one of %q, %q, %q or %q, got %q",
+			attributesModeJSON, attributesModeMap, attributesModeFlat, attributesModeNone, cfg.AttributesMode)
+	}
+	if cfg.HeartbeatInterval < 0 {
+		return fmt.Errorf("heartbeat_interval must not be negative, got %s", cfg.HeartbeatInterval)
+	}
+	if cfg.InspectionEndpoint != "" {
+		if _, _, err := net.SplitHostPort(cfg.InspectionEndpoint); err != nil {
+			return fmt.Errorf("inspection_endpoint must be host:port, got %q: %w", cfg.InspectionEndpoint, err)
+		}
+	}
+	for metric, n := range cfg.TopN {
+		if n <= 0 {
+			return fmt.Errorf("top_n[%s] must be > 0, got %d", metric, n)
+		}
+	}
+	for metric, exit := range cfg.ExitThresholds {
+		if exit < 0 {
+			return fmt.Errorf("exit_thresholds[%s] must be >= 0, got %v", metric, exit)
+		}
+		if threshold, ok := cfg.MetricThresholds[metric]; ok && exit > threshold {
+			return fmt.Errorf("exit_thresholds[%s] must not exceed its metric threshold %v, got %v", metric, threshold, exit)
+		}
+	}
+	if cfg.EnterBreaches < 0 {
+		return fmt.Errorf("enter_breaches must be >= 0, got %d", cfg.EnterBreaches)
+	}
+	if cfg.ExitQuietIntervals < 0 {
+		return fmt.Errorf("exit_quiet_intervals must be >= 0, got %d", cfg.ExitQuietIntervals)
+	}
+	if cfg.OtherMetricsInterval < 0 {
+		return fmt.Errorf("other_metrics_interval must not be negative, got %s", cfg.OtherMetricsInterval)
+	}
+	switch cfg.ExcludedAction {
+	case "", excludedActionDrop, excludedActionAggregate:
+	default:
+		return fmt.Errorf("excluded_action must be %q or %q, got %q", excludedActionDrop, excludedActionAggregate, cfg.ExcludedAction)
+	}
+	for metric, function := range cfg.AggregateFunctions {
+		if function != aggregateFunctionSum && function != aggregateFunctionMax {
+			return fmt.Errorf("aggregate_functions[%s] must be %q or %q, got %q", metric, aggregateFunctionSum, aggregateFunctionMax, function)
+		}
+	}
+	switch cfg.EvictionPolicy {
+	case "", evictionPolicyLRU, evictionPolicyLeastInteresting, evictionPolicyOldestExceeded:
+	default:
+		return fmt.Errorf("eviction_policy must be one of %q, %q or %q, got %q",
+			evictionPolicyLRU, evictionPolicyLeastInteresting, evictionPolicyOldestExceeded, cfg.EvictionPolicy)
+	}
+	switch cfg.Mode {
+	case "", modeEnforce, modeShadow:
+	default:
+		return fmt.Errorf("mode must be %q or %q, got %q", modeEnforce, modeShadow, cfg.Mode)
+	}
+	if cfg.Mode == modeShadow && cfg.AttributesMode == attributesModeNone {
+		return fmt.Errorf("attributes_mode %q would strip the annotations of shadow mode", attributesModeNone)
+	}
 	if cfg.EnableMultiMetric && cfg.CompositeThreshold <= 0 {
 		return fmt.Errorf("composite_threshold must be > 0, got %f", cfg.CompositeThreshold)
 	}
 
-	// No storage path validation needed - we always use default platform-specific paths
-	// Storage is controlled via EnableStorage flag only
+	if err := validateRules(cfg.Rules); err != nil {
+		return err
+	}
+	if err := cfg.validateSelectors(); err != nil {
+		return err
+	}
+	// Conditions are parsed like the filter processor does, without the collector's telemetry
+	set := component.TelemetrySettings{Logger: zap.NewNop()}
+	if _, err := newConditionSet("keep_conditions", cfg.KeepConditions, set); err != nil {
+		return err
+	}
+	if _, err := newConditionSet("drop_conditions", cfg.DropConditions, set); err != nil {
+		return err
+	}
+
+	if cfg.StorageID != nil && cfg.EnableStorage != nil && !*cfg.EnableStorage {
+		return errors.New("storage cannot be set when enable_storage is false")
+	}
+
+	if cfg.StoragePath != "" {
+		if cfg.StorageID != nil {
+			return errors.New("storage_path and storage cannot both be set")
+		}
+		if err := validateStoragePathSyntax(cfg.StoragePath); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
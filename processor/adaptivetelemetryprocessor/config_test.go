@@ -5,9 +5,11 @@ package adaptivetelemetryprocessor
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
 )
 
 func TestConfigNormalize(t *testing.T) {
@@ -101,6 +103,237 @@ func TestConfigValidate(t *testing.T) {
 			expectError: true,
 			errorString: "composite_threshold must be > 0, got -1.500000",
 		},
+		{
+			name: "Invalid dynamic threshold mode",
+			config: Config{
+				DynamicThresholdMode: "hourly",
+			},
+			expectError: true,
+			errorString: "dynamic_threshold_mode must be",
+		},
+		{
+			name: "Invalid seasonality",
+			config: Config{
+				Seasonality: "day_of_month",
+			},
+			expectError: true,
+			errorString: "seasonality must be",
+		},
+		{
+			name: "Invalid metric aggregation",
+			config: Config{
+				MetricAggregations: map[string]string{"system.cpu.utilization": "median"},
+			},
+			expectError: true,
+			errorString: "metric_aggregations[system.cpu.utilization] must be one of",
+		},
+		{
+			name: "Invalid histogram statistic",
+			config: Config{
+				HistogramStatistics: map[string]string{"http.server.request.duration": "p90"},
+			},
+			expectError: true,
+			errorString: "histogram_statistics[http.server.request.duration] must be one of",
+		},
+		{
+			name: "Both volume budgets",
+			config: Config{
+				EnableDynamicThresholds: true,
+				MaxDatapointsPerMinute:  1000,
+				TargetOutputRatio:       0.2,
+			},
+			expectError: true,
+			errorString: "max_datapoints_per_minute and target_output_ratio cannot both be set",
+		},
+		{
+			name: "Volume budget without dynamic thresholds",
+			config: Config{
+				TargetOutputRatio: 0.2,
+			},
+			expectError: true,
+			errorString: "require enable_dynamic_thresholds",
+		},
+		{
+			name: "Target output ratio above one",
+			config: Config{
+				EnableDynamicThresholds: true,
+				TargetOutputRatio:       1.5,
+			},
+			expectError: true,
+			errorString: "target_output_ratio must be between 0 and 1",
+		},
+		{
+			name: "Invalid drop condition",
+			config: Config{
+				DropConditions: []string{`metric.name == `},
+			},
+			expectError: true,
+			errorString: "drop_conditions: ",
+		},
+		{
+			name: "Inspection endpoint without port",
+			config: Config{
+				InspectionEndpoint: "localhost",
+			},
+			expectError: true,
+			errorString: "inspection_endpoint must be host:port",
+		},
+		{
+			name: "Negative heartbeat interval",
+			config: Config{
+				HeartbeatInterval: -time.Minute,
+			},
+			expectError: true,
+			errorString: "heartbeat_interval must not be negative",
+		},
+		{
+			name: "Zero top N",
+			config: Config{
+				TopN: map[string]int{"process.cpu.utilization": 0},
+			},
+			expectError: true,
+			errorString: "top_n[process.cpu.utilization] must be > 0",
+		},
+		{
+			name: "Exit threshold above metric threshold",
+			config: Config{
+				MetricThresholds: map[string]float64{"process.cpu.utilization": 5.0},
+				ExitThresholds:   map[string]float64{"process.cpu.utilization": 8.0},
+			},
+			expectError: true,
+			errorString: "exit_thresholds[process.cpu.utilization] must not exceed its metric threshold",
+		},
+		{
+			name: "Negative enter breaches",
+			config: Config{
+				EnterBreaches: -1,
+			},
+			expectError: true,
+			errorString: "enter_breaches must be >= 0",
+		},
+		{
+			name: "Negative other metrics interval",
+			config: Config{
+				OtherMetricsInterval: -time.Minute,
+			},
+			expectError: true,
+			errorString: "other_metrics_interval must not be negative",
+		},
+		{
+			name: "Invalid excluded action",
+			config: Config{
+				ExcludedAction: "sample",
+			},
+			expectError: true,
+			errorString: `excluded_action must be "drop" or "aggregate"`,
+		},
+		{
+			name: "Invalid aggregate function",
+			config: Config{
+				AggregateFunctions: map[string]string{"process.cpu.utilization": "mean"},
+			},
+			expectError: true,
+			errorString: "aggregate_functions[process.cpu.utilization] must be",
+		},
+		{
+			name: "Invalid eviction policy",
+			config: Config{
+				EvictionPolicy: "random",
+			},
+			expectError: true,
+			errorString: "eviction_policy must be one of",
+		},
+		{
+			name: "Invalid cumulative conversion",
+			config: Config{
+				CumulativeConversions: map[string]string{"process.cpu.time": "per_minute"},
+			},
+			expectError: true,
+			errorString: "cumulative_conversions[process.cpu.time] must be one of",
+		},
+		{
+			name: "Invalid mode",
+			config: Config{
+				Mode: "dry_run",
+			},
+			expectError: true,
+			errorString: "mode must be",
+		},
+		{
+			name: "Shadow mode with attributes stripped",
+			config: Config{
+				Mode:           "shadow",
+				AttributesMode: "none",
+			},
+			expectError: true,
+			errorString: "would strip the annotations of shadow mode",
+		},
+		{
+			name: "Invalid attributes mode",
+			config: Config{
+				AttributesMode: "yaml",
+			},
+			expectError: true,
+			errorString: "attributes_mode must be one of",
+		},
+		{
+			name: "Percentile out of range",
+			config: Config{
+				DynamicThresholdPercentile: 150,
+			},
+			expectError: true,
+			errorString: "dynamic_threshold_percentile must be between 0 and 100",
+		},
+		{
+			name: "Invalid anomaly method",
+			config: Config{
+				AnomalyMethod: "median",
+			},
+			expectError: true,
+			errorString: "anomaly_method must be one of",
+		},
+		{
+			name: "Invalid per-metric anomaly method",
+			config: Config{
+				AnomalyMethods: map[string]string{"process.cpu.utilization": "stddev"},
+			},
+			expectError: true,
+			errorString: "anomaly_methods[process.cpu.utilization] must be one of",
+		},
+		{
+			name: "Invalid rule",
+			config: Config{
+				Rules: []RuleConfig{{Name: "db", Match: RuleSelector{ResourceType: "[process"}}},
+			},
+			expectError: true,
+			errorString: "rule \"db\": invalid resource_type pattern",
+		},
+		{
+			name: "Storage path outside allowed directory",
+			config: Config{
+				StoragePath: "relative/state.db",
+			},
+			expectError: true,
+			errorString: "storage_path must be an absolute path",
+		},
+		{
+			name: "Storage path together with storage extension",
+			config: Config{
+				StoragePath: "/var/lib/nrdot-collector/state.db",
+				StorageID:   &component.ID{},
+			},
+			expectError: true,
+			errorString: "storage_path and storage cannot both be set",
+		},
+		{
+			name: "Storage extension with storage disabled",
+			config: Config{
+				EnableStorage: ptrBool(false),
+				StorageID:     &component.ID{},
+			},
+			expectError: true,
+			errorString: "storage cannot be set when enable_storage is false",
+		},
 	}
 
 	for _, tc := range testCases {
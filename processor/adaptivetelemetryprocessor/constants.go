@@ -26,6 +26,98 @@ const (
 	stageAnomalyRetention          = "anomaly_retention"           // Retention after anomaly was detected
 	stageStandardRetention         = "standard_retention"          // Retention after threshold exceeded
 	stageResourceProcessingTimeout = "resource_processing_timeout" // Used for all resource types during timeout
+	stageHeartbeat                 = "heartbeat"                   // Excluded resource forwarded once per heartbeat_interval
+	stageAggregated                = "aggregated"                  // Excluded processes rolled up into the _other resource of their host
+	stageTopN                      = "top_n"                       // Among the top N processes of its host for a top_n metric
+	stageExcludeList               = "exclude_list"                // Matched an exclude selector (always dropped)
+	stageKeepCondition             = "keep_condition"              // A keep condition holds for the resource or some of its metrics
+	stageDropCondition             = "drop_condition"              // A drop condition holds for the resource (always dropped)
+
+	// How selector patterns are matched
+	matchTypeGlob   = "glob"   // Shell file name patterns
+	matchTypeRegexp = "regexp" // Regular expressions anchored to the whole value
+
+	// Anomaly detection methods
+	anomalyMethodPercentChange = "percent_change" // Percentage change over the rolling mean
+	anomalyMethodZScore        = "zscore"         // Standard deviations above the mean
+	anomalyMethodMAD           = "mad"            // Scaled median absolute deviations above the median
+	anomalyMethodEWMA          = "ewma"           // Standard deviations above an exponentially weighted mean
+
+	// Aggregations of the data points of a metric
+	aggregationSum  = "sum"  // Sum of all data points
+	aggregationMax  = "max"  // Largest data point
+	aggregationAvg  = "avg"  // Mean of the data points
+	aggregationLast = "last" // Data point with the latest timestamp
+
+	// Values derived from histogram, exponential histogram and summary data points
+	histogramStatisticMean  = "mean"  // Sum divided by count
+	histogramStatisticCount = "count" // Number of observations
+	histogramStatisticP50   = "p50"
+	histogramStatisticP95   = "p95"
+	histogramStatisticP99   = "p99"
+
+	// Policies choosing the tracked entities evicted beyond max_tracked_entities
+	evictionPolicyLRU              = "lru"               // Least recently evaluated first
+	evictionPolicyLeastInteresting = "least_interesting" // Lowest value to threshold ratio first
+	evictionPolicyOldestExceeded   = "oldest_exceeded"   // Longest since a threshold was exceeded or an anomaly detected first
+
+	// Conversions of monotonic cumulative sums before evaluation
+	cumulativeConversionRate  = "rate"  // Increase per second since the previous point
+	cumulativeConversionDelta = "delta" // Increase since the previous point
+	cumulativeConversionNone  = "none"  // Lifetime total, as reported
+
+	// Actions on the resources no stage includes
+	excludedActionDrop      = "drop"      // Drop the resource
+	excludedActionAggregate = "aggregate" // Merge excluded processes into one _other resource per host
+
+	// Functions merging the data points of excluded processes
+	aggregateFunctionSum = "sum"
+	aggregateFunctionMax = "max"
+
+	// process.executable.name of the resource excluded processes are merged into
+	otherExecutableName = "_other"
+
+	// Volume budget controller of the dynamic thresholds
+	volumeBudgetGain      = 0.5  // Share of the relative volume error corrected per window
+	volumeBudgetMaxStep   = 2.0  // Largest factor a threshold moves by per window
+	volumeBudgetTolerance = 0.05 // Relative volume error left uncorrected
+
+	// Dynamic threshold modes
+	dynamicThresholdModeGlobal   = "global"   // One threshold per metric learned from each batch
+	dynamicThresholdModeSeasonal = "seasonal" // Thresholds learned per entity, metric and time bucket
+
+	// Processor modes
+	modeEnforce = "enforce" // Drop the resources that no stage includes
+	modeShadow  = "shadow"  // Forward every resource, annotated with the decision ATP would have made
+
+	// Shadow mode decisions
+	shadowDecisionKeep = "keep"
+	shadowDecisionDrop = "drop"
+	stageFiltered      = "filtered" // Stage recorded in shadow mode for resources no stage includes
+
+	// ATP attribute output modes
+	attributesModeJSON = "json" // process.atp as a JSON string
+	attributesModeMap  = "map"  // process.atp as a native map attribute
+	attributesModeFlat = "flat" // flattened atp.* attributes
+	attributesModeNone = "none" // no ATP attributes on exported resources
+
+	// ATP attribute keys
+	atpAttributeKey        = "process.atp"
+	atpEnabledAttributeKey = "process.atp.enabled"
+	atpFlatAttributePrefix = "atp."
+
+	// Seasonality time buckets
+	seasonalityHourOfDay  = "hour_of_day"  // 24 buckets
+	seasonalityHourOfWeek = "hour_of_week" // 168 buckets
+
+	// Internal telemetry attribute keys and values
+	telemetryAttrStage       = "stage"
+	telemetryAttrOperation   = "operation"
+	telemetryAttrMetricName  = "metric_name"
+	telemetryAttrMode        = "mode"
+	telemetryAttrEviction    = "eviction_policy"
+	persistenceOperationLoad = "load"
+	persistenceOperationSave = "save"
 
 	// Hostmetrics resource types
 	resourceTypeCPU        = "cpu"
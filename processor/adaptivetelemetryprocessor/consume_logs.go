@@ -0,0 +1,40 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// logsProcessor is the logs pipeline view over a shared processorImp.
+// Logs are forwarded only for resources whose entity is currently hot in the metrics state.
+type logsProcessor struct {
+	*sharedLifecycle
+	nextConsumer consumer.Logs
+}
+
+// ConsumeLogs implements consumer.Logs; drops resource logs of processes that ATP currently filters.
+func (l *logsProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	inputResources := ld.ResourceLogs().Len()
+
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		forward, _ := l.proc.shouldForwardSignalResource(rl.Resource())
+		// Shadow mode never drops: signal gating is only reported through the metrics decisions
+		return !forward && l.proc.config.Mode != modeShadow
+	})
+
+	outputResources := ld.ResourceLogs().Len()
+	l.proc.logger.Debug("Logs processed",
+		zap.Int("input_resources", inputResources),
+		zap.Int("output_resources", outputResources))
+
+	if outputResources == 0 {
+		return nil
+	}
+	return l.nextConsumer.ConsumeLogs(ctx, ld)
+}
@@ -8,12 +8,25 @@ import (
 	"math"
 	"time"
 
+	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.uber.org/zap"
 )
 
-// ConsumeMetrics implements consumer.Metrics; called by the OTel Collector pipeline when metrics arrive.
-func (p *processorImp) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+// metricsProcessor is the metrics pipeline view over a shared processorImp.
+// Each metrics pipeline keeps its own next consumer while evaluating against the shared entity state.
+type metricsProcessor struct {
+	*sharedLifecycle
+	nextConsumer consumer.Metrics
+}
+
+// ConsumeMetrics implements consumer.Metrics for the metrics pipeline this instance was created for.
+func (m *metricsProcessor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	return m.proc.consumeMetrics(ctx, md, m.nextConsumer)
+}
+
+// consumeMetrics filters md and forwards the result to next.
+func (p *processorImp) consumeMetrics(ctx context.Context, md pmetric.Metrics, next consumer.Metrics) error {
 	// Track batch processing time for performance monitoring
 	batchStart := time.Now()
 	defer p.logBatchProcessingTime(batchStart)
@@ -29,11 +42,11 @@ func (p *processorImp) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) e
 	// Process metrics with safeguards
 	filteredMetrics, processingDuration, err := p.processMetricsWithTiming(ctxWithTimeout, md)
 	if err != nil {
-		return p.handleProcessingError(ctx, md, err, processingDuration)
+		return p.handleProcessingError(ctx, next, md, err, processingDuration)
 	}
 
 	// Safety check for empty results
-	if err := p.validateProcessingResults(ctx, md, filteredMetrics, processingDuration); err != nil {
+	if err := p.validateProcessingResults(ctx, next, md, filteredMetrics, processingDuration); err != nil {
 		return err
 	}
 
@@ -42,7 +55,7 @@ func (p *processorImp) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) e
 	p.performMaintenanceTasks()
 
 	// Send metrics to next consumer
-	return p.forwardMetricsToNextConsumer(ctx, filteredMetrics, outputStats)
+	return p.forwardMetricsToNextConsumer(ctx, next, filteredMetrics, outputStats)
 }
 
 // logBatchProcessingTime logs slow batch processing warnings
@@ -97,7 +110,7 @@ func (p *processorImp) processMetricsWithTiming(ctx context.Context, md pmetric.
 }
 
 // handleProcessingError handles errors during metric processing
-func (p *processorImp) handleProcessingError(ctx context.Context, md pmetric.Metrics, err error, processingDuration time.Duration) error {
+func (p *processorImp) handleProcessingError(ctx context.Context, next consumer.Metrics, md pmetric.Metrics, err error, processingDuration time.Duration) error {
 	// Log detailed error information
 	if ctxErr := ctx.Err(); ctxErr != nil {
 		p.logger.Error("Context error during processing",
@@ -108,18 +121,18 @@ func (p *processorImp) handleProcessingError(ctx context.Context, md pmetric.Met
 		zap.Error(err),
 		zap.Duration("processing_duration", processingDuration))
 	// Fall back to passing through all metrics if processing fails
-	return p.nextConsumer.ConsumeMetrics(ctx, md)
+	return next.ConsumeMetrics(ctx, md)
 }
 
 // validateProcessingResults checks if processing results are valid
-func (p *processorImp) validateProcessingResults(ctx context.Context, md, filteredMetrics pmetric.Metrics, processingDuration time.Duration) error {
+func (p *processorImp) validateProcessingResults(ctx context.Context, next consumer.Metrics, md, filteredMetrics pmetric.Metrics, processingDuration time.Duration) error {
 	// Safety check - if processing returned zero resources but input had resources,
 	// fall back to the original metrics to ensure data keeps flowing
 	if filteredMetrics.ResourceMetrics().Len() == 0 && md.ResourceMetrics().Len() > 0 {
 		p.logger.Warn("Processing resulted in zero resources, falling back to original metrics",
 			zap.Int("input_resources", md.ResourceMetrics().Len()),
 			zap.Duration("processing_duration", processingDuration))
-		return p.nextConsumer.ConsumeMetrics(ctx, md)
+		return next.ConsumeMetrics(ctx, md)
 	}
 
 	// Log metrics count after processing with detailed timing
@@ -194,8 +207,13 @@ func countMetricByType(m pmetric.Metric, typeCount map[string]int) {
 	}
 }
 
-// performMaintenanceTasks runs periodic maintenance operations
+// performMaintenanceTasks runs periodic maintenance operations when the maintenance loop is not running
 func (p *processorImp) performMaintenanceTasks() {
+	if p.maintenanceRunning.Load() {
+		return
+	}
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
 	// Run persistence operations if needed but only once per minute to reduce overhead
 	if p.persistenceEnabled && time.Since(p.lastPersistenceOp) > time.Minute {
 		if err := p.persistTrackedEntities(); err != nil {
@@ -206,30 +224,29 @@ func (p *processorImp) performMaintenanceTasks() {
 }
 
 // forwardMetricsToNextConsumer sends processed metrics to the next consumer
-func (p *processorImp) forwardMetricsToNextConsumer(ctx context.Context, filteredMetrics pmetric.Metrics, stats outputStats) error {
+func (p *processorImp) forwardMetricsToNextConsumer(ctx context.Context, next consumer.Metrics, filteredMetrics pmetric.Metrics, stats outputStats) error {
 	// Call the next consumer with appropriate timeout handling
 	nextStart := time.Now()
 	consumeCtx, cancelConsume := context.WithTimeout(ctx, 10*time.Second)
 	defer cancelConsume()
 
-	// Always log what we're about to send to the next consumer
-	p.logger.Info("SENDING METRICS to next consumer",
+	p.logger.Debug("Sending metrics to next consumer",
 		zap.Int("resource_count", stats.ResourceCount),
 		zap.Int("metric_count", stats.TotalMetricCount),
 		zap.Any("metric_types", stats.MetricTypeCount))
 
-	err := p.nextConsumer.ConsumeMetrics(consumeCtx, filteredMetrics)
+	err := next.ConsumeMetrics(consumeCtx, filteredMetrics)
 	consumeDuration := time.Since(nextStart)
 
-	// Always log at INFO level regardless of the result
+	// Batch volumes are reported through internal telemetry, only failures are logged above debug
 	if err != nil {
-		p.logger.Error("ERROR from next consumer",
+		p.logger.Error("Next consumer returned an error",
 			zap.Error(err),
 			zap.Duration("consumer_duration", consumeDuration),
 			zap.Int("resource_count", stats.ResourceCount),
 			zap.Int("metric_count", stats.TotalMetricCount))
 	} else {
-		p.logger.Info("SUCCESS: Metrics passed to next consumer",
+		p.logger.Debug("Metrics passed to next consumer",
 			zap.Int("resource_count", stats.ResourceCount),
 			zap.Int("metric_count", stats.TotalMetricCount),
 			zap.Duration("consumer_duration", consumeDuration))
@@ -0,0 +1,40 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// tracesProcessor is the traces pipeline view over a shared processorImp.
+// Spans are forwarded only for resources whose entity is currently hot in the metrics state.
+type tracesProcessor struct {
+	*sharedLifecycle
+	nextConsumer consumer.Traces
+}
+
+// ConsumeTraces implements consumer.Traces; drops resource spans of processes that ATP currently filters.
+func (t *tracesProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	inputResources := td.ResourceSpans().Len()
+
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		forward, _ := t.proc.shouldForwardSignalResource(rs.Resource())
+		// Shadow mode never drops: signal gating is only reported through the metrics decisions
+		return !forward && t.proc.config.Mode != modeShadow
+	})
+
+	outputResources := td.ResourceSpans().Len()
+	t.proc.logger.Debug("Traces processed",
+		zap.Int("input_resources", inputResources),
+		zap.Int("output_resources", outputResources))
+
+	if outputResources == 0 {
+		return nil
+	}
+	return t.nextConsumer.ConsumeTraces(ctx, td)
+}
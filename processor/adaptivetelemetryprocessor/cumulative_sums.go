@@ -0,0 +1,184 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// validCumulativeConversions lists the accepted cumulative_conversions values
+var validCumulativeConversions = []string{cumulativeConversionRate, cumulativeConversionDelta, cumulativeConversionNone}
+
+// isValidCumulativeConversion reports whether conversion is a known cumulative sum conversion
+func isValidCumulativeConversion(conversion string) bool {
+	for _, c := range validCumulativeConversions {
+		if c == conversion {
+			return true
+		}
+	}
+	return false
+}
+
+// cumulativeConversionFor returns how the monotonic cumulative sums of a metric are evaluated
+func (cfg *Config) cumulativeConversionFor(metricName string) string {
+	if conversion, ok := cfg.CumulativeConversions[metricName]; ok && conversion != "" {
+		return conversion
+	}
+	return cumulativeConversionRate
+}
+
+// cumulativePoint is the previous point of one cumulative series
+type cumulativePoint struct {
+	start pcommon.Timestamp
+	ts    pcommon.Timestamp
+	value float64
+}
+
+// cumulativeSeries holds the previous points of an entity's cumulative sums, by metric and point attributes
+type cumulativeSeries struct {
+	points   map[string]cumulativePoint
+	lastSeen time.Time
+}
+
+// isConvertedCumulativeSum reports whether m is a monotonic cumulative sum that cfg evaluates as a rate or delta
+func isConvertedCumulativeSum(m pmetric.Metric, cfg *Config) bool {
+	return m.Type() == pmetric.MetricTypeSum &&
+		m.Sum().IsMonotonic() &&
+		m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative &&
+		cfg.cumulativeConversionFor(m.Name()) != cumulativeConversionNone
+}
+
+// convertCumulativeSums replaces the lifetime totals of the entity's monotonic cumulative sums in values
+// with their rate or delta since the previous batch, and remembers the current points for the next one.
+// A metric without a previous point yet is removed from values; pending reports that it is still evaluated.
+func (p *processorImp) convertCumulativeSums(id string, rm pmetric.ResourceMetrics, values map[string]float64, cfg *Config) (pending bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var series *cumulativeSeries
+	scopeMetrics := rm.ScopeMetrics()
+	for i := 0; i < scopeMetrics.Len(); i++ {
+		metrics := scopeMetrics.At(i).Metrics()
+		for j := 0; j < metrics.Len(); j++ {
+			m := metrics.At(j)
+			if !p.shouldProcessMetric(m.Name(), cfg) || !isConvertedCumulativeSum(m, cfg) {
+				continue
+			}
+			if series == nil {
+				series = p.cumulativeSeriesFor(id)
+			}
+
+			value, ok := series.convert(m, cfg)
+			if ok {
+				values[m.Name()] = value
+				continue
+			}
+			delete(values, m.Name())
+			pending = true
+		}
+	}
+	return pending
+}
+
+// cumulativeSeriesFor returns the cumulative series of an entity, created on first use. Callers hold p.mu.
+func (p *processorImp) cumulativeSeriesFor(id string) *cumulativeSeries {
+	if p.cumulativeSums == nil {
+		p.cumulativeSums = make(map[string]*cumulativeSeries)
+	}
+	series, ok := p.cumulativeSums[id]
+	if !ok {
+		series = &cumulativeSeries{points: make(map[string]cumulativePoint)}
+		p.cumulativeSums[id] = series
+	}
+	series.lastSeen = time.Now()
+	return series
+}
+
+// convert returns the rate or delta of the data points of m, combined with the metric's aggregation.
+// It reports false when no data point has a previous point to compare with.
+func (s *cumulativeSeries) convert(m pmetric.Metric, cfg *Config) (float64, bool) {
+	perSecond := cfg.cumulativeConversionFor(m.Name()) == cumulativeConversionRate
+	agg := pointAggregator{aggregation: cfg.aggregationFor(m.Name())}
+
+	dataPoints := m.Sum().DataPoints()
+	for i := 0; i < dataPoints.Len(); i++ {
+		dp := dataPoints.At(i)
+		key := m.Name() + pointAttributesKey(dp.Attributes())
+		cur := cumulativePoint{start: dp.StartTimestamp(), ts: dp.Timestamp(), value: numberValue(dp)}
+
+		prev, seen := s.points[key]
+		if seen && cur.ts <= prev.ts {
+			// Repeated or out of order point, keep the newer one
+			continue
+		}
+		s.points[key] = cur
+		if !seen {
+			continue
+		}
+
+		delta, elapsed := cumulativeIncrease(prev, cur)
+		if !perSecond {
+			agg.add(delta, cur.ts)
+			continue
+		}
+		if elapsed > 0 {
+			agg.add(delta/elapsed.Seconds(), cur.ts)
+		}
+	}
+	return agg.value(), agg.count > 0
+}
+
+// cumulativeIncrease returns how much a cumulative series grew from prev to cur, and over how long.
+// A new start timestamp or a lower value is a reset: the series then counts from zero since its start.
+func cumulativeIncrease(prev, cur cumulativePoint) (float64, time.Duration) {
+	reset := cur.value < prev.value || (cur.start != 0 && prev.start != 0 && cur.start != prev.start)
+	if !reset {
+		return cur.value - prev.value, cur.ts.AsTime().Sub(prev.ts.AsTime())
+	}
+	if cur.start != 0 && cur.start < cur.ts {
+		return cur.value, cur.ts.AsTime().Sub(cur.start.AsTime())
+	}
+	return cur.value, cur.ts.AsTime().Sub(prev.ts.AsTime())
+}
+
+// pointAttributesKey identifies a data point within its metric by its attributes, in a stable order
+func pointAttributesKey(attrs pcommon.Map) string {
+	if attrs.Len() == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		pairs = append(pairs, k+"="+v.AsString())
+		return true
+	})
+	sort.Strings(pairs)
+	return "|" + strings.Join(pairs, "|")
+}
+
+// cleanupCumulativeSums drops the previous points of entities not seen within the retention period
+func (p *processorImp) cleanupCumulativeSums(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := now.Add(-time.Duration(p.config.RetentionMinutes) * time.Minute)
+	removed := 0
+	for id, series := range p.cumulativeSums {
+		if series.lastSeen.Before(cutoff) {
+			delete(p.cumulativeSums, id)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		p.logger.Debug("Removed stale cumulative sum points",
+			zap.Int("removed_count", removed),
+			zap.Int("remaining_count", len(p.cumulativeSums)))
+	}
+}
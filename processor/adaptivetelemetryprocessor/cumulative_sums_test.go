@@ -0,0 +1,142 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+var cumulativeTestStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// createCumulativeCPUTime returns a process with a monotonic cumulative process.cpu.time point per state,
+// observed at offset seconds after start
+func createCumulativeCPUTime(start time.Time, offset int, values map[string]float64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	attrs := rm.Resource().Attributes()
+	attrs.PutStr("process.executable.name", "/usr/bin/worker")
+	attrs.PutInt("process.pid", 7)
+	attrs.PutStr("host.name", "testhost")
+	attrs.PutStr("process.executable.path", "/usr/bin/worker")
+
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("process.cpu.time")
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	for state, v := range values {
+		dp := sum.DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("state", state)
+		dp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(cumulativeTestStart.Add(time.Duration(offset) * time.Second)))
+		dp.SetDoubleValue(v)
+	}
+	return md
+}
+
+// withCPUTimeThreshold evaluates the rate of the process.cpu.time cumulative sum against 0.5
+func withCPUTimeThreshold(cfg *Config) {
+	cfg.MetricThresholds = map[string]float64{"process.cpu.time": 0.5}
+}
+
+func TestCumulativeSumRate(t *testing.T) {
+	p := newTestProcessor(t, &Config{CumulativeConversions: nil}, withCPUTimeThreshold)
+	batches := []struct {
+		offset   int
+		start    time.Time
+		values   map[string]float64
+		included bool
+	}{
+		// A long-lived process: the lifetime total is far above the threshold, but there is no rate yet
+		{0, cumulativeTestStart.Add(-time.Hour), map[string]float64{"user": 900, "system": 100}, false},
+		// 2 seconds of CPU over 10 seconds
+		{10, cumulativeTestStart.Add(-time.Hour), map[string]float64{"user": 901, "system": 101}, false},
+		// 10 seconds of CPU over 10 seconds
+		{20, cumulativeTestStart.Add(-time.Hour), map[string]float64{"user": 909, "system": 103}, true},
+		// Restarted 5 seconds ago with 4 seconds of CPU: a reset, not a negative rate
+		{30, cumulativeTestStart.Add(25 * time.Second), map[string]float64{"user": 3, "system": 1}, true},
+	}
+
+	for i, b := range batches {
+		out, err := p.processMetrics(t.Context(), createCumulativeCPUTime(b.start, b.offset, b.values))
+		require.NoError(t, err)
+		assert.Equal(t, b.included, out.ResourceMetrics().Len() == 1, "batch %d", i)
+	}
+
+	// 1 second of CPU over 10 seconds after the restart
+	_, err := p.processMetrics(t.Context(),
+		createCumulativeCPUTime(cumulativeTestStart.Add(25*time.Second), 40, map[string]float64{"user": 3.5, "system": 1.5}))
+	require.NoError(t, err)
+	require.Len(t, p.trackedEntities, 1)
+	for _, te := range p.trackedEntities {
+		assert.InDelta(t, 0.1, te.CurrentValues["process.cpu.time"], 1e-9)
+	}
+}
+
+func TestCumulativeSumConversions(t *testing.T) {
+	start := cumulativeTestStart.Add(-time.Hour)
+
+	t.Run("delta", func(t *testing.T) {
+		p := newTestProcessor(t, &Config{CumulativeConversions: map[string]string{"process.cpu.time": cumulativeConversionDelta}}, withCPUTimeThreshold)
+		rm := createCumulativeCPUTime(start, 0, map[string]float64{"user": 100}).ResourceMetrics().At(0)
+		id := buildResourceIdentity(rm.Resource())
+
+		values := p.extractMetricValues(rm, p.config)
+		assert.True(t, p.convertCumulativeSums(id, rm, values, p.config))
+		assert.NotContains(t, values, "process.cpu.time")
+
+		rm = createCumulativeCPUTime(start, 60, map[string]float64{"user": 130}).ResourceMetrics().At(0)
+		values = p.extractMetricValues(rm, p.config)
+		assert.False(t, p.convertCumulativeSums(id, rm, values, p.config))
+		assert.InDelta(t, 30.0, values["process.cpu.time"], 1e-9)
+	})
+
+	t.Run("none keeps the lifetime total", func(t *testing.T) {
+		p := newTestProcessor(t, &Config{CumulativeConversions: map[string]string{"process.cpu.time": cumulativeConversionNone}}, withCPUTimeThreshold)
+		out, err := p.processMetrics(t.Context(), createCumulativeCPUTime(start, 0, map[string]float64{"user": 100}))
+		require.NoError(t, err)
+		assert.Equal(t, 1, out.ResourceMetrics().Len())
+		assert.Empty(t, p.cumulativeSums)
+	})
+}
+
+func TestCumulativeIncrease(t *testing.T) {
+	at := func(seconds int) pcommon.Timestamp {
+		return pcommon.NewTimestampFromTime(cumulativeTestStart.Add(time.Duration(seconds) * time.Second))
+	}
+	testCases := []struct {
+		name          string
+		prev, cur     cumulativePoint
+		expectDelta   float64
+		expectElapsed time.Duration
+	}{
+		{"increase", cumulativePoint{at(0), at(10), 5}, cumulativePoint{at(0), at(20), 8}, 3, 10 * time.Second},
+		{"reset from start timestamp", cumulativePoint{at(0), at(10), 5}, cumulativePoint{at(15), at(20), 8}, 8, 5 * time.Second},
+		{"reset without start timestamp", cumulativePoint{0, at(10), 5}, cumulativePoint{0, at(20), 2}, 2, 10 * time.Second},
+		{"start timestamp appears", cumulativePoint{0, at(10), 5}, cumulativePoint{at(0), at(20), 8}, 3, 10 * time.Second},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delta, elapsed := cumulativeIncrease(tc.prev, tc.cur)
+			assert.InDelta(t, tc.expectDelta, delta, 1e-9)
+			assert.Equal(t, tc.expectElapsed, elapsed)
+		})
+	}
+}
+
+func TestCleanupCumulativeSums(t *testing.T) {
+	p := newTestProcessor(t, &Config{CumulativeConversions: nil}, withCPUTimeThreshold)
+	p.cumulativeSeriesFor("stale").lastSeen = time.Now().Add(-2 * time.Hour)
+	p.cumulativeSeriesFor("fresh")
+	p.cleanupCumulativeSums(time.Now())
+
+	assert.NotContains(t, p.cumulativeSums, "stale")
+	assert.Contains(t, p.cumulativeSums, "fresh")
+}
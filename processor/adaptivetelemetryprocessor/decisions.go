@@ -0,0 +1,255 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// maxRecordedDecisions bounds the decisions kept for inspection; the first recorded are evicted first
+const maxRecordedDecisions = 10000
+
+// Results of a stage check
+const (
+	checkMatched    = "matched"     // The stage keeps (or, for exclude_list and drop_condition, drops) the resource
+	checkNotMatched = "not_matched" // The stage was checked and does not apply
+	checkDisabled   = "disabled"    // The stage is not configured for the resource
+)
+
+// resourceDecision is the last evaluation of a resource, as shown by the inspection endpoint
+type resourceDecision struct {
+	ResourceID string             `json:"resource_id"`
+	Time       time.Time          `json:"time"`
+	Decision   string             `json:"decision"` // keep or drop
+	Stage      string             `json:"stage"`    // Stage that decided; filtered when no stage kept the resource
+	Rule       string             `json:"rule,omitempty"`
+	Values     map[string]float64 `json:"values,omitempty"`
+	Checks     []stageCheck       `json:"checks,omitempty"`
+}
+
+// stageCheck explains the outcome of one stage for a resource
+type stageCheck struct {
+	Stage  string `json:"stage"`
+	Result string `json:"result"`
+	Reason string `json:"reason"`
+}
+
+// decisionLog keeps the last decision of each evaluated resource, up to maxRecordedDecisions
+type decisionLog struct {
+	byID  map[string]*resourceDecision
+	order []string // Resource ids in first recorded order, for eviction
+}
+
+func newDecisionLog() *decisionLog {
+	return &decisionLog{byID: make(map[string]*resourceDecision)}
+}
+
+// record stores the decision, replacing the previous one of the resource
+func (l *decisionLog) record(d *resourceDecision) {
+	if _, ok := l.byID[d.ResourceID]; !ok {
+		for len(l.byID) >= maxRecordedDecisions && len(l.order) > 0 {
+			delete(l.byID, l.order[0])
+			l.order = l.order[1:]
+		}
+		l.order = append(l.order, d.ResourceID)
+	}
+	l.byID[d.ResourceID] = d
+}
+
+// evaluatedResource is a resource of the batch, kept until its decision is recorded
+type evaluatedResource struct {
+	rm     pmetric.ResourceMetrics
+	id     string
+	values map[string]float64
+}
+
+// recordDecisions records the decision of every resource evaluated in the batch, once top N and
+// heartbeats are settled. It only runs when the inspection endpoint is enabled.
+func (p *processorImp) recordDecisions(evaluated []evaluatedResource, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range evaluated {
+		stage := stageFiltered
+		if v, ok := e.rm.Resource().Attributes().Get(internalFilterStageAttributeKey); ok {
+			stage = v.AsString()
+		}
+		decision := shadowDecisionKeep
+		switch stage {
+		case stageFiltered, stageExcludeList, stageDropCondition:
+			decision = shadowDecisionDrop
+		}
+
+		cfg, rule := p.configFor(e.rm.Resource())
+		p.decisions.record(&resourceDecision{
+			ResourceID: e.id,
+			Time:       now,
+			Decision:   decision,
+			Stage:      stage,
+			Rule:       rule,
+			Values:     e.values,
+			Checks:     p.explainStages(e.rm, e.id, e.values, cfg, stage),
+		})
+	}
+}
+
+// explainStages checks every stage, in evaluation order, against a resource evaluated with values.
+// Stages are checked whether or not an earlier stage decided, so the reasons each one does not apply
+// are all shown. Callers hold p.mu.
+func (p *processorImp) explainStages(rm pmetric.ResourceMetrics, id string, values map[string]float64, cfg *Config, stage string) []stageCheck {
+	attrs := rm.Resource().Attributes()
+	te := p.trackedEntities[id]
+	checks := []stageCheck{
+		explainMatch(stageExcludeList, len(p.config.Exclude) > 0, p.isExplicitlyExcluded(attrs),
+			"matches an exclude selector", "no exclude selector matches"),
+		explainMatch(stageDropCondition, p.dropConditions != nil, p.isDroppedByConditions(rm.Resource()),
+			"a drop condition holds", "no drop condition holds for the resource"),
+	}
+
+	if targeted := p.isResourceTargeted(values, cfg); targeted {
+		checks = append(checks, stageCheck{stageDefaultInclusion, checkNotMatched, "evaluated metrics: " + strings.Join(slices.Sorted(maps.Keys(values)), ", ")})
+	} else {
+		checks = append(checks, stageCheck{stageDefaultInclusion, checkMatched, "no metric with a threshold"})
+	}
+
+	checks = append(checks,
+		explainMatch(stageZombieProcess, true, isZombieProcess(attrs), "process.state is Z", "not a zombie process"),
+		explainMatch(stageIncludeList, len(p.config.IncludeProcessList) > 0 || len(p.config.Include) > 0, p.isExplicitlyIncluded(attrs),
+			"on the include list", "not on the include list"),
+		explainMatch(stageKeepCondition, p.keepConditions != nil, p.isKeptByConditions(rm.Resource()) || len(p.conditionKeptMetrics(rm)) > 0,
+			"a keep condition holds", "no keep condition holds for the resource or its metrics"),
+		p.explainAnomaly(id, te, cfg, stage),
+		p.explainThresholds(id, te, values, cfg),
+		p.explainMultiMetric(values, cfg),
+		explainRetention(stageAnomalyRetention, te, cfg.EnableAnomalyDetection, func(te *trackedEntity) (bool, string) {
+			return inAnomalyRetention(te, cfg), fmt.Sprintf("last anomaly %s, retention %dm", formatSince(te.LastAnomalyDetected), anomalyRetentionMinutes(cfg))
+		}),
+		explainRetention(stageStandardRetention, te, cfg.RetentionMinutes > 0, func(te *trackedEntity) (bool, string) {
+			return inStandardRetention(te, cfg), fmt.Sprintf("last exceeded %s, retention %dm", formatSince(te.LastExceeded), cfg.RetentionMinutes)
+		}),
+		explainMatch(stageTopN, len(p.config.TopN) > 0, stage == stageTopN,
+			"among the top N processes of its host", "not among the top N processes of its host"),
+		explainMatch(stageHeartbeat, p.config.HeartbeatInterval > 0, stage == stageHeartbeat,
+			"heartbeat due", fmt.Sprintf("kept by another stage, forwarded less than %s ago, or reporting no heartbeat_metrics", p.config.HeartbeatInterval)),
+	)
+	return checks
+}
+
+// explainMatch checks a stage that matches or not, when it is configured
+func explainMatch(stage string, enabled, matched bool, matchedReason, notMatchedReason string) stageCheck {
+	switch {
+	case !enabled:
+		return stageCheck{stage, checkDisabled, "not configured"}
+	case matched:
+		return stageCheck{stage, checkMatched, matchedReason}
+	default:
+		return stageCheck{stage, checkNotMatched, notMatchedReason}
+	}
+}
+
+// explainAnomaly checks the anomaly detection stage from the history of the tracked or observed entity
+func (p *processorImp) explainAnomaly(id string, te *trackedEntity, cfg *Config, stage string) stageCheck {
+	if !cfg.EnableAnomalyDetection {
+		return stageCheck{stageAnomalyDetection, checkDisabled, "enable_anomaly_detection is false"}
+	}
+	if stage == stageAnomalyDetection {
+		return stageCheck{stageAnomalyDetection, checkMatched, "anomaly detected"}
+	}
+	minPoints := cfg.AnomalyMinDataPoints
+	if minPoints <= 0 {
+		minPoints = defaultAnomalyMinDataPoints
+	}
+	history := p.observedHistory(id)
+	if te != nil {
+		history = te.MetricHistory
+	}
+	points := 0
+	for _, h := range history {
+		points = max(points, len(h))
+	}
+	if points < minPoints {
+		return stageCheck{stageAnomalyDetection, checkNotMatched, fmt.Sprintf("history of %d points, %d needed for a baseline", points, minPoints)}
+	}
+	return stageCheck{stageAnomalyDetection, checkNotMatched, fmt.Sprintf("no change beyond the %s threshold", cfg.AnomalyMethod)}
+}
+
+// explainThresholds compares each evaluated metric with the entity's dynamic threshold, or its static one
+func (p *processorImp) explainThresholds(id string, te *trackedEntity, values map[string]float64, cfg *Config) stageCheck {
+	check := stageCheck{Stage: stageStaticThreshold, Result: checkNotMatched}
+	dynamic := p.dynamicThresholdsApply(cfg)
+	if dynamic {
+		check.Stage = stageDynamicThreshold
+	}
+
+	now := time.Now()
+	var comparisons []string
+	for _, m := range slices.Sorted(maps.Keys(values)) {
+		threshold, ok := cfg.MetricThresholds[m]
+		if dynamic {
+			threshold, _, ok = p.dynamicThresholdFor(id, m, now, cfg)
+		}
+		if !ok {
+			continue
+		}
+		op := "<"
+		if values[m] >= threshold {
+			op = ">="
+			check.Result = checkMatched
+		}
+		comparisons = append(comparisons, fmt.Sprintf("%s %g %s %g", m, values[m], op, threshold))
+	}
+	if len(comparisons) == 0 {
+		return stageCheck{check.Stage, checkNotMatched, "no metric with a threshold"}
+	}
+	check.Reason = strings.Join(comparisons, ", ")
+	if te != nil && te.ThresholdBreaches > 0 && !te.ThresholdActive {
+		check.Reason += fmt.Sprintf(" (%d of %d enter_breaches)", te.ThresholdBreaches, cfg.getEnterBreaches())
+	}
+	return check
+}
+
+// explainMultiMetric compares the composite score with the composite threshold
+func (p *processorImp) explainMultiMetric(values map[string]float64, cfg *Config) stageCheck {
+	if !p.multiMetricEnabled {
+		return stageCheck{stageMultiMetric, checkDisabled, "enable_multi_metric is false"}
+	}
+	score, _ := p.calculateCompositeGeneric(values, cfg)
+	threshold := cfg.CompositeThreshold
+	if threshold <= 0 {
+		threshold = defaultCompositeThreshold
+	}
+	if score >= threshold {
+		return stageCheck{stageMultiMetric, checkMatched, fmt.Sprintf("composite score %.2f >= %g", score, threshold)}
+	}
+	return stageCheck{stageMultiMetric, checkNotMatched, fmt.Sprintf("composite score %.2f < %g", score, threshold)}
+}
+
+// explainRetention checks a retention stage, which only applies to tracked entities
+func explainRetention(stage string, te *trackedEntity, enabled bool, in func(*trackedEntity) (bool, string)) stageCheck {
+	if !enabled {
+		return stageCheck{stage, checkDisabled, "not configured"}
+	}
+	if te == nil {
+		return stageCheck{stage, checkNotMatched, "not tracked"}
+	}
+	matched, reason := in(te)
+	if matched {
+		return stageCheck{stage, checkMatched, reason}
+	}
+	return stageCheck{stage, checkNotMatched, reason}
+}
+
+// formatSince describes how long ago t was
+func formatSince(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
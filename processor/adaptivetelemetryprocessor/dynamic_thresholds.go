@@ -56,9 +56,10 @@ func (p *processorImp) shouldUpdateDynamicThresholds() bool {
 
 // dynamicUpdateContext holds context information for a dynamic threshold update
 type dynamicUpdateContext struct {
-	startTime  time.Time
-	smoothing  float64
-	metricKeys []string
+	startTime    time.Time
+	smoothing    float64
+	metricKeys   []string
+	sampleCounts map[string]int // Values each updated threshold was calculated from
 }
 
 // initializeDynamicUpdate sets up the context for dynamic threshold update
@@ -86,16 +87,18 @@ func (p *processorImp) initializeDynamicUpdate() *dynamicUpdateContext {
 	}
 
 	return &dynamicUpdateContext{
-		startTime:  start,
-		smoothing:  smoothing,
-		metricKeys: metricKeys,
+		startTime:    start,
+		smoothing:    smoothing,
+		metricKeys:   metricKeys,
+		sampleCounts: make(map[string]int),
 	}
 }
 
 // metricAverageData holds average calculation data for a metric
 type metricAverageData struct {
-	avg   float64
-	count int
+	avg    float64
+	count  int
+	values []float64 // per-resource values, kept for percentile targets
 }
 
 // computeMetricAverages calculates averages for all configured metrics in a single pass
@@ -139,14 +142,14 @@ func (p *processorImp) processMetricForAverages(m pmetric.Metric, metricAvgs map
 		return
 	}
 
-	var sum float64
-	for d := 0; d < g.DataPoints().Len(); d++ {
-		sum += g.DataPoints().At(d).DoubleValue()
-	}
+	value := extractNumberValue(g.DataPoints(), p.config.aggregationFor(name))
 
 	val := metricAvgs[name]
-	val.avg += sum
+	val.avg += value
 	val.count++
+	if p.config.DynamicThresholdPercentile > 0 {
+		val.values = append(val.values, value)
+	}
 	metricAvgs[name] = val
 }
 
@@ -155,8 +158,9 @@ func finalizeAverages(metricAvgs map[string]metricAverageData) {
 	for metric, data := range metricAvgs {
 		if data.count > 0 {
 			metricAvgs[metric] = metricAverageData{
-				avg:   data.avg / float64(data.count),
-				count: data.count,
+				avg:    data.avg / float64(data.count),
+				count:  data.count,
+				values: data.values,
 			}
 		}
 	}
@@ -174,6 +178,7 @@ func (p *processorImp) calculateNewThresholds(metricAvgs map[string]metricAverag
 		newVal, shouldUpdate := p.calculateSingleThreshold(metric, metricAvgs, currentThresholds, updateContext.smoothing)
 		if shouldUpdate {
 			newThresholds[metric] = newVal
+			updateContext.sampleCounts[metric] = metricAvgs[metric].count
 		}
 	}
 
@@ -206,6 +211,9 @@ func (p *processorImp) calculateSingleThreshold(metric string, metricAvgs map[st
 	}
 
 	target := base + (data.avg * genericScalingFactor)
+	if p.config.DynamicThresholdPercentile > 0 && len(data.values) > 0 {
+		target = p.calculateTarget(base, data.values)
+	}
 	newVal := (smoothing * target) + ((1 - smoothing) * prev)
 
 	// Apply min/max constraints
@@ -238,11 +246,12 @@ func (p *processorImp) applyThresholdConstraints(metric string, value float64) f
 }
 
 // applyThresholdUpdates safely updates the dynamic thresholds
-func (p *processorImp) applyThresholdUpdates(newThresholds map[string]float64, _ *dynamicUpdateContext) {
+func (p *processorImp) applyThresholdUpdates(newThresholds map[string]float64, updateContext *dynamicUpdateContext) {
 	if len(newThresholds) > 0 {
 		p.mu.Lock()
 		for k, v := range newThresholds {
 			p.dynamicCustomThresholds[k] = v
+			p.recordThresholdUpdate(k, updateContext.sampleCounts[k], updateContext.startTime)
 		}
 		p.mu.Unlock()
 	}
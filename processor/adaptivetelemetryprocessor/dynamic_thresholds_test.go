@@ -136,6 +136,27 @@ func TestUpdateDynamicThresholds(t *testing.T) {
 			},
 			description: "Multiple metrics should each be updated with their own values",
 		},
+		{
+			name: "Percentile target",
+			config: &Config{
+				EnableDynamicThresholds:    true,
+				DynamicSmoothingFactor:     0.2,
+				DynamicThresholdPercentile: 95,
+				MetricThresholds: map[string]float64{
+					"process.cpu.utilization": 10.0,
+				},
+			},
+			initialThresholds: map[string]float64{
+				"process.cpu.utilization": 10.0,
+			},
+			metricValues: map[string]float64{
+				"process.cpu.utilization": 30.0,
+			},
+			expectedThresholds: map[string]float64{
+				"process.cpu.utilization": 14.0, // target = max(10, p95 of [30]) = 30; new = (0.2*30) + (0.8*10) = 14
+			},
+			description: "Percentile target should replace the fixed scaling factor",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,129 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/newrelic/nrdot-collector-components/internal/common/priorityqueue"
+)
+
+// getMaxTrackedEntities returns the tracked entity budget, with default
+func (p *processorImp) getMaxTrackedEntities() int {
+	if p.config.MaxTrackedEntities > 0 {
+		return p.config.MaxTrackedEntities
+	}
+	return defaultMaxTrackedEntities
+}
+
+// getEvictionPolicy returns the policy choosing the evicted entities, with default
+func (p *processorImp) getEvictionPolicy() string {
+	if p.config.EvictionPolicy != "" {
+		return p.config.EvictionPolicy
+	}
+	return evictionPolicyLRU
+}
+
+// enforceTrackedEntityBudget evicts tracked entities beyond max_tracked_entities, those the eviction
+// policy ranks first. Evicted entities keep their history in the observed tier, like expired ones.
+// Seasonal profiles are held to the same budget.
+func (p *processorImp) enforceTrackedEntityBudget(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	maxEntities := p.getMaxTrackedEntities()
+	// Profiles are evicted after the entities, so those of just evicted entities go first
+	defer p.enforceSeasonalProfileBudget(maxEntities)
+
+	excess := len(p.trackedEntities) - maxEntities
+	if excess <= 0 {
+		return
+	}
+
+	// The queue pops the highest priority first, so the priority is how evictable an entity is
+	policy := p.getEvictionPolicy()
+	now := time.Now()
+	queue := make(priorityqueue.PriorityQueue[string, float64], 0, len(p.trackedEntities))
+	for id, te := range p.trackedEntities {
+		queue = append(queue, &priorityqueue.QueueItem[string, float64]{
+			Value:    id,
+			Priority: p.evictionPriority(te, policy, now),
+			Index:    len(queue),
+		})
+	}
+	heap.Init(&queue)
+
+	for i := 0; i < excess; i++ {
+		id := heap.Pop(&queue).(*priorityqueue.QueueItem[string, float64]).Value
+		te := p.trackedEntities[id]
+		delete(p.trackedEntities, id)
+		if p.configForEntity(te).EnableAnomalyDetection {
+			p.observeEntity(id, te.MetricHistory, now)
+		}
+	}
+
+	p.logger.Debug("Evicted tracked entities over the budget",
+		zap.Int("evicted_count", excess),
+		zap.Int("max_tracked_entities", maxEntities),
+		zap.String("eviction_policy", policy))
+	if p.telemetry != nil {
+		p.telemetry.ProcessorAdaptivetelemetryEvictedEntities.Add(ctx, int64(excess),
+			metric.WithAttributes(attribute.String(telemetryAttrEviction, policy)))
+	}
+}
+
+// evictionPriority ranks a tracked entity for eviction under policy, higher is evicted first.
+// Callers hold p.mu.
+func (p *processorImp) evictionPriority(te *trackedEntity, policy string, now time.Time) float64 {
+	switch policy {
+	case evictionPolicyLeastInteresting:
+		return -p.interestScore(te)
+	case evictionPolicyOldestExceeded:
+		last := te.LastExceeded
+		if te.LastAnomalyDetected.After(last) {
+			last = te.LastAnomalyDetected
+		}
+		return secondsSince(last, now)
+	default:
+		last := te.LastSeen
+		if last.IsZero() {
+			last = te.FirstSeen
+		}
+		return secondsSince(last, now)
+	}
+}
+
+// interestScore is the highest ratio of an entity's current values to their thresholds,
+// dynamic ones when they apply. Callers hold p.mu.
+func (p *processorImp) interestScore(te *trackedEntity) float64 {
+	cfg := p.configForEntity(te)
+	score := 0.0
+	for m, v := range te.CurrentValues {
+		threshold, ok := cfg.MetricThresholds[m]
+		if p.dynamicThresholdsApply(cfg) {
+			if dynamic, _, hasDynamic := p.dynamicThresholdFor(te.Identity, m, time.Now(), cfg); hasDynamic {
+				threshold, ok = dynamic, true
+			}
+		}
+		if ok && threshold > 0 {
+			score = math.Max(score, v/threshold)
+		}
+	}
+	return score
+}
+
+// secondsSince returns the seconds from t to now, the largest value for a zero t
+func secondsSince(t, now time.Time) float64 {
+	if t.IsZero() {
+		return math.MaxFloat64
+	}
+	return now.Sub(t).Seconds()
+}
@@ -0,0 +1,96 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+
+	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/metadatatest"
+)
+
+func TestEnforceTrackedEntityBudget(t *testing.T) {
+	now := time.Now()
+	entities := func() map[string]*trackedEntity {
+		return map[string]*trackedEntity{
+			// Seen last, barely over its threshold long ago
+			"busy-long-ago": {
+				FirstSeen:     now.Add(-time.Hour),
+				LastSeen:      now,
+				LastExceeded:  now.Add(-50 * time.Minute),
+				CurrentValues: map[string]float64{"process.cpu.utilization": 11},
+			},
+			// Seen first, far over its threshold
+			"hot": {
+				FirstSeen:     now.Add(-time.Hour),
+				LastSeen:      now.Add(-20 * time.Minute),
+				LastExceeded:  now.Add(-20 * time.Minute),
+				CurrentValues: map[string]float64{"process.cpu.utilization": 90},
+			},
+			// Recent anomaly, in between otherwise
+			"anomalous": {
+				FirstSeen:           now.Add(-time.Hour),
+				LastSeen:            now.Add(-10 * time.Minute),
+				LastAnomalyDetected: now.Add(-time.Minute),
+				CurrentValues:       map[string]float64{"process.cpu.utilization": 30},
+			},
+		}
+	}
+
+	testCases := []struct {
+		policy  string
+		evicted string
+	}{
+		{evictionPolicyLRU, "hot"},
+		{evictionPolicyLeastInteresting, "busy-long-ago"},
+		{evictionPolicyOldestExceeded, "busy-long-ago"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			p, tel := newTelemetryTestProcessor(t, &Config{
+				MetricThresholds:   map[string]float64{"process.cpu.utilization": 10},
+				MaxTrackedEntities: 2,
+				EvictionPolicy:     tc.policy,
+			})
+			p.trackedEntities = entities()
+
+			p.enforceTrackedEntityBudget(t.Context())
+			assert.Len(t, p.trackedEntities, 2)
+			assert.NotContains(t, p.trackedEntities, tc.evicted)
+
+			// Within the budget, nothing is evicted
+			p.enforceTrackedEntityBudget(t.Context())
+			assert.Len(t, p.trackedEntities, 2)
+
+			metadatatest.AssertEqualProcessorAdaptivetelemetryEvictedEntities(t, tel,
+				[]metricdata.DataPoint[int64]{{Value: 1, Attributes: attribute.NewSet(attribute.String("eviction_policy", tc.policy))}},
+				metricdatatest.IgnoreTimestamp())
+		})
+	}
+}
+
+func TestTrackedEntityBudgetDuringProcessing(t *testing.T) {
+	p, _ := newTelemetryTestProcessor(t, &Config{
+		MetricThresholds:   map[string]float64{"process.cpu.utilization": 10},
+		MaxTrackedEntities: 2,
+	}, withShortAnomalyHistory)
+
+	md := createTestProcessMetrics("/usr/bin/first", 1, 50.0)
+	addProcessToMetrics(md, "/usr/bin/second", 2, 50.0)
+	addProcessToMetrics(md, "/usr/bin/third", 3, 50.0)
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	// The batch is forwarded in full, only the state is trimmed
+	assert.Equal(t, 3, out.ResourceMetrics().Len())
+	assert.Len(t, p.trackedEntities, 2)
+	// The evicted entity keeps its baseline in the observed tier
+	assert.Equal(t, 1, p.observed.len())
+}
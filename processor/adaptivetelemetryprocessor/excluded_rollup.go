@@ -0,0 +1,144 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+
+	"github.com/newrelic/nrdot-collector-components/internal/coreinternal/aggregateutil"
+)
+
+// excludedRollup merges the excluded processes of a batch into one _other resource per host,
+// so host-level totals built from process metrics stay correct while per-process series are dropped
+type excludedRollup struct {
+	hosts map[string]*hostRollup
+	order []string // host keys in first seen order, so the output is deterministic
+}
+
+// hostRollup holds the merged metrics of the excluded processes sharing the same host attributes
+type hostRollup struct {
+	resource pcommon.Resource // host attributes, without the process.* ones
+	scope    pcommon.InstrumentationScope
+	metrics  map[string]*rollupMetric
+	order    []string
+	count    int // excluded processes merged
+}
+
+// rollupMetric holds the grouped data points of one metric across excluded processes
+type rollupMetric struct {
+	details pmetric.Metric // name, unit and type, without data points
+	groups  aggregateutil.AggGroups
+}
+
+// newExcludedRollup returns the rollup of a batch, nil unless excluded_action is aggregate.
+// Shadow mode forwards excluded resources as they are, so a rollup would count them twice.
+func (p *processorImp) newExcludedRollup() *excludedRollup {
+	if p.config.ExcludedAction != excludedActionAggregate || p.config.Mode == modeShadow {
+		return nil
+	}
+	return &excludedRollup{hosts: make(map[string]*hostRollup)}
+}
+
+// add merges an excluded resource into the _other resource of its host and reports whether it did.
+// Only process resources are merged; summaries are skipped since their quantiles cannot be merged.
+func (r *excludedRollup) add(rm pmetric.ResourceMetrics) bool {
+	if r == nil || getResourceType(rm.Resource().Attributes()) != resourceTypeProcess {
+		return false
+	}
+
+	hostAttrs := pcommon.NewMap()
+	rm.Resource().Attributes().CopyTo(hostAttrs)
+	hostAttrs.RemoveIf(func(k string, _ pcommon.Value) bool {
+		return strings.HasPrefix(k, "process.") || k == internalFilterStageAttributeKey
+	})
+	key := buildFallbackIdentity(hostAttrs)
+
+	host, ok := r.hosts[key]
+	if !ok {
+		host = &hostRollup{
+			resource: pcommon.NewResource(),
+			scope:    pcommon.NewInstrumentationScope(),
+			metrics:  make(map[string]*rollupMetric),
+		}
+		hostAttrs.MoveTo(host.resource.Attributes())
+		if rm.ScopeMetrics().Len() > 0 {
+			rm.ScopeMetrics().At(0).Scope().CopyTo(host.scope)
+		}
+		r.hosts[key] = host
+		r.order = append(r.order, key)
+	}
+	host.count++
+
+	for i := 0; i < rm.ScopeMetrics().Len(); i++ {
+		metrics := rm.ScopeMetrics().At(i).Metrics()
+		for j := 0; j < metrics.Len(); j++ {
+			host.group(metrics.At(j))
+		}
+	}
+	return true
+}
+
+// group adds the data points of metric to the merged metric of the same name and type
+func (h *hostRollup) group(metric pmetric.Metric) {
+	if metric.Type() == pmetric.MetricTypeSummary || metric.Type() == pmetric.MetricTypeEmpty {
+		return
+	}
+	key := metric.Name() + "|" + metric.Type().String()
+	merged, ok := h.metrics[key]
+	if !ok {
+		merged = &rollupMetric{details: pmetric.NewMetric()}
+		aggregateutil.CopyMetricDetails(metric, merged.details)
+		h.metrics[key] = merged
+		h.order = append(h.order, key)
+	}
+
+	// Grouping moves the data points, and the input is returned as is if processing is cancelled
+	points := pmetric.NewMetric()
+	metric.CopyTo(points)
+	aggregateutil.GroupDataPoints(points, &merged.groups)
+}
+
+// appendExcludedRollup appends the _other resource of every host to filtered
+func (p *processorImp) appendExcludedRollup(r *excludedRollup, filtered pmetric.Metrics) {
+	if r == nil {
+		return
+	}
+	for _, key := range r.order {
+		host := r.hosts[key]
+		dest := filtered.ResourceMetrics().AppendEmpty()
+		host.resource.CopyTo(dest.Resource())
+		attrs := dest.Resource().Attributes()
+		attrs.PutStr("process.executable.name", otherExecutableName)
+		attrs.PutBool(atpEnabledAttributeKey, true)
+		atp := atpDetailsMap(dest.Resource(), "stage", p.logger)
+		atp.PutStr("stage", stageAggregated)
+		atp.PutInt("aggregated_count", int64(host.count))
+
+		sm := dest.ScopeMetrics().AppendEmpty()
+		host.scope.CopyTo(sm.Scope())
+		for _, metricKey := range host.order {
+			merged := host.metrics[metricKey]
+			metric := sm.Metrics().AppendEmpty()
+			aggregateutil.CopyMetricDetails(merged.details, metric)
+			aggregateutil.MergeDataPoints(metric, p.aggregateFunctionFor(metric.Name()), merged.groups)
+		}
+
+		p.logger.Debug("Aggregated excluded processes",
+			zap.String("host", key),
+			zap.Int("process_count", host.count),
+			zap.Int("metric_count", len(host.order)))
+	}
+}
+
+// aggregateFunctionFor returns how the data points of metric are merged in _other resources, with default
+func (p *processorImp) aggregateFunctionFor(metric string) aggregateutil.AggregationType {
+	if p.config.AggregateFunctions[metric] == aggregateFunctionMax {
+		return aggregateutil.Max
+	}
+	return aggregateutil.Sum
+}
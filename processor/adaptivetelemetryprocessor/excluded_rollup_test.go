@@ -0,0 +1,112 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// createRollupTestMetrics returns three quiet processes on testhost, one on otherhost and a busy one on testhost
+func createRollupTestMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	addProcessToMetrics(md, "/usr/bin/quiet1", 1, 10.0)
+	addProcessToMetrics(md, "/usr/bin/quiet2", 2, 20.0)
+	addProcessToMetrics(md, "/usr/bin/quiet3", 3, 5.0)
+	addProcessToMetrics(md, "/usr/bin/elsewhere", 4, 15.0)
+	md.ResourceMetrics().At(3).Resource().Attributes().PutStr("host.name", "otherhost")
+	addProcessToMetrics(md, "/usr/bin/busy", 5, 80.0)
+	return md
+}
+
+// findOtherResource returns the _other resource of host, failing when there is none
+func findOtherResource(t *testing.T, md pmetric.Metrics, host string) pmetric.ResourceMetrics {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		attrs := rm.Resource().Attributes()
+		name, _ := attrs.Get("process.executable.name")
+		hostName, _ := attrs.Get("host.name")
+		if name.Str() == otherExecutableName && hostName.Str() == host {
+			return rm
+		}
+	}
+	require.Failf(t, "missing _other resource", "host %s", host)
+	return pmetric.NewResourceMetrics()
+}
+
+func TestExcludedProcessesAggregatedPerHost(t *testing.T) {
+	p := newTestProcessor(t, &Config{ExcludedAction: excludedActionAggregate})
+
+	out, err := p.processMetrics(t.Context(), createRollupTestMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 3, out.ResourceMetrics().Len(), "busy process and one _other resource per host")
+
+	other := findOtherResource(t, out, "testhost")
+	attrs := other.Resource().Attributes()
+	_, hasPID := attrs.Get("process.pid")
+	assert.False(t, hasPID, "process attributes are not carried over")
+	atp, ok := attrs.Get(atpAttributeKey)
+	require.True(t, ok)
+	assert.Contains(t, atp.Str(), `"stage":"aggregated"`)
+	assert.Contains(t, atp.Str(), `"aggregated_count":3`)
+	require.Equal(t, 1, countMetricsInResource(other))
+	points := other.ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+	require.Equal(t, 1, points.Len())
+	assert.InDelta(t, 35.0, points.At(0).DoubleValue(), 1e-9, "utilization of the excluded processes is summed")
+
+	other = findOtherResource(t, out, "otherhost")
+	points = other.ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+	assert.InDelta(t, 15.0, points.At(0).DoubleValue(), 1e-9)
+}
+
+func TestExcludedProcessesAggregateFunctionMax(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		ExcludedAction:     excludedActionAggregate,
+		AggregateFunctions: map[string]string{"process.cpu.utilization": aggregateFunctionMax},
+	})
+
+	out, err := p.processMetrics(t.Context(), createRollupTestMetrics())
+	require.NoError(t, err)
+
+	points := findOtherResource(t, out, "testhost").ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+	require.Equal(t, 1, points.Len())
+	assert.InDelta(t, 20.0, points.At(0).DoubleValue(), 1e-9)
+}
+
+func TestExcludedProcessesDroppedByDefault(t *testing.T) {
+	p := newTestProcessor(t, &Config{})
+
+	out, err := p.processMetrics(t.Context(), createRollupTestMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 1, out.ResourceMetrics().Len())
+	name, _ := out.ResourceMetrics().At(0).Resource().Attributes().Get("process.executable.name")
+	assert.Equal(t, "/usr/bin/busy", name.Str())
+}
+
+func TestExcludedProcessesNotAggregatedInShadowMode(t *testing.T) {
+	p := newTestProcessor(t, &Config{ExcludedAction: excludedActionAggregate, Mode: modeShadow})
+
+	out, err := p.processMetrics(t.Context(), createRollupTestMetrics())
+	require.NoError(t, err)
+	assert.Equal(t, 5, out.ResourceMetrics().Len(), "shadow mode forwards every process, a rollup would count them twice")
+}
+
+func TestExcludedRollupSkipsNonProcessResources(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("host.name", "testhost")
+	rm.Resource().Attributes().PutStr("device", "sda")
+	rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+
+	p := newTestProcessor(t, &Config{ExcludedAction: excludedActionAggregate})
+	rollup := p.newExcludedRollup()
+	assert.False(t, rollup.add(rm))
+
+	out := pmetric.NewMetrics()
+	p.appendExcludedRollup(rollup, out)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+}
@@ -24,6 +24,8 @@ func NewFactory() processor.Factory {
 		metadata.Type,
 		createDefaultConfig,
 		processor.WithMetrics(createMetricsProcessor, metadata.MetricsStability),
+		processor.WithLogs(createLogsProcessor, metadata.LogsStability),
+		processor.WithTraces(createTracesProcessor, metadata.TracesStability),
 	)
 }
 
@@ -54,14 +56,48 @@ func createMetricsProcessor(
 	cfg component.Config,
 	nextConsumer consumer.Metrics,
 ) (processor.Metrics, error) {
-	pCfg, ok := cfg.(*Config) // Fixed: proper type assertion
-	if !ok {
-		return nil, fmt.Errorf("invalid config type: expected *Config, got %T", cfg)
+	lifecycle, err := createSharedLifecycle(set, cfg)
+	if err != nil {
+		return nil, err
 	}
+	return &metricsProcessor{sharedLifecycle: lifecycle, nextConsumer: nextConsumer}, nil
+}
 
-	proc, err := newProcessor(set.Logger, pCfg, nextConsumer)
+// createLogsProcessor constructs the processor for logs pipelines.
+// Logs are gated on the entity state built by the metrics pipeline using the same processor config.
+func createLogsProcessor(
+	_ context.Context,
+	set processor.Settings,
+	cfg component.Config,
+	nextConsumer consumer.Logs,
+) (processor.Logs, error) {
+	lifecycle, err := createSharedLifecycle(set, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return proc, nil
+	return &logsProcessor{sharedLifecycle: lifecycle, nextConsumer: nextConsumer}, nil
+}
+
+// createTracesProcessor constructs the processor for traces pipelines.
+// Spans are gated on the entity state built by the metrics pipeline using the same processor config.
+func createTracesProcessor(
+	_ context.Context,
+	set processor.Settings,
+	cfg component.Config,
+	nextConsumer consumer.Traces,
+) (processor.Traces, error) {
+	lifecycle, err := createSharedLifecycle(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tracesProcessor{sharedLifecycle: lifecycle, nextConsumer: nextConsumer}, nil
+}
+
+// createSharedLifecycle validates the config type and acquires the processor shared by it.
+func createSharedLifecycle(set processor.Settings, cfg component.Config) (*sharedLifecycle, error) {
+	pCfg, ok := cfg.(*Config) // Fixed: proper type assertion
+	if !ok {
+		return nil, fmt.Errorf("invalid config type: expected *Config, got %T", cfg)
+	}
+	return newSharedLifecycle(set, pCfg)
 }
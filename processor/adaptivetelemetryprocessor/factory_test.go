@@ -4,43 +4,17 @@
 package adaptivetelemetryprocessor
 
 import (
-	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"go.opentelemetry.io/collector/component"
-	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
-	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/processor"
 	"go.uber.org/zap/zaptest"
 
 	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/metadata"
 )
 
-// mockMetricsConsumer implements consumer.Metrics for testing
-type mockMetricsConsumer struct {
-	metrics []pmetric.Metrics
-}
-
-func newMockMetricsConsumer() *mockMetricsConsumer {
-	return &mockMetricsConsumer{
-		metrics: make([]pmetric.Metrics, 0),
-	}
-}
-
-func (*mockMetricsConsumer) Capabilities() consumer.Capabilities {
-	return consumer.Capabilities{MutatesData: false}
-}
-
-func (m *mockMetricsConsumer) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
-	metricsCopy := pmetric.NewMetrics()
-	md.CopyTo(metricsCopy)
-	m.metrics = append(m.metrics, metricsCopy)
-	return nil
-}
-
 func TestNewFactory(t *testing.T) {
 	factory := NewFactory()
 	assert.Equal(t, metadata.Type, factory.Type())
@@ -69,7 +43,6 @@ func TestCreateDefaultConfig(t *testing.T) {
 
 func TestCreateProcessor(t *testing.T) {
 	logger := zaptest.NewLogger(t)
-	mockConsumer := newMockMetricsConsumer()
 
 	tests := []struct {
 		name          string
@@ -110,7 +83,7 @@ func TestCreateProcessor(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			test.config.Normalize()
-			proc, err := newProcessor(logger, test.config, mockConsumer)
+			proc, err := newProcessor(telemetrySettings(logger), test.config)
 
 			if test.errorExpected {
 				require.Error(t, err)
@@ -125,7 +98,6 @@ func TestCreateProcessor(t *testing.T) {
 
 func TestCapabilities(t *testing.T) {
 	logger := zaptest.NewLogger(t)
-	mockConsumer := newMockMetricsConsumer()
 
 	config := &Config{
 		MetricThresholds: map[string]float64{},
@@ -133,7 +105,7 @@ func TestCapabilities(t *testing.T) {
 		EnableStorage:    func() *bool { b := false; return &b }(), // Disable storage for test
 	}
 
-	proc, err := newProcessor(logger, config, mockConsumer)
+	proc, err := newProcessor(telemetrySettings(logger), config)
 	require.NoError(t, err)
 
 	caps := proc.Capabilities()
@@ -142,7 +114,6 @@ func TestCapabilities(t *testing.T) {
 
 func TestStartShutdown(t *testing.T) {
 	logger := zaptest.NewLogger(t)
-	mockConsumer := newMockMetricsConsumer()
 
 	config := &Config{
 		MetricThresholds: map[string]float64{},
@@ -150,7 +121,7 @@ func TestStartShutdown(t *testing.T) {
 		EnableStorage:    func() *bool { b := false; return &b }(), // Disable storage for test
 	}
 
-	proc, err := newProcessor(logger, config, mockConsumer)
+	proc, err := newProcessor(telemetrySettings(logger), config)
 	require.NoError(t, err)
 
 	// Start should succeed
@@ -251,9 +222,7 @@ func TestCreateMetricsProcessorWithFactoryExtended(t *testing.T) {
 	// Context for creating processors
 	ctx := t.Context()
 	settings := processor.Settings{
-		TelemetrySettings: component.TelemetrySettings{
-			Logger: zaptest.NewLogger(t),
-		},
+		TelemetrySettings: telemetrySettings(zaptest.NewLogger(t)),
 	}
 
 	for _, tc := range testCases {
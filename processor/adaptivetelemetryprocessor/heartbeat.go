@@ -0,0 +1,67 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// heartbeatDue reports whether an excluded entity has not been forwarded within the heartbeat interval,
+// and if so records that it is forwarded now
+func (p *processorImp) heartbeatDue(id string, now time.Time) bool {
+	if p.config.HeartbeatInterval <= 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if last, ok := p.heartbeats[id]; ok && now.Sub(last) < p.config.HeartbeatInterval {
+		return false
+	}
+	if p.heartbeats == nil {
+		p.heartbeats = make(map[string]time.Time)
+	}
+	p.heartbeats[id] = now
+	return true
+}
+
+// recordForwarded records that a stage included an entity, so its next heartbeat is due one interval later
+func (p *processorImp) recordForwarded(id string, now time.Time) {
+	if p.config.HeartbeatInterval <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.heartbeats == nil {
+		p.heartbeats = make(map[string]time.Time)
+	}
+	p.heartbeats[id] = now
+}
+
+// cleanupHeartbeats forgets entities not forwarded for two heartbeat intervals, which are no longer reporting
+func (p *processorImp) cleanupHeartbeats(now time.Time) {
+	if p.config.HeartbeatInterval <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := now.Add(-2 * p.config.HeartbeatInterval)
+	removed := 0
+	for id, last := range p.heartbeats {
+		if last.Before(cutoff) {
+			delete(p.heartbeats, id)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		p.logger.Debug("Removed heartbeat times of entities no longer reporting",
+			zap.Int("removed_count", removed),
+			zap.Int("remaining_count", len(p.heartbeats)))
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// createIdleProcessMetrics returns an idle process reporting CPU utilization and memory usage
+func createIdleProcessMetrics() pmetric.Metrics {
+	md := createTestProcessMetrics("/usr/bin/idle", 9, 1.0)
+	memory := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty()
+	memory.SetName("process.memory.usage")
+	memory.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1 << 20)
+	return md
+}
+
+func TestHeartbeatForExcludedEntity(t *testing.T) {
+	p := newTestProcessor(t, &Config{HeartbeatInterval: time.Hour})
+
+	// First seen: forwarded once, tagged as a heartbeat
+	out, err := p.processMetrics(t.Context(), createIdleProcessMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 1, out.ResourceMetrics().Len())
+	atp, ok := out.ResourceMetrics().At(0).Resource().Attributes().Get(atpAttributeKey)
+	require.True(t, ok)
+	assert.Contains(t, atp.Str(), `"stage":"heartbeat"`)
+	assert.Equal(t, 2, countMetricsInResource(out.ResourceMetrics().At(0)))
+	assert.Empty(t, p.trackedEntities, "heartbeats do not track the entity")
+
+	// Within the interval: dropped
+	out, err = p.processMetrics(t.Context(), createIdleProcessMetrics())
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+
+	// Once the interval has passed: forwarded again
+	for id := range p.heartbeats {
+		p.heartbeats[id] = time.Now().Add(-61 * time.Minute)
+	}
+	out, err = p.processMetrics(t.Context(), createIdleProcessMetrics())
+	require.NoError(t, err)
+	assert.Equal(t, 1, out.ResourceMetrics().Len())
+}
+
+func TestHeartbeatMetricsSubset(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		HeartbeatInterval: time.Hour,
+		HeartbeatMetrics:  []string{"process.memory.usage"},
+	})
+
+	out, err := p.processMetrics(t.Context(), createIdleProcessMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 1, out.ResourceMetrics().Len())
+	metrics := out.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 1, metrics.Len())
+	assert.Equal(t, "process.memory.usage", metrics.At(0).Name())
+}
+
+func TestHeartbeatDisabled(t *testing.T) {
+	p := newTestProcessor(t, &Config{})
+
+	out, err := p.processMetrics(t.Context(), createIdleProcessMetrics())
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+	assert.Empty(t, p.heartbeats)
+}
+
+func TestHeartbeatCountsFromLastInclusion(t *testing.T) {
+	p := newTestProcessor(t, &Config{HeartbeatInterval: time.Hour})
+
+	// Included by its threshold, so no heartbeat is due for an interval
+	_, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/busy", 1, 80.0))
+	require.NoError(t, err)
+	require.Len(t, p.heartbeats, 1)
+	for id := range p.heartbeats {
+		assert.False(t, p.heartbeatDue(id, time.Now()))
+		assert.True(t, p.heartbeatDue(id, time.Now().Add(time.Hour)))
+	}
+}
+
+func TestCleanupHeartbeats(t *testing.T) {
+	p := newTestProcessor(t, &Config{HeartbeatInterval: time.Minute})
+	now := time.Now()
+	p.heartbeats["gone"] = now.Add(-3 * time.Minute)
+	p.heartbeats["alive"] = now.Add(-time.Minute)
+
+	p.cleanupHeartbeats(now)
+	assert.NotContains(t, p.heartbeats, "gone")
+	assert.Contains(t, p.heartbeats, "alive")
+}
+
+func TestHeartbeatSkippedWithoutHeartbeatMetrics(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		HeartbeatInterval: time.Hour,
+		HeartbeatMetrics:  []string{"process.open_file_descriptors"},
+	})
+
+	out, err := p.processMetrics(t.Context(), createIdleProcessMetrics())
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len(), "nothing to forward as a heartbeat")
+	assert.Empty(t, p.heartbeats, "no heartbeat is recorded")
+}
@@ -0,0 +1,93 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import "math"
+
+// getEnterBreaches returns the consecutive evaluations at or above a threshold before the threshold stage includes an entity, with default
+func (cfg *Config) getEnterBreaches() int {
+	if cfg.EnterBreaches > 0 {
+		return cfg.EnterBreaches
+	}
+	return 1
+}
+
+// getExitQuietIntervals returns the consecutive evaluations below every exit threshold before the threshold stage
+// stops including an entity, with default
+func (cfg *Config) getExitQuietIntervals() int {
+	if cfg.ExitQuietIntervals > 0 {
+		return cfg.ExitQuietIntervals
+	}
+	return 1
+}
+
+// exitThresholdFor returns the value metric must stay at or above to keep an included entity, at most threshold.
+// threshold is the effective threshold of metric. The exit_thresholds entry is set against the static threshold,
+// so a dynamic threshold keeps the same exit ratio: with a threshold of 50 and an exit threshold of 30, an entity
+// included by a dynamic threshold of 80 stays while the metric is at or above 48.
+func (cfg *Config) exitThresholdFor(metric string, threshold float64) float64 {
+	exit, ok := cfg.ExitThresholds[metric]
+	if !ok {
+		return threshold
+	}
+	if static, hasStatic := cfg.MetricThresholds[metric]; hasStatic && static > 0 {
+		return math.Min(threshold*exit/static, threshold)
+	}
+	return math.Min(exit, threshold)
+}
+
+// staticThresholdBreach returns the first metric at or above its static threshold, "" if none, and whether
+// any metric is at or above its exit threshold
+func staticThresholdBreach(values map[string]float64, cfg *Config) (string, bool) {
+	exceeded, held := "", false
+	for m, v := range values {
+		threshold, ok := cfg.MetricThresholds[m]
+		if !ok {
+			continue
+		}
+		// If threshold is 0.0, it means "always include if metric present"
+		if threshold == 0.0 || v >= threshold {
+			exceeded = m
+		}
+		if v >= cfg.exitThresholdFor(m, threshold) {
+			held = true
+		}
+		if exceeded != "" {
+			break
+		}
+	}
+	return exceeded, held
+}
+
+// applyThresholdHysteresis reports whether the threshold stage includes the entity. exceeded reports a metric at
+// or above its threshold and held a metric at or above its exit threshold. An entity enters after enter_breaches
+// exceeded evaluations in a row, and once included leaves after exit_quiet_intervals evaluations in a row
+// where no metric is held.
+func (te *trackedEntity) applyThresholdHysteresis(exceeded, held bool, cfg *Config) bool {
+	if te.ThresholdActive {
+		if exceeded || held {
+			te.QuietIntervals = 0
+			return true
+		}
+		te.QuietIntervals++
+		if te.QuietIntervals < cfg.getExitQuietIntervals() {
+			return true
+		}
+		te.ThresholdActive = false
+		te.QuietIntervals = 0
+		return false
+	}
+
+	if !exceeded {
+		te.ThresholdBreaches = 0
+		return false
+	}
+	te.ThresholdBreaches++
+	if te.ThresholdBreaches < cfg.getEnterBreaches() {
+		return false
+	}
+	te.ThresholdActive = true
+	te.ThresholdBreaches = 0
+	return true
+}
@@ -0,0 +1,141 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forwardedAt processes one batch per value of a process hovering around the threshold and reports
+// for each whether the process was forwarded. Standard retention expires between batches, so the
+// threshold stage alone decides.
+func forwardedAt(t *testing.T, p *processorImp, cpuValues ...float64) []bool {
+	forwarded := make([]bool, 0, len(cpuValues))
+	for _, cpu := range cpuValues {
+		for _, te := range p.trackedEntities {
+			if !te.LastExceeded.IsZero() {
+				te.LastExceeded = te.LastExceeded.Add(-time.Duration(p.config.RetentionMinutes+1) * time.Minute)
+			}
+		}
+		out, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/hover", 11, cpu))
+		require.NoError(t, err)
+		forwarded = append(forwarded, out.ResourceMetrics().Len() == 1)
+	}
+	return forwarded
+}
+
+func TestHysteresisDefaultsKeepSingleComparison(t *testing.T) {
+	p := newTestProcessor(t, &Config{})
+	assert.Equal(t, []bool{true, false, true}, forwardedAt(t, p, 80, 40, 60))
+}
+
+func TestHysteresisEnterBreaches(t *testing.T) {
+	p := newTestProcessor(t, &Config{EnterBreaches: 3})
+	assert.Equal(t, []bool{false, false, true, true}, forwardedAt(t, p, 80, 80, 80, 80))
+}
+
+func TestHysteresisEnterBreachesMustBeConsecutive(t *testing.T) {
+	p := newTestProcessor(t, &Config{EnterBreaches: 2})
+	assert.Equal(t, []bool{false, false, false, true}, forwardedAt(t, p, 80, 10, 80, 80))
+}
+
+func TestHysteresisPendingEntityIsTracked(t *testing.T) {
+	p := newTestProcessor(t, &Config{EnterBreaches: 3, RetentionMinutes: 30})
+	forwardedAt(t, p, 80, 80)
+
+	require.Len(t, p.trackedEntities, 1)
+	for _, te := range p.trackedEntities {
+		assert.Equal(t, 2, te.ThresholdBreaches)
+		assert.False(t, te.ThresholdActive)
+		assert.True(t, te.LastExceeded.IsZero(), "a pending entity is not retained")
+	}
+
+	// Cleanup keeps a pending entity while it reports
+	p.cleanupExpiredEntities()
+	assert.Len(t, p.trackedEntities, 1)
+	for _, te := range p.trackedEntities {
+		te.LastSeen = time.Now().Add(-time.Hour)
+	}
+	p.cleanupExpiredEntities()
+	assert.Empty(t, p.trackedEntities)
+}
+
+func TestHysteresisExitThresholds(t *testing.T) {
+	p := newTestProcessor(t, &Config{ExitThresholds: map[string]float64{"process.cpu.utilization": 30.0}})
+
+	// Entered above 50, held above 30, left below 30, and entering again takes 50
+	assert.Equal(t, []bool{true, true, true, false, false, true}, forwardedAt(t, p, 80, 40, 35, 20, 40, 55))
+}
+
+func TestHysteresisExitThresholdsWithDynamicThresholds(t *testing.T) {
+	p := newTestProcessor(t, &Config{ExitThresholds: map[string]float64{"process.cpu.utilization": 30.0}}, withDynamicThresholds)
+	p.dynamicCustomThresholds["process.cpu.utilization"] = 80.0
+
+	// The exit threshold keeps its 30/50 ratio to the dynamic threshold of 80: entered at 80, held at 48 and above
+	assert.Equal(t, []bool{true, true, true, false, false, true}, forwardedAt(t, p, 90, 60, 48, 40, 60, 85))
+}
+
+func TestExitThresholdFor(t *testing.T) {
+	cfg := &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 50.0, "process.memory.usage": 0},
+		ExitThresholds:   map[string]float64{"process.cpu.utilization": 30.0, "process.memory.usage": 0},
+	}
+	assert.InDelta(t, 30.0, cfg.exitThresholdFor("process.cpu.utilization", 50.0), 1e-9, "static threshold")
+	assert.InDelta(t, 48.0, cfg.exitThresholdFor("process.cpu.utilization", 80.0), 1e-9, "same ratio to a dynamic threshold")
+	assert.Zero(t, cfg.exitThresholdFor("process.memory.usage", 10.0), "no ratio to a threshold of 0")
+	assert.Equal(t, 70.0, cfg.exitThresholdFor("process.disk.io", 70.0), "no exit threshold")
+}
+
+func TestHysteresisExitQuietIntervals(t *testing.T) {
+	p := newTestProcessor(t, &Config{ExitQuietIntervals: 2})
+	assert.Equal(t, []bool{true, true, true, true, false}, forwardedAt(t, p, 80, 10, 80, 10, 10))
+
+	for _, te := range p.trackedEntities {
+		assert.False(t, te.ThresholdActive)
+		assert.Zero(t, te.QuietIntervals)
+	}
+}
+
+func TestHysteresisQuietIntervalsDoNotExtendRetention(t *testing.T) {
+	p := newTestProcessor(t, &Config{ExitQuietIntervals: 3})
+	forwardedAt(t, p, 80)
+	var lastExceeded time.Time
+	for _, te := range p.trackedEntities {
+		lastExceeded = te.LastExceeded
+	}
+
+	forwardedAt(t, p, 10)
+	for _, te := range p.trackedEntities {
+		assert.True(t, te.LastExceeded.Before(lastExceeded), "not refreshed by a quiet interval")
+		assert.Equal(t, 1, te.QuietIntervals)
+	}
+}
+
+func TestApplyThresholdHysteresis(t *testing.T) {
+	cfg := &Config{EnterBreaches: 2, ExitQuietIntervals: 2}
+	te := &trackedEntity{}
+
+	assert.False(t, te.applyThresholdHysteresis(true, true, cfg))
+	assert.True(t, te.applyThresholdHysteresis(true, true, cfg))
+	assert.True(t, te.ThresholdActive)
+	assert.True(t, te.applyThresholdHysteresis(false, true, cfg), "held by an exit threshold")
+	assert.True(t, te.applyThresholdHysteresis(false, false, cfg), "first quiet interval")
+	assert.False(t, te.applyThresholdHysteresis(false, false, cfg), "second quiet interval")
+	assert.False(t, te.ThresholdActive)
+}
+
+func TestHysteresisStatePersisted(t *testing.T) {
+	data, err := json.Marshal(&trackedEntity{Identity: "process.11@testhost", ThresholdActive: true, QuietIntervals: 2})
+	require.NoError(t, err)
+
+	var restored trackedEntity
+	require.NoError(t, json.Unmarshal(data, &restored))
+	assert.True(t, restored.ThresholdActive)
+	assert.Equal(t, 2, restored.QuietIntervals)
+}
@@ -8,8 +8,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
 )
 
 func TestIncludeListBypassesAllFilters(t *testing.T) {
@@ -28,7 +30,6 @@ func TestIncludeListBypassesAllFilters(t *testing.T) {
 		logger:                   zap.NewNop(),
 		config:                   cfg,
 		trackedEntities:          make(map[string]*trackedEntity),
-		nextConsumer:             &mockMetricsConsumer{},
 		persistenceEnabled:       false,
 		dynamicThresholdsEnabled: false,
 		multiMetricEnabled:       false,
@@ -90,7 +91,6 @@ func TestIncludeListWithMultipleProcesses(t *testing.T) {
 		logger:                   zap.NewNop(),
 		config:                   cfg,
 		trackedEntities:          make(map[string]*trackedEntity),
-		nextConsumer:             &mockMetricsConsumer{},
 		persistenceEnabled:       false,
 		dynamicThresholdsEnabled: false,
 		multiMetricEnabled:       false,
@@ -161,7 +161,6 @@ func TestProcessExceedsThresholdButNotInIncludeList(t *testing.T) {
 		logger:                   zap.NewNop(),
 		config:                   cfg,
 		trackedEntities:          make(map[string]*trackedEntity),
-		nextConsumer:             &mockMetricsConsumer{},
 		persistenceEnabled:       false,
 		dynamicThresholdsEnabled: false,
 		multiMetricEnabled:       false,
@@ -250,3 +249,40 @@ func addProcessToMetrics(md pmetric.Metrics, processName string, pid int, cpuUti
 func ptrBool(b bool) *bool {
 	return &b
 }
+
+// testProcessorOption adjusts the configuration of a test processor before it is validated
+type testProcessorOption func(*Config)
+
+// withMetricThresholds evaluates the given metrics instead of process.cpu.utilization against 50
+func withMetricThresholds(thresholds map[string]float64) testProcessorOption {
+	return func(cfg *Config) {
+		cfg.MetricThresholds = thresholds
+	}
+}
+
+// withDynamicThresholds learns thresholds from the metric thresholds
+func withDynamicThresholds(cfg *Config) {
+	cfg.EnableDynamicThresholds = true
+}
+
+// newTestProcessor creates a processor from cfg the way the collector does: the configuration is
+// validated, then newProcessor normalizes it. Storage is disabled, and unless cfg or an option sets
+// metric thresholds, process.cpu.utilization is evaluated against 50.
+func newTestProcessor(t *testing.T, cfg *Config, opts ...testProcessorOption) *processorImp {
+	return newTestProcessorWithSettings(t, telemetrySettings(zaptest.NewLogger(t)), cfg, opts...)
+}
+
+// newTestProcessorWithSettings is newTestProcessor reporting logs and telemetry through set
+func newTestProcessorWithSettings(t *testing.T, set component.TelemetrySettings, cfg *Config, opts ...testProcessorOption) *processorImp {
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.MetricThresholds == nil {
+		cfg.MetricThresholds = map[string]float64{"process.cpu.utilization": 50.0}
+	}
+	cfg.EnableStorage = ptrBool(false)
+	require.NoError(t, cfg.Validate())
+	p, err := newProcessor(set, cfg)
+	require.NoError(t, err)
+	return p
+}
@@ -0,0 +1,220 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// inspectionReadHeaderTimeout bounds how long a client may take to send request headers
+const inspectionReadHeaderTimeout = 5 * time.Second
+
+// inspectedThreshold is a metric threshold as shown by the inspection endpoint
+type inspectedThreshold struct {
+	Static    *float64   `json:"static,omitempty"`
+	Dynamic   *float64   `json:"dynamic,omitempty"`
+	Min       *float64   `json:"min,omitempty"`
+	Max       *float64   `json:"max,omitempty"`
+	Weight    *float64   `json:"weight,omitempty"`
+	Samples   int64      `json:"samples,omitempty"` // Values the dynamic threshold was learned from
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// inspectedThresholds is the response of the thresholds page
+type inspectedThresholds struct {
+	DynamicThresholdsEnabled bool                           `json:"dynamic_thresholds_enabled"`
+	LastThresholdUpdate      time.Time                      `json:"last_threshold_update"`
+	CompositeThreshold       float64                        `json:"composite_threshold"`
+	Metrics                  map[string]*inspectedThreshold `json:"metrics"`
+}
+
+// decisionSummary lists a recorded decision without its checks
+type decisionSummary struct {
+	ResourceID string    `json:"resource_id"`
+	Time       time.Time `json:"time"`
+	Decision   string    `json:"decision"`
+	Stage      string    `json:"stage"`
+}
+
+// explanation is the response of the explain page
+type explanation struct {
+	*resourceDecision
+	Entity *trackedEntity `json:"entity,omitempty"` // Tracked state, when the resource is tracked
+}
+
+// startInspection serves the inspection pages on inspection_endpoint until stopInspection
+func (p *processorImp) startInspection() error {
+	ln, err := net.Listen("tcp", p.config.InspectionEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to listen on inspection_endpoint %q: %w", p.config.InspectionEndpoint, err)
+	}
+	p.inspection = &http.Server{
+		Handler:           p.inspectionHandler(),
+		ReadHeaderTimeout: inspectionReadHeaderTimeout,
+	}
+	go func(srv *http.Server) {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logger.Error("Inspection endpoint stopped", zap.Error(err))
+		}
+	}(p.inspection)
+
+	p.logger.Info("Inspection endpoint started", zap.String("endpoint", ln.Addr().String()))
+	return nil
+}
+
+// stopInspection stops the inspection server, waiting for running requests until ctx is done
+func (p *processorImp) stopInspection(ctx context.Context) {
+	if p.inspection == nil {
+		return
+	}
+	if err := p.inspection.Shutdown(ctx); err != nil {
+		p.logger.Warn("Failed to stop the inspection endpoint", zap.Error(err))
+	}
+	p.inspection = nil
+}
+
+// inspectionHandler returns the inspection pages:
+//   - /atp/entities: tracked entities, or the one with ?id=
+//   - /atp/thresholds: static and dynamic thresholds by metric
+//   - /atp/decisions: last decision of each evaluated resource, filtered by ?decision=keep|drop
+//   - /atp/explain?id=: stage by stage explanation of the last decision of a resource
+func (p *processorImp) inspectionHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /atp/entities", p.serveEntities)
+	mux.HandleFunc("GET /atp/thresholds", p.serveThresholds)
+	mux.HandleFunc("GET /atp/decisions", p.serveDecisions)
+	mux.HandleFunc("GET /atp/explain", p.serveExplain)
+	return mux
+}
+
+func (p *processorImp) serveEntities(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if id := r.URL.Query().Get("id"); id != "" {
+		te, ok := p.trackedEntities[id]
+		if !ok {
+			writeInspectionError(w, http.StatusNotFound, fmt.Sprintf("entity %q is not tracked", id))
+			return
+		}
+		writeInspectionJSON(w, te)
+		return
+	}
+
+	entities := make([]*trackedEntity, 0, len(p.trackedEntities))
+	for _, id := range slices.Sorted(maps.Keys(p.trackedEntities)) {
+		entities = append(entities, p.trackedEntities[id])
+	}
+	writeInspectionJSON(w, map[string]any{"count": len(entities), "entities": entities})
+}
+
+func (p *processorImp) serveThresholds(w http.ResponseWriter, _ *http.Request) {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	resp := inspectedThresholds{
+		DynamicThresholdsEnabled: p.dynamicThresholdsEnabled,
+		LastThresholdUpdate:      p.lastThresholdUpdate,
+		CompositeThreshold:       p.config.CompositeThreshold,
+		Metrics:                  make(map[string]*inspectedThreshold),
+	}
+	metric := func(name string) *inspectedThreshold {
+		if _, ok := resp.Metrics[name]; !ok {
+			resp.Metrics[name] = &inspectedThreshold{}
+		}
+		return resp.Metrics[name]
+	}
+	for name, v := range p.config.MetricThresholds {
+		metric(name).Static = &v
+	}
+	if p.dynamicThresholdsEnabled {
+		for name, v := range p.dynamicCustomThresholds {
+			t := metric(name)
+			t.Dynamic = &v
+			if stats, ok := p.dynamicThresholdStats[name]; ok {
+				t.Samples = stats.samples
+				t.UpdatedAt = &stats.updatedAt
+			}
+		}
+	}
+	for name, v := range p.config.MinThresholds {
+		metric(name).Min = &v
+	}
+	for name, v := range p.config.MaxThresholds {
+		metric(name).Max = &v
+	}
+	for name, v := range p.config.Weights {
+		metric(name).Weight = &v
+	}
+	writeInspectionJSON(w, resp)
+}
+
+func (p *processorImp) serveDecisions(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("decision")
+	if filter != "" && filter != shadowDecisionKeep && filter != shadowDecisionDrop {
+		writeInspectionError(w, http.StatusBadRequest, fmt.Sprintf("decision must be %q or %q", shadowDecisionKeep, shadowDecisionDrop))
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	decisions := make([]decisionSummary, 0)
+	for _, id := range slices.Sorted(maps.Keys(p.decisions.byID)) {
+		d := p.decisions.byID[id]
+		if filter != "" && d.Decision != filter {
+			continue
+		}
+		decisions = append(decisions, decisionSummary{ResourceID: d.ResourceID, Time: d.Time, Decision: d.Decision, Stage: d.Stage})
+	}
+	writeInspectionJSON(w, map[string]any{"count": len(decisions), "decisions": decisions})
+}
+
+func (p *processorImp) serveExplain(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeInspectionError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	d, ok := p.decisions.byID[id]
+	if !ok {
+		writeInspectionError(w, http.StatusNotFound, fmt.Sprintf("resource %q was not evaluated since the processor started", id))
+		return
+	}
+	writeInspectionJSON(w, explanation{resourceDecision: d, Entity: p.trackedEntities[id]})
+}
+
+// writeInspectionJSON writes v as indented JSON. Callers hold the locks guarding v.
+func writeInspectionJSON(w http.ResponseWriter, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeInspectionError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(data, '\n'))
+}
+
+func writeInspectionError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
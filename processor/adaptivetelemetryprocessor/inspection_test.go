@@ -0,0 +1,172 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+)
+
+func newInspectionTestProcessor(t *testing.T) (*processorImp, *httptest.Server) {
+	p := newTestProcessor(t, &Config{InspectionEndpoint: "localhost:0"})
+	srv := httptest.NewServer(p.inspectionHandler())
+	t.Cleanup(srv.Close)
+	return p, srv
+}
+
+// getInspection decodes the JSON page at path into v and returns the status code
+func getInspection(t *testing.T, srv *httptest.Server, path string, v any) int {
+	resp, err := http.Get(srv.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, v), string(body))
+	return resp.StatusCode
+}
+
+func checkOf(t *testing.T, d *resourceDecision, stage string) stageCheck {
+	for _, c := range d.Checks {
+		if c.Stage == stage {
+			return c
+		}
+	}
+	require.Failf(t, "stage not checked", "stage %s", stage)
+	return stageCheck{}
+}
+
+func TestInspectionExplainsDecisions(t *testing.T) {
+	p, srv := newInspectionTestProcessor(t)
+
+	md := createTestProcessMetrics("/usr/bin/idle", 10, 10)
+	addProcessToMetrics(md, "/usr/bin/busy", 11, 90)
+	idleID := buildResourceIdentity(md.ResourceMetrics().At(0).Resource())
+	busyID := buildResourceIdentity(md.ResourceMetrics().At(1).Resource())
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	var idle resourceDecision
+	require.Equal(t, http.StatusOK, getInspection(t, srv, "/atp/explain?id="+url.QueryEscape(idleID), &idle))
+	assert.Equal(t, shadowDecisionDrop, idle.Decision)
+	assert.Equal(t, stageFiltered, idle.Stage)
+	assert.Equal(t, stageCheck{stageStaticThreshold, checkNotMatched, "process.cpu.utilization 10 < 50"}, checkOf(t, &idle, stageStaticThreshold))
+	assert.Equal(t, checkDisabled, checkOf(t, &idle, stageAnomalyDetection).Result)
+	assert.Equal(t, stageCheck{stageStandardRetention, checkNotMatched, "not tracked"}, checkOf(t, &idle, stageStandardRetention))
+
+	var busy struct {
+		resourceDecision
+		Entity *trackedEntity `json:"entity"`
+	}
+	require.Equal(t, http.StatusOK, getInspection(t, srv, "/atp/explain?id="+url.QueryEscape(busyID), &busy))
+	assert.Equal(t, shadowDecisionKeep, busy.Decision)
+	assert.Equal(t, stageStaticThreshold, busy.Stage)
+	assert.Equal(t, stageCheck{stageStaticThreshold, checkMatched, "process.cpu.utilization 90 >= 50"}, checkOf(t, &busy.resourceDecision, stageStaticThreshold))
+	require.NotNil(t, busy.Entity, "tracked entity is included")
+	assert.Equal(t, 90.0, busy.Entity.CurrentValues["process.cpu.utilization"])
+
+	var dropped struct {
+		Count     int               `json:"count"`
+		Decisions []decisionSummary `json:"decisions"`
+	}
+	require.Equal(t, http.StatusOK, getInspection(t, srv, "/atp/decisions?decision=drop", &dropped))
+	require.Equal(t, 1, dropped.Count)
+	assert.Equal(t, idleID, dropped.Decisions[0].ResourceID)
+
+	var errResp map[string]string
+	assert.Equal(t, http.StatusNotFound, getInspection(t, srv, "/atp/explain?id=unknown", &errResp))
+	assert.Contains(t, errResp["error"], "was not evaluated")
+	assert.Equal(t, http.StatusBadRequest, getInspection(t, srv, "/atp/explain", &errResp))
+	assert.Equal(t, http.StatusBadRequest, getInspection(t, srv, "/atp/decisions?decision=maybe", &errResp))
+}
+
+func TestInspectionEntities(t *testing.T) {
+	p, srv := newInspectionTestProcessor(t)
+
+	md := createTestProcessMetrics("/usr/bin/busy", 11, 90)
+	busyID := buildResourceIdentity(md.ResourceMetrics().At(0).Resource())
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	var list struct {
+		Count    int              `json:"count"`
+		Entities []*trackedEntity `json:"entities"`
+	}
+	require.Equal(t, http.StatusOK, getInspection(t, srv, "/atp/entities", &list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, busyID, list.Entities[0].Identity)
+	assert.False(t, list.Entities[0].FirstSeen.IsZero())
+	assert.False(t, list.Entities[0].LastExceeded.IsZero())
+
+	var te trackedEntity
+	require.Equal(t, http.StatusOK, getInspection(t, srv, "/atp/entities?id="+url.QueryEscape(busyID), &te))
+	assert.Equal(t, 90.0, te.MaxValues["process.cpu.utilization"])
+
+	var errResp map[string]string
+	assert.Equal(t, http.StatusNotFound, getInspection(t, srv, "/atp/entities?id=unknown", &errResp))
+}
+
+func TestInspectionThresholds(t *testing.T) {
+	p, srv := newInspectionTestProcessor(t)
+	p.config.Weights = map[string]float64{"process.cpu.utilization": 0.7}
+	p.dynamicThresholdsEnabled = true
+	p.dynamicCustomThresholds["process.cpu.utilization"] = 62.5
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	p.recordThresholdUpdate("process.cpu.utilization", 12, updated)
+
+	var resp inspectedThresholds
+	require.Equal(t, http.StatusOK, getInspection(t, srv, "/atp/thresholds", &resp))
+	assert.True(t, resp.DynamicThresholdsEnabled)
+	cpu := resp.Metrics["process.cpu.utilization"]
+	require.NotNil(t, cpu)
+	assert.Equal(t, 50.0, *cpu.Static)
+	assert.Equal(t, 62.5, *cpu.Dynamic)
+	assert.Equal(t, 0.7, *cpu.Weight)
+	assert.Nil(t, cpu.Max)
+	assert.Equal(t, int64(12), cpu.Samples)
+	assert.True(t, updated.Equal(*cpu.UpdatedAt))
+}
+
+func TestInspectionEndpointLifecycle(t *testing.T) {
+	p := newTestProcessor(t, &Config{InspectionEndpoint: "localhost:0"})
+	require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+	require.NotNil(t, p.inspection)
+
+	require.NoError(t, p.Shutdown(t.Context()))
+	assert.Nil(t, p.inspection)
+
+	// A decision log is only kept when the endpoint is enabled
+	p = newTestProcessor(t, &Config{})
+	assert.Nil(t, p.decisions)
+}
+
+func TestInspectionEndpointListenError(t *testing.T) {
+	p := newTestProcessor(t, &Config{InspectionEndpoint: "256.0.0.1:0"})
+	assert.ErrorContains(t, p.Start(t.Context(), componenttest.NewNopHost()), "failed to listen on inspection_endpoint")
+	require.NoError(t, p.Shutdown(t.Context()))
+}
+
+func TestDecisionLogEvictsFirstRecorded(t *testing.T) {
+	l := newDecisionLog()
+	for i := 0; i < maxRecordedDecisions; i++ {
+		l.record(&resourceDecision{ResourceID: fmt.Sprint(i)})
+	}
+	l.record(&resourceDecision{ResourceID: "0", Decision: shadowDecisionKeep}) // re-recorded, not added
+	require.Len(t, l.byID, maxRecordedDecisions)
+
+	l.record(&resourceDecision{ResourceID: "new"})
+	assert.Len(t, l.byID, maxRecordedDecisions)
+	assert.NotContains(t, l.byID, "0", "first recorded is evicted")
+	assert.Contains(t, l.byID, "new")
+}
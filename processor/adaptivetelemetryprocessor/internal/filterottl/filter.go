@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// Modifications copyright New Relic, Inc.
+//
+// Modifications can be found at the following URL:
+// https://github.com/newrelic/nrdot-collector-components/commits/main/processor/adaptivetelemetryprocessor/internal/filterottl/filter.go?since=2026-10-16
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filterottl builds OTTL condition sequences for the resource and metric contexts.
+// It is the subset of the filterottl package of opentelemetry-collector-contrib used by the
+// filter processor, which cannot be imported from outside that module.
+package filterottl // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/filterottl"
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"go.opentelemetry.io/collector/component"
+)
+
+// NewBoolExprForResource creates a condition sequence of resource conditions that holds when any condition holds
+func NewBoolExprForResource(conditions []string, functions map[string]ottl.Factory[*ottlresource.TransformContext], errorMode ottl.ErrorMode, set component.TelemetrySettings) (*ottl.ConditionSequence[*ottlresource.TransformContext], error) {
+	parser, err := ottlresource.NewParser(functions, set)
+	if err != nil {
+		return nil, err
+	}
+	statements, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, err
+	}
+	c := ottlresource.NewConditionSequence(statements, set, ottlresource.WithConditionSequenceErrorMode(errorMode))
+	return &c, nil
+}
+
+// NewBoolExprForMetric creates a condition sequence of metric conditions that holds when any condition holds
+func NewBoolExprForMetric(conditions []string, functions map[string]ottl.Factory[*ottlmetric.TransformContext], errorMode ottl.ErrorMode, set component.TelemetrySettings) (*ottl.ConditionSequence[*ottlmetric.TransformContext], error) {
+	parser, err := ottlmetric.NewParser(functions, set)
+	if err != nil {
+		return nil, err
+	}
+	statements, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, err
+	}
+	c := ottlmetric.NewConditionSequence(statements, set, ottlmetric.WithConditionSequenceErrorMode(errorMode))
+	return &c, nil
+}
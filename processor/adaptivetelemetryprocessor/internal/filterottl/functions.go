@@ -0,0 +1,26 @@
+// Copyright The OpenTelemetry Authors
+// Modifications copyright New Relic, Inc.
+//
+// Modifications can be found at the following URL:
+// https://github.com/newrelic/nrdot-collector-components/commits/main/processor/adaptivetelemetryprocessor/internal/filterottl/functions.go?since=2026-10-16
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filterottl // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/filterottl"
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+)
+
+// StandardResourceFuncs returns the OTTL converters available to resource conditions
+func StandardResourceFuncs() map[string]ottl.Factory[*ottlresource.TransformContext] {
+	return ottlfuncs.StandardConverters[*ottlresource.TransformContext]()
+}
+
+// StandardMetricFuncs returns the OTTL converters available to metric conditions
+func StandardMetricFuncs() map[string]ottl.Factory[*ottlmetric.TransformContext] {
+	return ottlfuncs.StandardConverters[*ottlmetric.TransformContext]()
+}
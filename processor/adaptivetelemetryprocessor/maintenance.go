@@ -0,0 +1,146 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+
+	"github.com/newrelic/nrdot-collector-components/internal/coreinternal/timeutils"
+)
+
+// startMaintenance launches the persistence, cleanup and dynamic threshold loops, and the thresholds
+// file check when one is configured. From then on, batches no longer persist or clean up state, and
+// only record samples for the threshold loop.
+func (p *processorImp) startMaintenance() {
+	p.maintenanceRunning.Store(true)
+	p.startMaintenanceTicker(p.config.PersistInterval, p.withConfig(p.persistState))
+	p.startMaintenanceTicker(p.config.CleanupInterval, p.withConfig(func() { p.cleanupState(time.Now()) }))
+	p.startMaintenanceTicker(p.config.ThresholdUpdateInterval, p.withConfig(p.applyThresholdSamples))
+	if p.config.ThresholdsFile != "" {
+		p.startMaintenanceTicker(p.config.ThresholdsFileCheckInterval, p.reloadThresholdsFile)
+	}
+
+	p.logger.Debug("Maintenance loop started",
+		zap.Duration("persist_interval", p.config.PersistInterval),
+		zap.Duration("cleanup_interval", p.config.CleanupInterval),
+		zap.Duration("threshold_update_interval", p.config.ThresholdUpdateInterval))
+}
+
+// startMaintenanceTicker calls task every interval until stopMaintenance
+func (p *processorImp) startMaintenanceTicker(interval time.Duration, task func()) {
+	ticker := &timeutils.PolicyTicker{OnTickFunc: p.maintenanceTick(task)}
+	ticker.Start(interval)
+	p.maintenanceTickers = append(p.maintenanceTickers, ticker)
+}
+
+// maintenanceTick returns task as the tick of a maintenance ticker. Ticks hold p.maintenanceMu for
+// reading, so stopMaintenance waits for a running tick, and skip task once maintenance is stopped.
+func (p *processorImp) maintenanceTick(task func()) func() {
+	return func() {
+		p.maintenanceMu.RLock()
+		defer p.maintenanceMu.RUnlock()
+		if p.maintenanceRunning.Load() {
+			task()
+		}
+	}
+}
+
+// withConfig returns task holding p.configMu for reading, so a thresholds file reload does not
+// change the thresholds and rules while it runs
+func (p *processorImp) withConfig(task func()) func() {
+	return func() {
+		p.configMu.RLock()
+		defer p.configMu.RUnlock()
+		task()
+	}
+}
+
+// stopMaintenance stops the maintenance loops and waits for running tasks to return
+func (p *processorImp) stopMaintenance() {
+	for _, ticker := range p.maintenanceTickers {
+		ticker.Stop()
+	}
+	p.maintenanceTickers = nil
+
+	// A tick that fired as its ticker stopped either finishes before this or finds maintenance stopped
+	p.maintenanceMu.Lock()
+	p.maintenanceRunning.Store(false)
+	p.maintenanceMu.Unlock()
+}
+
+// persistState saves the tracked entities, when persistence is enabled
+func (p *processorImp) persistState() {
+	if !p.persistenceEnabled || p.storage == nil {
+		return
+	}
+	if err := p.persistTrackedEntities(); err != nil {
+		p.logger.Warn("Failed to persist tracked entities", zap.Error(err))
+	}
+	p.lastPersistenceOp = time.Now()
+}
+
+// cleanupState expires tracked entities, observed history, cumulative sum points, heartbeat times and
+// seasonal profiles, and enforces the tracked entity budget
+func (p *processorImp) cleanupState(now time.Time) {
+	if p.config.RetentionMinutes > 0 {
+		p.cleanupExpiredEntities()
+		p.cleanupCumulativeSums(now)
+	}
+	p.cleanupObservedEntities(now)
+	p.cleanupHeartbeats(now)
+	if p.config.DynamicThresholdMode == dynamicThresholdModeSeasonal {
+		p.cleanupSeasonalProfiles(now)
+	}
+	p.enforceTrackedEntityBudget(context.Background())
+}
+
+// recordThresholdSamples adds the values of a batch to the samples of the next dynamic threshold update
+func (p *processorImp) recordThresholdSamples(md pmetric.Metrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.thresholdSamples == nil {
+		p.thresholdSamples = make(map[string]metricAverageData)
+	}
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			metrics := sms.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				p.processMetricForAverages(metrics.At(k), p.thresholdSamples)
+			}
+		}
+	}
+}
+
+// applyThresholdSamples updates the dynamic thresholds from the samples recorded since the last update
+func (p *processorImp) applyThresholdSamples() {
+	if !p.dynamicThresholdsEnabled {
+		return
+	}
+
+	p.mu.Lock()
+	samples := p.thresholdSamples
+	p.thresholdSamples = nil
+	p.mu.Unlock()
+	if len(samples) == 0 {
+		return
+	}
+
+	finalizeAverages(samples)
+	updateContext := p.initializeDynamicUpdate()
+	newThresholds := p.calculateNewThresholds(samples, updateContext)
+	p.applyThresholdUpdates(newThresholds, updateContext)
+
+	p.logger.Debug("Dynamic thresholds updated",
+		zap.Int("updated_metrics", len(newThresholds)),
+		zap.Int("total_metrics_analyzed", len(samples)),
+		zap.Duration("duration", time.Since(updateContext.startTime)))
+	p.logDetailedThresholds()
+}
@@ -0,0 +1,146 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+)
+
+// countingStorage counts saves, which the maintenance loop makes from its own goroutine
+type countingStorage struct {
+	mockStorage
+	saves atomic.Int32
+}
+
+func (s *countingStorage) Save(map[string]*trackedEntity) error {
+	s.saves.Add(1)
+	return nil
+}
+
+// newMaintenanceTestProcessor returns a started processor whose maintenance loops tick every few milliseconds
+func newMaintenanceTestProcessor(t *testing.T, cfg *Config) *processorImp {
+	p := newTestProcessor(t, cfg)
+
+	// Below the configurable minimum, to keep the test fast
+	p.config.PersistInterval = 5 * time.Millisecond
+	p.config.CleanupInterval = 5 * time.Millisecond
+	p.config.ThresholdUpdateInterval = 5 * time.Millisecond
+	return p
+}
+
+func TestStopMaintenanceWaitsForRunningTick(t *testing.T) {
+	p := newTestProcessor(t, &Config{})
+	p.stopMaintenance() // Stopping maintenance that never started is a no-op
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var ticks atomic.Int32
+	p.maintenanceRunning.Store(true)
+	p.startMaintenanceTicker(time.Millisecond, func() {
+		if ticks.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+	})
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		p.stopMaintenance()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("stopMaintenance returned during a tick")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+
+	// A tick firing after stopMaintenance skips its task
+	ticked := ticks.Load()
+	p.maintenanceTick(func() { ticks.Add(1) })()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, ticked, ticks.Load(), "no tick after stopMaintenance returns")
+}
+
+func TestMaintenancePersistsWithoutBatches(t *testing.T) {
+	p := newMaintenanceTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 10},
+	})
+	storage := &countingStorage{}
+	p.storage = storage
+	p.persistenceEnabled = true
+
+	require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+	assert.Eventually(t, func() bool { return storage.saves.Load() >= 2 }, time.Second, time.Millisecond)
+
+	// Shutdown stops the loop before its final save
+	require.NoError(t, p.Shutdown(t.Context()))
+	saves := storage.saves.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, saves, storage.saves.Load())
+}
+
+func TestMaintenanceExpiresEntitiesWithoutBatches(t *testing.T) {
+	p := newMaintenanceTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 10},
+		RetentionMinutes: 5,
+	})
+	p.trackedEntities["expired"] = &trackedEntity{Identity: "expired", LastExceeded: time.Now().Add(-time.Hour)}
+	p.trackedEntities["recent"] = &trackedEntity{Identity: "recent", LastExceeded: time.Now()}
+
+	require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, p.Shutdown(t.Context())) })
+
+	assert.Eventually(t, func() bool {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		_, ok := p.trackedEntities["expired"]
+		return !ok
+	}, time.Second, time.Millisecond)
+	p.mu.RLock()
+	assert.Contains(t, p.trackedEntities, "recent")
+	p.mu.RUnlock()
+}
+
+func TestMaintenanceUpdatesThresholdsFromSamples(t *testing.T) {
+	p := newMaintenanceTestProcessor(t, &Config{
+		MetricThresholds:        map[string]float64{"process.cpu.utilization": 10},
+		EnableDynamicThresholds: true,
+		DynamicSmoothingFactor:  1,
+	})
+	require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+	t.Cleanup(func() { require.NoError(t, p.Shutdown(t.Context())) })
+
+	md := createTestProcessMetrics("/usr/bin/busy", 1, 50.0)
+	addProcessToMetrics(md, "/usr/bin/idle", 2, 10.0)
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	// base + average × 0.2
+	assert.Eventually(t, func() bool {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.dynamicCustomThresholds["process.cpu.utilization"] == 16
+	}, time.Second, time.Millisecond)
+}
+
+func TestMaintenanceIntervalsNormalize(t *testing.T) {
+	cfg := &Config{}
+	cfg.Normalize()
+	assert.Equal(t, time.Minute, cfg.PersistInterval)
+	assert.Equal(t, time.Minute, cfg.CleanupInterval)
+	assert.Equal(t, time.Minute, cfg.ThresholdUpdateInterval)
+
+	cfg = &Config{PersistInterval: time.Millisecond, CleanupInterval: 10 * time.Minute}
+	cfg.Normalize()
+	assert.Equal(t, time.Second, cfg.PersistInterval)
+	assert.Equal(t, 10*time.Minute, cfg.CleanupInterval)
+}
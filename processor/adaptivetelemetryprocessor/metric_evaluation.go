@@ -38,17 +38,17 @@ func (me *metricEvaluator) EvaluateResource(resourceMetrics pmetric.ResourceMetr
 // extractMetricValues delegates to processor's implementation in composite_metrics.go
 func (me *metricEvaluator) extractMetricValues(rm pmetric.ResourceMetrics) map[string]float64 {
 	// Delegate to the specialized implementation in processor
-	return me.processor.extractMetricValues(rm)
+	return me.processor.extractMetricValues(rm, me.config)
 }
 
 // detectAnomaly delegates to the specialized implementation in anomaly_detection.go
 func (me *metricEvaluator) detectAnomaly(trackedEntity *trackedEntity, currentValues map[string]float64) (bool, string) {
-	return detectAnomalyUtil(me.processor, trackedEntity, currentValues)
+	return detectAnomalyUtil(me.processor, trackedEntity, currentValues, me.config)
 }
 
 // calculateCompositeScore delegates to the specialized implementation in composite_metrics.go
 func (me *metricEvaluator) calculateCompositeScore(values map[string]float64) (float64, string) {
-	return me.processor.calculateCompositeGeneric(values)
+	return me.processor.calculateCompositeGeneric(values, me.config)
 }
 
 // UpdateDynamicThresholds delegates to the specialized implementation in dynamic_thresholds.go
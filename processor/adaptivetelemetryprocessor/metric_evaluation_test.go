@@ -74,7 +74,7 @@ func TestExtractMetricValues(t *testing.T) {
 			)
 
 			// Extract metric values
-			values := proc.extractMetricValues(md.ResourceMetrics().At(0))
+			values := proc.extractMetricValues(md.ResourceMetrics().At(0), proc.config)
 
 			// Verify values
 			assert.Len(t, values, len(tc.expectedValues))
@@ -479,7 +479,7 @@ func TestCalculateCompositeGenericScore(t *testing.T) {
 				config: tc.config,
 			}
 
-			score, _ := proc.calculateCompositeGeneric(tc.metricValues)
+			score, _ := proc.calculateCompositeGeneric(tc.metricValues, proc.config)
 			assert.InDelta(t, tc.expectedScore, score, 0.001)
 		})
 	}
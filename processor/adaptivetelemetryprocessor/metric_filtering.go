@@ -0,0 +1,112 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// recordTriggerMetrics stores the metrics that made the stage set on resource include the entity.
+// Anomaly detection records its metric when it fires, and retention stages and quiet intervals keep
+// the metrics of the inclusion they extend. Callers hold p.mu.
+func (p *processorImp) recordTriggerMetrics(resource pcommon.Resource, te *trackedEntity, values map[string]float64, cfg *Config) {
+	stageAttr, ok := resource.Attributes().Get(internalFilterStageAttributeKey)
+	if !ok {
+		return
+	}
+
+	var triggers []string
+	switch stageAttr.Str() {
+	case stageStaticThreshold:
+		for m, v := range values {
+			if threshold, has := cfg.MetricThresholds[m]; has && (threshold == 0.0 || v >= threshold) {
+				triggers = append(triggers, m)
+			}
+		}
+		// An entity held by exit thresholds keeps the metrics still at or above them
+		if len(triggers) == 0 {
+			for m, v := range values {
+				if threshold, has := cfg.MetricThresholds[m]; has && v >= cfg.exitThresholdFor(m, threshold) {
+					triggers = append(triggers, m)
+				}
+			}
+		}
+	case stageDynamicThreshold:
+		now := time.Now()
+		for m, v := range values {
+			if threshold, _, has := p.dynamicThresholdFor(te.Identity, m, now, cfg); has && v >= threshold {
+				triggers = append(triggers, m)
+			}
+		}
+		if len(triggers) == 0 {
+			for m, v := range values {
+				if threshold, _, has := p.dynamicThresholdFor(te.Identity, m, now, cfg); has && v >= cfg.exitThresholdFor(m, threshold) {
+					triggers = append(triggers, m)
+				}
+			}
+		}
+	case stageMultiMetric:
+		// Every metric with a threshold adds to the composite score
+		for m := range values {
+			if _, has := cfg.MetricThresholds[m]; has {
+				triggers = append(triggers, m)
+			}
+		}
+	default:
+		return
+	}
+	// Quiet intervals keep the entity with the metrics of its inclusion
+	if len(triggers) == 0 {
+		return
+	}
+	sort.Strings(triggers)
+	te.TriggerMetrics = triggers
+}
+
+// isEvaluationStage reports whether stage includes a resource because of its metric values
+func isEvaluationStage(stage string) bool {
+	switch stage {
+	case stageAnomalyDetection, stageStaticThreshold, stageDynamicThreshold, stageMultiMetric,
+		stageAnomalyRetention, stageStandardRetention:
+		return true
+	default:
+		return false
+	}
+}
+
+// filterIncludedMetrics reduces a resource included by an evaluation stage to the metrics that triggered
+// its inclusion and always_send_metrics, when metric_level_filtering is enabled. All metrics are forwarded
+// once per other_metrics_interval, and when the triggering metrics are unknown.
+func (p *processorImp) filterIncludedMetrics(rm pmetric.ResourceMetrics, id, stage string, now time.Time) {
+	if !p.config.MetricLevelFiltering || p.config.Mode == modeShadow || !isEvaluationStage(stage) {
+		return
+	}
+
+	p.mu.Lock()
+	te, ok := p.trackedEntities[id]
+	if !ok || len(te.TriggerMetrics) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	if p.config.OtherMetricsInterval > 0 && now.Sub(te.lastAllMetrics) >= p.config.OtherMetricsInterval {
+		te.lastAllMetrics = now
+		p.mu.Unlock()
+		return
+	}
+	keep := append(slices.Clone(te.TriggerMetrics), p.config.AlwaysSendMetrics...)
+	p.mu.Unlock()
+
+	before := countMetricsInResource(rm)
+	keepMetrics(rm, keep)
+	p.logger.Debug("Filtered metrics of included resource",
+		zap.String("resource_id", id),
+		zap.Strings("kept_metrics", keep),
+		zap.Int("dropped_count", before-countMetricsInResource(rm)))
+}
@@ -0,0 +1,115 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// createMultiMetricProcess returns a process reporting CPU utilization, memory usage, threads and uptime
+func createMultiMetricProcess(cpu float64) pmetric.Metrics {
+	md := createTestProcessMetrics("/usr/bin/worker", 7, cpu)
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for name, value := range map[string]int64{"process.memory.usage": 1 << 20, "process.threads": 12, "process.uptime": 3600} {
+		m := metrics.AppendEmpty()
+		m.SetName(name)
+		m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(value)
+	}
+	return md
+}
+
+// metricNames returns the names of the metrics of the only resource of md
+// withCPUAndMemoryThresholds evaluates process.memory.usage next to process.cpu.utilization
+func withCPUAndMemoryThresholds(cfg *Config) {
+	cfg.MetricThresholds = map[string]float64{
+		"process.cpu.utilization": 50.0,
+		"process.memory.usage":    1 << 30,
+	}
+}
+
+func metricNames(t *testing.T, md pmetric.Metrics) []string {
+	require.Equal(t, 1, md.ResourceMetrics().Len())
+	var names []string
+	sms := md.ResourceMetrics().At(0).ScopeMetrics()
+	for i := 0; i < sms.Len(); i++ {
+		for j := 0; j < sms.At(i).Metrics().Len(); j++ {
+			names = append(names, sms.At(i).Metrics().At(j).Name())
+		}
+	}
+	return names
+}
+
+func TestMetricLevelFilteringKeepsTriggerMetrics(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricLevelFiltering: true,
+		AlwaysSendMetrics:    []string{"process.uptime"},
+	}, withCPUAndMemoryThresholds)
+
+	out, err := p.processMetrics(t.Context(), createMultiMetricProcess(80.0))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"process.cpu.utilization", "process.uptime"}, metricNames(t, out),
+		"memory is evaluated but below its threshold, threads are never evaluated")
+
+	for _, te := range p.trackedEntities {
+		assert.Equal(t, []string{"process.cpu.utilization"}, te.TriggerMetrics)
+	}
+}
+
+func TestMetricLevelFilteringDuringRetention(t *testing.T) {
+	p := newTestProcessor(t, &Config{MetricLevelFiltering: true, RetentionMinutes: 30}, withCPUAndMemoryThresholds)
+
+	_, err := p.processMetrics(t.Context(), createMultiMetricProcess(80.0))
+	require.NoError(t, err)
+
+	// Below the threshold, the entity is retained with the metric that triggered its inclusion
+	out, err := p.processMetrics(t.Context(), createMultiMetricProcess(10.0))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process.cpu.utilization"}, metricNames(t, out))
+}
+
+func TestMetricLevelFilteringOtherMetricsInterval(t *testing.T) {
+	p := newTestProcessor(t, &Config{MetricLevelFiltering: true, OtherMetricsInterval: time.Hour}, withCPUAndMemoryThresholds)
+
+	// First inclusion forwards all metrics
+	out, err := p.processMetrics(t.Context(), createMultiMetricProcess(80.0))
+	require.NoError(t, err)
+	assert.Len(t, metricNames(t, out), 4)
+
+	// Within the interval only the trigger metric
+	out, err = p.processMetrics(t.Context(), createMultiMetricProcess(80.0))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process.cpu.utilization"}, metricNames(t, out))
+
+	// Once the interval has passed, all metrics again
+	for _, te := range p.trackedEntities {
+		te.lastAllMetrics = time.Now().Add(-61 * time.Minute)
+	}
+	out, err = p.processMetrics(t.Context(), createMultiMetricProcess(80.0))
+	require.NoError(t, err)
+	assert.Len(t, metricNames(t, out), 4)
+}
+
+func TestMetricLevelFilteringDisabled(t *testing.T) {
+	p := newTestProcessor(t, &Config{}, withCPUAndMemoryThresholds)
+
+	out, err := p.processMetrics(t.Context(), createMultiMetricProcess(80.0))
+	require.NoError(t, err)
+	assert.Len(t, metricNames(t, out), 4)
+}
+
+func TestMetricLevelFilteringSkipsNonEvaluationStages(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricLevelFiltering: true,
+		IncludeProcessList:   []string{"/usr/bin/worker"},
+	}, withCPUAndMemoryThresholds)
+
+	out, err := p.processMetrics(t.Context(), createMultiMetricProcess(10.0))
+	require.NoError(t, err)
+	assert.Len(t, metricNames(t, out), 4, "include-listed processes keep all their metrics")
+}
@@ -0,0 +1,329 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"math"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// validAggregations lists the accepted metric_aggregations values
+var validAggregations = []string{aggregationSum, aggregationMax, aggregationAvg, aggregationLast}
+
+// validHistogramStatistics lists the accepted histogram_statistics values
+var validHistogramStatistics = []string{
+	histogramStatisticMean, histogramStatisticCount,
+	histogramStatisticP50, histogramStatisticP95, histogramStatisticP99,
+}
+
+// histogramQuantiles maps the quantile statistics to the quantile they estimate
+var histogramQuantiles = map[string]float64{
+	histogramStatisticP50: 0.50,
+	histogramStatisticP95: 0.95,
+	histogramStatisticP99: 0.99,
+}
+
+// isValidAggregation reports whether aggregation is a known data point aggregation
+func isValidAggregation(aggregation string) bool {
+	for _, a := range validAggregations {
+		if a == aggregation {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidHistogramStatistic reports whether statistic is a known histogram statistic
+func isValidHistogramStatistic(statistic string) bool {
+	for _, s := range validHistogramStatistics {
+		if s == statistic {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregationFor returns how the data points of a metric are combined into one value
+func (cfg *Config) aggregationFor(metricName string) string {
+	if aggregation, ok := cfg.MetricAggregations[metricName]; ok && aggregation != "" {
+		return aggregation
+	}
+	return aggregationSum
+}
+
+// histogramStatisticFor returns the value derived from each histogram data point of a metric
+func (cfg *Config) histogramStatisticFor(metricName string) string {
+	if statistic, ok := cfg.HistogramStatistics[metricName]; ok && statistic != "" {
+		return statistic
+	}
+	return histogramStatisticMean
+}
+
+// pointAggregator combines data point values with one of the aggregations
+type pointAggregator struct {
+	aggregation string
+	total       float64
+	maxValue    float64
+	last        float64
+	lastTime    pcommon.Timestamp
+	count       int
+}
+
+// add folds the value of a data point observed at ts into the aggregate
+func (a *pointAggregator) add(value float64, ts pcommon.Timestamp) {
+	if a.count == 0 || value > a.maxValue {
+		a.maxValue = value
+	}
+	if a.count == 0 || ts >= a.lastTime {
+		a.last = value
+		a.lastTime = ts
+	}
+	a.total += value
+	a.count++
+}
+
+// value returns the aggregate, 0 when no data point was added
+func (a *pointAggregator) value() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	switch a.aggregation {
+	case aggregationMax:
+		return a.maxValue
+	case aggregationAvg:
+		return a.total / float64(a.count)
+	case aggregationLast:
+		return a.last
+	default:
+		return a.total
+	}
+}
+
+// numberValue returns the value of a gauge or sum data point, whatever its value type
+func numberValue(dp pmetric.NumberDataPoint) float64 {
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		return float64(dp.IntValue())
+	}
+	return dp.DoubleValue()
+}
+
+// extractNumberValue combines gauge or sum data points with aggregation
+func extractNumberValue(dataPoints pmetric.NumberDataPointSlice, aggregation string) float64 {
+	agg := pointAggregator{aggregation: aggregation}
+	for i := 0; i < dataPoints.Len(); i++ {
+		dp := dataPoints.At(i)
+		agg.add(numberValue(dp), dp.Timestamp())
+	}
+	return agg.value()
+}
+
+// extractHistogramValue derives statistic from each histogram data point and combines them with aggregation
+func extractHistogramValue(dataPoints pmetric.HistogramDataPointSlice, statistic, aggregation string) float64 {
+	agg := pointAggregator{aggregation: aggregation}
+	for i := 0; i < dataPoints.Len(); i++ {
+		dp := dataPoints.At(i)
+		agg.add(histogramStatistic(dp, statistic), dp.Timestamp())
+	}
+	return agg.value()
+}
+
+// extractExponentialHistogramValue derives statistic from each exponential histogram data point and combines them with aggregation
+func extractExponentialHistogramValue(dataPoints pmetric.ExponentialHistogramDataPointSlice, statistic, aggregation string) float64 {
+	agg := pointAggregator{aggregation: aggregation}
+	for i := 0; i < dataPoints.Len(); i++ {
+		dp := dataPoints.At(i)
+		agg.add(exponentialHistogramStatistic(dp, statistic), dp.Timestamp())
+	}
+	return agg.value()
+}
+
+// extractSummaryValue derives statistic from each summary data point and combines them with aggregation
+func extractSummaryValue(dataPoints pmetric.SummaryDataPointSlice, statistic, aggregation string) float64 {
+	agg := pointAggregator{aggregation: aggregation}
+	for i := 0; i < dataPoints.Len(); i++ {
+		dp := dataPoints.At(i)
+		agg.add(summaryStatistic(dp, statistic), dp.Timestamp())
+	}
+	return agg.value()
+}
+
+// bucketRange is a histogram bucket holding count observations between lower and upper
+type bucketRange struct {
+	lower, upper float64
+	count        uint64
+}
+
+// histogramStatistic derives statistic from an explicit bucket histogram data point
+func histogramStatistic(dp pmetric.HistogramDataPoint, statistic string) float64 {
+	if statistic == histogramStatisticCount {
+		return float64(dp.Count())
+	}
+	if dp.Count() == 0 {
+		return 0
+	}
+	if statistic == histogramStatisticMean && dp.HasSum() {
+		return dp.Sum() / float64(dp.Count())
+	}
+
+	bounds := dp.ExplicitBounds()
+	counts := dp.BucketCounts()
+	if bounds.Len() == 0 || counts.Len() != bounds.Len()+1 {
+		// A single bucket only tells the extremes, when recorded
+		return histogramExtremesValue(dp, statistic)
+	}
+
+	buckets := make([]bucketRange, 0, counts.Len())
+	for i := 0; i < counts.Len(); i++ {
+		var lower, upper float64
+		switch {
+		case i == 0:
+			upper = bounds.At(0)
+			lower = math.Min(0, upper)
+			if dp.HasMin() {
+				lower = math.Min(dp.Min(), upper)
+			}
+		case i == bounds.Len():
+			lower = bounds.At(i - 1)
+			upper = lower
+			if dp.HasMax() {
+				upper = math.Max(dp.Max(), lower)
+			}
+		default:
+			lower, upper = bounds.At(i-1), bounds.At(i)
+		}
+		buckets = append(buckets, bucketRange{lower: lower, upper: upper, count: counts.At(i)})
+	}
+	return bucketStatistic(buckets, statistic)
+}
+
+// histogramExtremesValue estimates statistic of a histogram without usable buckets from its sum, min and max
+func histogramExtremesValue(dp pmetric.HistogramDataPoint, statistic string) float64 {
+	switch {
+	case statistic != histogramStatisticMean && dp.HasMax():
+		return dp.Max()
+	case dp.HasSum():
+		return dp.Sum() / float64(dp.Count())
+	case dp.HasMin() && dp.HasMax():
+		return (dp.Min() + dp.Max()) / 2
+	default:
+		return 0
+	}
+}
+
+// exponentialHistogramStatistic derives statistic from an exponential histogram data point
+func exponentialHistogramStatistic(dp pmetric.ExponentialHistogramDataPoint, statistic string) float64 {
+	if statistic == histogramStatisticCount {
+		return float64(dp.Count())
+	}
+	if dp.Count() == 0 {
+		return 0
+	}
+	if statistic == histogramStatisticMean && dp.HasSum() {
+		return dp.Sum() / float64(dp.Count())
+	}
+
+	// Bucket index i covers (base^i, base^(i+1)], base = 2^(2^-scale)
+	base := math.Exp2(math.Exp2(-float64(dp.Scale())))
+	negative, positive := dp.Negative(), dp.Positive()
+	buckets := make([]bucketRange, 0, negative.BucketCounts().Len()+positive.BucketCounts().Len()+1)
+
+	// Most negative values first
+	for i := negative.BucketCounts().Len() - 1; i >= 0; i-- {
+		index := float64(negative.Offset()) + float64(i)
+		buckets = append(buckets, bucketRange{
+			lower: -math.Pow(base, index+1),
+			upper: -math.Pow(base, index),
+			count: negative.BucketCounts().At(i),
+		})
+	}
+	buckets = append(buckets, bucketRange{count: dp.ZeroCount()})
+	for i := 0; i < positive.BucketCounts().Len(); i++ {
+		index := float64(positive.Offset()) + float64(i)
+		buckets = append(buckets, bucketRange{
+			lower: math.Pow(base, index),
+			upper: math.Pow(base, index+1),
+			count: positive.BucketCounts().At(i),
+		})
+	}
+	value := bucketStatistic(buckets, statistic)
+	if dp.HasMin() {
+		value = math.Max(value, dp.Min())
+	}
+	if dp.HasMax() {
+		value = math.Min(value, dp.Max())
+	}
+	return value
+}
+
+// bucketStatistic estimates the mean or a quantile of observations spread evenly within ordered buckets
+func bucketStatistic(buckets []bucketRange, statistic string) float64 {
+	var total uint64
+	var midpointSum float64
+	for _, b := range buckets {
+		total += b.count
+		midpointSum += float64(b.count) * (b.lower + b.upper) / 2
+	}
+	if total == 0 {
+		return 0
+	}
+
+	q, isQuantile := histogramQuantiles[statistic]
+	if !isQuantile {
+		return midpointSum / float64(total)
+	}
+
+	rank := q * float64(total)
+	var cumulative float64
+	for _, b := range buckets {
+		if b.count == 0 {
+			continue
+		}
+		next := cumulative + float64(b.count)
+		if next >= rank {
+			return b.lower + (b.upper-b.lower)*(rank-cumulative)/float64(b.count)
+		}
+		cumulative = next
+	}
+	return buckets[len(buckets)-1].upper
+}
+
+// summaryStatistic derives statistic from a summary data point, interpolating between reported quantiles
+func summaryStatistic(dp pmetric.SummaryDataPoint, statistic string) float64 {
+	switch statistic {
+	case histogramStatisticCount:
+		return float64(dp.Count())
+	case histogramStatisticMean:
+		if dp.Count() == 0 {
+			return 0
+		}
+		return dp.Sum() / float64(dp.Count())
+	}
+
+	q := histogramQuantiles[statistic]
+	quantiles := dp.QuantileValues()
+	if quantiles.Len() == 0 {
+		return 0
+	}
+
+	below, above := -1, -1
+	for i := 0; i < quantiles.Len(); i++ {
+		qv := quantiles.At(i)
+		if qv.Quantile() <= q && (below < 0 || qv.Quantile() > quantiles.At(below).Quantile()) {
+			below = i
+		}
+		if qv.Quantile() >= q && (above < 0 || qv.Quantile() < quantiles.At(above).Quantile()) {
+			above = i
+		}
+	}
+	switch {
+	case below < 0:
+		return quantiles.At(above).Value()
+	case above < 0 || below == above:
+		return quantiles.At(below).Value()
+	}
+	lo, hi := quantiles.At(below), quantiles.At(above)
+	return lo.Value() + (hi.Value()-lo.Value())*(q-lo.Quantile())/(hi.Quantile()-lo.Quantile())
+}
@@ -0,0 +1,166 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestExtractNumberValue(t *testing.T) {
+	dps := pmetric.NewNumberDataPointSlice()
+	for i, v := range []float64{0.2, 0.5, 0.1} {
+		dp := dps.AppendEmpty()
+		dp.SetDoubleValue(v)
+		dp.SetTimestamp(pcommon.Timestamp(10 - i)) // first point is the latest
+	}
+
+	assert.InDelta(t, 0.8, extractNumberValue(dps, aggregationSum), 1e-9)
+	assert.InDelta(t, 0.5, extractNumberValue(dps, aggregationMax), 1e-9)
+	assert.InDelta(t, 0.8/3, extractNumberValue(dps, aggregationAvg), 1e-9)
+	assert.InDelta(t, 0.2, extractNumberValue(dps, aggregationLast), 1e-9)
+	assert.Zero(t, extractNumberValue(pmetric.NewNumberDataPointSlice(), aggregationMax))
+
+	ints := pmetric.NewNumberDataPointSlice()
+	ints.AppendEmpty().SetIntValue(42)
+	ints.AppendEmpty().SetIntValue(8)
+	assert.InDelta(t, 50.0, extractNumberValue(ints, aggregationSum), 1e-9)
+	assert.InDelta(t, 42.0, extractNumberValue(ints, aggregationMax), 1e-9)
+}
+
+func TestHistogramStatistic(t *testing.T) {
+	// 100 observations: 50 in (0, 10], 40 in (10, 100], 10 in (100, 1000]
+	dp := pmetric.NewHistogramDataPoint()
+	dp.SetCount(100)
+	dp.SetSum(4000)
+	dp.ExplicitBounds().FromRaw([]float64{10, 100, 1000})
+	dp.BucketCounts().FromRaw([]uint64{50, 40, 10, 0})
+
+	assert.InDelta(t, 100.0, histogramStatistic(dp, histogramStatisticCount), 1e-9)
+	assert.InDelta(t, 40.0, histogramStatistic(dp, histogramStatisticMean), 1e-9)
+	assert.InDelta(t, 10.0, histogramStatistic(dp, histogramStatisticP50), 1e-9)
+	assert.InDelta(t, 550.0, histogramStatistic(dp, histogramStatisticP95), 1e-9)
+	assert.InDelta(t, 910.0, histogramStatistic(dp, histogramStatisticP99), 1e-9)
+
+	t.Run("mean without sum uses bucket midpoints", func(t *testing.T) {
+		noSum := pmetric.NewHistogramDataPoint()
+		noSum.SetCount(2)
+		noSum.ExplicitBounds().FromRaw([]float64{10, 20})
+		noSum.BucketCounts().FromRaw([]uint64{0, 2, 0})
+		assert.InDelta(t, 15.0, histogramStatistic(noSum, histogramStatisticMean), 1e-9)
+	})
+
+	t.Run("overflow bucket is bounded by max", func(t *testing.T) {
+		overflow := pmetric.NewHistogramDataPoint()
+		overflow.SetCount(10)
+		overflow.SetMax(300)
+		overflow.ExplicitBounds().FromRaw([]float64{100})
+		overflow.BucketCounts().FromRaw([]uint64{0, 10})
+		assert.InDelta(t, 290.0, histogramStatistic(overflow, histogramStatisticP95), 1e-9)
+	})
+
+	t.Run("empty histogram", func(t *testing.T) {
+		assert.Zero(t, histogramStatistic(pmetric.NewHistogramDataPoint(), histogramStatisticP99))
+	})
+}
+
+func TestExponentialHistogramStatistic(t *testing.T) {
+	// Scale 0: bucket i covers (2^i, 2^(i+1)]
+	dp := pmetric.NewExponentialHistogramDataPoint()
+	dp.SetScale(0)
+	dp.SetCount(10)
+	dp.SetZeroCount(0)
+	dp.Positive().SetOffset(2)
+	dp.Positive().BucketCounts().FromRaw([]uint64{5, 5}) // (4, 8] and (8, 16]
+
+	assert.InDelta(t, 10.0, exponentialHistogramStatistic(dp, histogramStatisticCount), 1e-9)
+	assert.InDelta(t, 8.0, exponentialHistogramStatistic(dp, histogramStatisticP50), 1e-9)
+	assert.InDelta(t, 15.2, exponentialHistogramStatistic(dp, histogramStatisticP95), 1e-9)
+	assert.InDelta(t, 9.0, exponentialHistogramStatistic(dp, histogramStatisticMean), 1e-9)
+
+	dp.SetMax(12)
+	assert.InDelta(t, 12.0, exponentialHistogramStatistic(dp, histogramStatisticP95), 1e-9)
+
+	dp.SetSum(70)
+	assert.InDelta(t, 7.0, exponentialHistogramStatistic(dp, histogramStatisticMean), 1e-9)
+}
+
+func TestSummaryStatistic(t *testing.T) {
+	dp := pmetric.NewSummaryDataPoint()
+	dp.SetCount(4)
+	dp.SetSum(20)
+	for q, v := range map[float64]float64{0.5: 4, 0.9: 9, 1.0: 11} {
+		qv := dp.QuantileValues().AppendEmpty()
+		qv.SetQuantile(q)
+		qv.SetValue(v)
+	}
+
+	assert.InDelta(t, 5.0, summaryStatistic(dp, histogramStatisticMean), 1e-9)
+	assert.InDelta(t, 4.0, summaryStatistic(dp, histogramStatisticCount), 1e-9)
+	assert.InDelta(t, 4.0, summaryStatistic(dp, histogramStatisticP50), 1e-9)
+	assert.InDelta(t, 10.0, summaryStatistic(dp, histogramStatisticP95), 1e-9)
+	assert.InDelta(t, 10.8, summaryStatistic(dp, histogramStatisticP99), 1e-9)
+	assert.Zero(t, summaryStatistic(pmetric.NewSummaryDataPoint(), histogramStatisticP95))
+}
+
+func TestExtractMetricValuesTypes(t *testing.T) {
+	p := &processorImp{logger: zaptest.NewLogger(t)}
+	cfg := &Config{
+		MetricThresholds: map[string]float64{
+			"process.threads":               10,
+			"system.cpu.utilization":        0.5,
+			"http.server.request.duration":  0.2,
+			"rpc.server.duration":           0.2,
+			"system.processes.count":        100,
+			"process.cpu.utilization.total": 0.5,
+		},
+		MetricAggregations:  map[string]string{"system.cpu.utilization": aggregationMax},
+		HistogramStatistics: map[string]string{"http.server.request.duration": histogramStatisticP95},
+	}
+
+	md := pmetric.NewMetrics()
+	ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	threads := ms.AppendEmpty()
+	threads.SetName("process.threads")
+	threads.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(12)
+
+	count := ms.AppendEmpty()
+	count.SetName("system.processes.count")
+	count.SetEmptySum().DataPoints().AppendEmpty().SetIntValue(250)
+
+	cpu := ms.AppendEmpty()
+	cpu.SetName("system.cpu.utilization")
+	cpuPoints := cpu.SetEmptyGauge().DataPoints()
+	for _, v := range []float64{0.3, 0.6, 0.05} { // per-state points
+		cpuPoints.AppendEmpty().SetDoubleValue(v)
+	}
+
+	latency := ms.AppendEmpty()
+	latency.SetName("http.server.request.duration")
+	hdp := latency.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.SetCount(100)
+	hdp.SetSum(10)
+	hdp.ExplicitBounds().FromRaw([]float64{0.1, 1})
+	hdp.BucketCounts().FromRaw([]uint64{90, 10, 0})
+
+	rpc := ms.AppendEmpty()
+	rpc.SetName("rpc.server.duration")
+	sdp := rpc.SetEmptySummary().DataPoints().AppendEmpty()
+	sdp.SetCount(4)
+	sdp.SetSum(2)
+
+	values := p.extractMetricValues(md.ResourceMetrics().At(0), cfg)
+	require.Len(t, values, 5)
+	assert.InDelta(t, 12.0, values["process.threads"], 1e-9)
+	assert.InDelta(t, 250.0, values["system.processes.count"], 1e-9)
+	assert.InDelta(t, 0.6, values["system.cpu.utilization"], 1e-9)
+	assert.InDelta(t, 0.55, values["http.server.request.duration"], 1e-9)
+	assert.InDelta(t, 0.5, values["rpc.server.duration"], 1e-9)
+}
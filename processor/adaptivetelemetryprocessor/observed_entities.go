@@ -0,0 +1,140 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"container/list"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// observedEntity is the rolling metric history of an entity that is evaluated but not tracked,
+// because no stage included it or its retention expired. It gives anomaly detection a baseline
+// the first time a quiet entity spikes.
+type observedEntity struct {
+	id       string
+	history  map[string][]float64
+	lastSeen time.Time
+}
+
+// observedTier holds observed entities in least recently seen order, so the cap evicts the stalest first
+type observedTier struct {
+	entries map[string]*list.Element // values are *observedEntity
+	lru     *list.List               // front is the most recently seen
+}
+
+func newObservedTier() *observedTier {
+	return &observedTier{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// len returns the number of observed entities
+func (o *observedTier) len() int {
+	if o == nil {
+		return 0
+	}
+	return o.lru.Len()
+}
+
+// getMaxObservedEntities returns the observed tier cap, with default
+func (p *processorImp) getMaxObservedEntities() int {
+	if p.config.MaxObservedEntities > 0 {
+		return p.config.MaxObservedEntities
+	}
+	return defaultMaxObservedEntities
+}
+
+// getObservedRetention returns how long an observed entity is kept without being seen, with default
+func (p *processorImp) getObservedRetention() time.Duration {
+	minutes := p.config.ObservedRetentionMinutes
+	if minutes <= 0 {
+		minutes = defaultObservedRetentionMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// takeObservedHistory removes an entity from the observed tier and returns its history, nil if it is not observed.
+// Callers hold p.mu and take the history when the entity becomes tracked.
+func (p *processorImp) takeObservedHistory(id string) map[string][]float64 {
+	if p.observed == nil {
+		return nil
+	}
+	elem, ok := p.observed.entries[id]
+	if !ok {
+		return nil
+	}
+	p.observed.lru.Remove(elem)
+	delete(p.observed.entries, id)
+	return elem.Value.(*observedEntity).history
+}
+
+// observedHistory returns the history of an observed entity, nil if it is not observed. Callers hold p.mu.
+func (p *processorImp) observedHistory(id string) map[string][]float64 {
+	if p.observed == nil {
+		return nil
+	}
+	if elem, ok := p.observed.entries[id]; ok {
+		return elem.Value.(*observedEntity).history
+	}
+	return nil
+}
+
+// observeEntity stores the history of an untracked entity seen at now, evicting the least recently
+// seen entities beyond the cap. Callers hold p.mu.
+func (p *processorImp) observeEntity(id string, history map[string][]float64, now time.Time) {
+	if len(history) == 0 {
+		return
+	}
+	if p.observed == nil {
+		p.observed = newObservedTier()
+	}
+
+	if elem, ok := p.observed.entries[id]; ok {
+		entity := elem.Value.(*observedEntity)
+		entity.history = history
+		entity.lastSeen = now
+		p.observed.lru.MoveToFront(elem)
+		return
+	}
+
+	p.observed.entries[id] = p.observed.lru.PushFront(&observedEntity{id: id, history: history, lastSeen: now})
+
+	evicted := 0
+	for maxEntities := p.getMaxObservedEntities(); p.observed.lru.Len() > maxEntities; evicted++ {
+		oldest := p.observed.lru.Back()
+		p.observed.lru.Remove(oldest)
+		delete(p.observed.entries, oldest.Value.(*observedEntity).id)
+	}
+	if evicted > 0 {
+		p.logger.Debug("Evicted observed entities over the cap",
+			zap.Int("evicted_count", evicted),
+			zap.Int("max_observed_entities", p.getMaxObservedEntities()))
+	}
+}
+
+// cleanupObservedEntities drops observed entities not seen within the observed retention
+func (p *processorImp) cleanupObservedEntities(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.observed == nil {
+		return
+	}
+	cutoff := now.Add(-p.getObservedRetention())
+	removed := 0
+	for oldest := p.observed.lru.Back(); oldest != nil && oldest.Value.(*observedEntity).lastSeen.Before(cutoff); oldest = p.observed.lru.Back() {
+		p.observed.lru.Remove(oldest)
+		delete(p.observed.entries, oldest.Value.(*observedEntity).id)
+		removed++
+	}
+
+	if removed > 0 {
+		p.logger.Debug("Removed expired observed entities",
+			zap.Int("removed_count", removed),
+			zap.Int("remaining_count", p.observed.len()))
+	}
+}
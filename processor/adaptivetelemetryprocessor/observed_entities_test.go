@@ -0,0 +1,118 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// withShortAnomalyHistory detects a 200% change once 3 points of history are kept
+func withShortAnomalyHistory(cfg *Config) {
+	cfg.EnableAnomalyDetection = true
+	cfg.AnomalyHistorySize = 10
+	cfg.AnomalyMinDataPoints = 3
+	cfg.AnomalyChangeThreshold = 200
+}
+
+func processIdentity(execPath string, pid int64) string {
+	res := pcommon.NewResource()
+	res.Attributes().PutStr("process.executable.name", execPath)
+	res.Attributes().PutInt("process.pid", pid)
+	res.Attributes().PutStr("host.name", "testhost")
+	res.Attributes().PutStr("process.executable.path", execPath)
+	return buildResourceIdentity(res)
+}
+
+func TestObservedEntityAnomalyOnFirstSpike(t *testing.T) {
+	p := newTestProcessor(t, &Config{}, withShortAnomalyHistory)
+
+	// A quiet process is excluded, but its history is kept
+	for i := 0; i < 4; i++ {
+		out, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/worker", 7, 2.0))
+		require.NoError(t, err)
+		assert.Equal(t, 0, out.ResourceMetrics().Len())
+	}
+	assert.Empty(t, p.trackedEntities)
+	assert.Equal(t, 1, p.observed.len())
+
+	// Its first spike, still below the static threshold, is an anomaly against that baseline
+	out, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/worker", 7, 20.0))
+	require.NoError(t, err)
+	require.Equal(t, 1, out.ResourceMetrics().Len())
+	stage, _ := out.ResourceMetrics().At(0).Resource().Attributes().Get("process.atp")
+	assert.Contains(t, stage.Str(), stageAnomalyDetection)
+
+	// The entity is now tracked and carries the observed history
+	assert.Equal(t, 0, p.observed.len())
+	require.Len(t, p.trackedEntities, 1)
+	for _, te := range p.trackedEntities {
+		assert.Equal(t, []float64{2, 2, 2, 2, 2, 20}, te.MetricHistory["process.cpu.utilization"])
+	}
+}
+
+func TestObservedEntitiesCapEvictsLeastRecentlySeen(t *testing.T) {
+	p := newTestProcessor(t, &Config{MaxObservedEntities: 2}, withShortAnomalyHistory)
+	now := time.Now()
+	history := map[string][]float64{"process.cpu.utilization": {1}}
+
+	p.observeEntity("a", history, now)
+	p.observeEntity("b", history, now)
+	p.observeEntity("a", history, now) // a is now the most recently seen
+	p.observeEntity("c", history, now)
+
+	assert.Equal(t, 2, p.observed.len())
+	assert.NotNil(t, p.takeObservedHistory("a"))
+	assert.Nil(t, p.takeObservedHistory("b"))
+	assert.NotNil(t, p.takeObservedHistory("c"))
+	assert.Equal(t, 0, p.observed.len())
+
+	// Entities without history are not observed
+	p.observeEntity("d", nil, now)
+	assert.Equal(t, 0, p.observed.len())
+}
+
+func TestCleanupObservedEntities(t *testing.T) {
+	p := newTestProcessor(t, &Config{ObservedRetentionMinutes: 10}, withShortAnomalyHistory)
+	now := time.Now()
+	history := map[string][]float64{"process.cpu.utilization": {1}}
+
+	p.observeEntity("stale", history, now.Add(-20*time.Minute))
+	p.observeEntity("fresh", history, now.Add(-5*time.Minute))
+	p.cleanupObservedEntities(now)
+
+	assert.Equal(t, 1, p.observed.len())
+	assert.NotNil(t, p.takeObservedHistory("fresh"))
+}
+
+func TestExpiredTrackedEntityIsObserved(t *testing.T) {
+	p := newTestProcessor(t, &Config{}, withShortAnomalyHistory)
+	id := processIdentity("/usr/bin/busy", 1)
+
+	// Included by the static threshold, then expired
+	_, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/busy", 1, 80.0))
+	require.NoError(t, err)
+	require.Contains(t, p.trackedEntities, id)
+	p.trackedEntities[id].LastExceeded = time.Now().Add(-2 * time.Hour)
+	p.cleanupExpiredEntities()
+
+	assert.NotContains(t, p.trackedEntities, id)
+	assert.NotEmpty(t, p.takeObservedHistory(id))
+}
+
+func TestObservedConfigNormalize(t *testing.T) {
+	cfg := &Config{}
+	cfg.Normalize()
+	assert.Equal(t, defaultMaxObservedEntities, cfg.MaxObservedEntities)
+	assert.Equal(t, defaultObservedRetentionMinutes, cfg.ObservedRetentionMinutes)
+
+	cfg = &Config{MaxObservedEntities: 1 << 30, ObservedRetentionMinutes: 1 << 30}
+	cfg.Normalize()
+	assert.Equal(t, maxObservedEntities, cfg.MaxObservedEntities)
+	assert.Equal(t, maxObservedRetentionMinutes, cfg.ObservedRetentionMinutes)
+}
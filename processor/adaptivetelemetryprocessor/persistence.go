@@ -21,6 +21,7 @@ func (p *processorImp) loadTrackedEntities() error {
 	}
 
 	entities, err := p.storage.Load()
+	p.recordPersistenceTelemetry(persistenceOperationLoad, start, err)
 	if err != nil {
 		p.logger.Error("Failed to load tracked entities from storage",
 			zap.Error(err),
@@ -48,6 +49,24 @@ func (p *processorImp) loadTrackedEntities() error {
 	}
 
 	p.trackedEntities = entities
+
+	if p.config.DynamicThresholdMode == dynamicThresholdModeSeasonal {
+		profiles, err := p.storage.LoadSeasonalProfiles()
+		if err != nil {
+			// Entities are usable without profiles; seasonal learning restarts
+			p.logger.Warn("Failed to load seasonal profiles from storage", zap.Error(err))
+		} else {
+			p.seasonalProfiles = profiles
+		}
+	}
+	state, err := p.storage.LoadProcessorState()
+	switch {
+	case err != nil:
+		// Entities are usable without it; thresholds are learned again from the configuration
+		p.logger.Warn("Failed to load processor state from storage", zap.Error(err))
+	case state != nil:
+		p.restoreProcessorState(state, time.Now())
+	}
 	duration := time.Since(start)
 
 	p.logger.Info("Successfully loaded tracked entities from storage",
@@ -73,7 +92,9 @@ func (p *processorImp) persistTrackedEntities() error {
 		zap.Int("count", entitiesCount),
 		zap.String("storage_type", fmt.Sprintf("%T", p.storage)))
 
-	if err := p.storage.Save(p.trackedEntities); err != nil {
+	err := p.storage.Save(p.trackedEntities)
+	p.recordPersistenceTelemetry(persistenceOperationSave, start, err)
+	if err != nil {
 		p.mu.RUnlock()
 		p.logger.Error("Failed to persist tracked entities",
 			zap.Error(err),
@@ -81,6 +102,22 @@ func (p *processorImp) persistTrackedEntities() error {
 			zap.Duration("attempt_duration", time.Since(start)))
 		return err
 	}
+	if p.config.DynamicThresholdMode == dynamicThresholdModeSeasonal {
+		if err := p.storage.SaveSeasonalProfiles(p.seasonalProfiles); err != nil {
+			p.mu.RUnlock()
+			p.logger.Error("Failed to persist seasonal profiles",
+				zap.Error(err),
+				zap.Int("profile_count", len(p.seasonalProfiles)))
+			return err
+		}
+	}
+	if err := p.storage.SaveProcessorState(p.snapshotProcessorState()); err != nil {
+		p.mu.RUnlock()
+		p.logger.Error("Failed to persist processor state",
+			zap.Error(err),
+			zap.Int("dynamic_threshold_count", len(p.dynamicCustomThresholds)))
+		return err
+	}
 	p.mu.RUnlock()
 
 	duration := time.Since(start)
@@ -91,7 +128,8 @@ func (p *processorImp) persistTrackedEntities() error {
 	return nil
 }
 
-// cleanupExpiredEntities removes entities that have exceeded their retention period
+// cleanupExpiredEntities removes entities that have exceeded their retention period.
+// Each entity expires after the retention of the rule it was last evaluated with.
 func (p *processorImp) cleanupExpiredEntities() {
 	if p.config.RetentionMinutes <= 0 {
 		return
@@ -100,13 +138,24 @@ func (p *processorImp) cleanupExpiredEntities() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	exp := time.Now().Add(-time.Duration(p.config.RetentionMinutes) * time.Minute)
+	now := time.Now()
 	removed := 0
 
 	for id, te := range p.trackedEntities {
-		if te.LastExceeded.Before(exp) {
+		cfg := p.configForEntity(te)
+		exp := now.Add(-time.Duration(cfg.RetentionMinutes) * time.Minute)
+		lastActive := te.LastExceeded
+		// An entity counting enter_breaches is kept while it reports
+		if te.ThresholdBreaches > 0 && te.LastSeen.After(lastActive) {
+			lastActive = te.LastSeen
+		}
+		if lastActive.Before(exp) {
 			delete(p.trackedEntities, id)
 			removed++
+			// Keep the baseline, so the entity's next spike is still detected
+			if cfg.EnableAnomalyDetection {
+				p.observeEntity(id, te.MetricHistory, now)
+			}
 		}
 	}
 
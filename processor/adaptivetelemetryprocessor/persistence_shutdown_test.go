@@ -40,6 +40,22 @@ func (m *mockStorage) Save(entities map[string]*trackedEntity) error {
 	return nil
 }
 
+func (*mockStorage) LoadSeasonalProfiles() (map[string]*seasonalProfile, error) {
+	return make(map[string]*seasonalProfile), nil
+}
+
+func (*mockStorage) SaveSeasonalProfiles(map[string]*seasonalProfile) error {
+	return nil
+}
+
+func (*mockStorage) LoadProcessorState() (*processorState, error) {
+	return nil, nil
+}
+
+func (*mockStorage) SaveProcessorState(*processorState) error {
+	return nil
+}
+
 func (m *mockStorage) Close() error {
 	m.closeCalled = true
 	return m.closeError
@@ -19,6 +19,9 @@ import (
 func (p *processorImp) processMetrics(ctx context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
 	start := time.Now()
 
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+
 	// Quick exit for empty metrics
 	if md.ResourceMetrics().Len() == 0 {
 		p.logger.Info("Received empty metrics batch, returning without processing")
@@ -52,6 +55,10 @@ type processingContext struct {
 	totalMetricCount int
 	metricTypeCount  map[string]int
 	stageHits        map[string]int // Track which stages triggered inclusions
+	keptCount        int            // Resources a stage included, forwarded or not
+	rollup           *excludedRollup
+	topN             *topNSelection
+	evaluated        []evaluatedResource // Resources whose decision is recorded for inspection
 }
 
 // initializeProcessingContext sets up the processing context and logs batch information
@@ -120,9 +127,18 @@ func (p *processorImp) handleContextCancellation(md pmetric.Metrics, processCtx
 	return md, processCtx.ctx.Err()
 }
 
-// updateDynamicThresholdsIfNeeded updates dynamic thresholds if interval has passed
+// updateDynamicThresholdsIfNeeded updates dynamic thresholds if interval has passed.
+// While the maintenance loop runs, the batch is only sampled for its next update.
 func (p *processorImp) updateDynamicThresholdsIfNeeded(md pmetric.Metrics) {
-	if !p.dynamicThresholdsEnabled || time.Since(p.lastThresholdUpdate).Seconds() < dynamicUpdateIntervalSecs {
+	// A volume budget adjusts the thresholds from the output instead
+	if !p.dynamicThresholdsEnabled || p.volume != nil {
+		return
+	}
+	if p.maintenanceRunning.Load() {
+		p.recordThresholdSamples(md)
+		return
+	}
+	if time.Since(p.lastThresholdUpdate).Seconds() < dynamicUpdateIntervalSecs {
 		return
 	}
 
@@ -137,6 +153,8 @@ func (p *processorImp) processAllResources(processCtx *processingContext, md pme
 	filtered := pmetric.NewMetrics()
 	rms := md.ResourceMetrics()
 	includedCount := 0
+	processCtx.rollup = p.newExcludedRollup()
+	processCtx.topN = p.newTopNSelection()
 
 	// Process all resources with a time limit per resource
 	for i := 0; i < rms.Len(); i++ {
@@ -154,29 +172,40 @@ func (p *processorImp) processAllResources(processCtx *processingContext, md pme
 					}
 				}
 
+				processCtx.keptCount = processCtx.resourceCount
 				return md, 0 // Return original metrics on timeout
 			}
 		}
 
 		rm := rms.At(i)
-		if p.processingSingleResource(rm, &filtered, processCtx.stageHits) {
+		if p.processingSingleResource(rm, &filtered, processCtx) {
 			includedCount++
 		}
 	}
+	includedCount += p.finishTopNSelection(&filtered, processCtx)
+	p.appendExcludedRollup(processCtx.rollup, filtered)
+	if p.decisions != nil {
+		p.recordDecisions(processCtx.evaluated, time.Now())
+	}
 
 	p.logger.Debug("Resource filtering completed",
 		zap.Int("included_count", includedCount),
 		zap.Int("total_resources", processCtx.resourceCount))
 
+	processCtx.keptCount = includedCount
 	return filtered, includedCount
 }
 
-// processingSingleResource processes a single resource and returns whether it was included
-func (p *processorImp) processingSingleResource(rm pmetric.ResourceMetrics, filtered *pmetric.Metrics, stageHits map[string]int) bool {
+// processingSingleResource processes a single resource and returns whether it was included.
+// Excluded processes wait for the top N selection of the batch, when top_n is configured.
+func (p *processorImp) processingSingleResource(rm pmetric.ResourceMetrics, filtered *pmetric.Metrics, processCtx *processingContext) bool {
 	resourceID := buildResourceIdentity(rm.Resource())
 
 	// Evaluate resource through all filter stages - no artificial timeout
-	includeResource := p.shouldIncludeResource(rm.Resource(), rm)
+	includeResource, values := p.evaluateResource(rm.Resource(), rm)
+	if p.decisions != nil {
+		processCtx.evaluated = append(processCtx.evaluated, evaluatedResource{rm: rm, id: resourceID, values: values})
+	}
 
 	// Get the filter stage from the resource attributes that was set by shouldIncludeResource
 	includeReason := ""
@@ -186,13 +215,42 @@ func (p *processorImp) processingSingleResource(rm pmetric.ResourceMetrics, filt
 
 	if includeResource {
 		p.handleIncludedResource(rm, resourceID, includeReason, filtered)
+		p.filterIncludedMetrics(filtered.ResourceMetrics().At(filtered.ResourceMetrics().Len()-1), resourceID, includeReason, time.Now())
 		// Track which stage allowed this resource through
-		if stageHits != nil {
-			stageHits[includeReason]++
+		processCtx.stageHits[includeReason]++
+		if includeReason != stageDefaultInclusion {
+			p.recordForwarded(resourceID, time.Now())
 		}
+		processCtx.topN.add(rm, resourceID, values, true)
 		return true
 	}
-	p.handleExcludedResource(rm, resourceID)
+
+	// Explicitly excluded resources get no heartbeat, top N or rollup
+	if includeReason == stageExcludeList || includeReason == stageDropCondition {
+		p.handleExcludedResource(rm, resourceID, includeReason, filtered, nil)
+		return false
+	}
+
+	// Whether an excluded process is among the top N of its host is known once the batch is evaluated
+	if processCtx.topN.add(rm, resourceID, values, false) {
+		return false
+	}
+	return p.handleNotIncludedResource(rm, resourceID, filtered, processCtx)
+}
+
+// handleNotIncludedResource forwards a resource no stage included as a heartbeat when one is due,
+// and excludes it otherwise. Returns whether the resource was forwarded.
+func (p *processorImp) handleNotIncludedResource(rm pmetric.ResourceMetrics, resourceID string, filtered *pmetric.Metrics, processCtx *processingContext) bool {
+	// An excluded entity is still forwarded once per heartbeat interval, so it is known to be alive.
+	// An entity reporting none of the heartbeat metrics has nothing to forward and gets no heartbeat.
+	if hasAnyMetric(rm, p.config.HeartbeatMetrics) && p.heartbeatDue(resourceID, time.Now()) {
+		setResourceFilterStage(rm.Resource(), stageHeartbeat)
+		p.handleIncludedResource(rm, resourceID, stageHeartbeat, filtered)
+		keepMetrics(filtered.ResourceMetrics().At(filtered.ResourceMetrics().Len()-1), p.config.HeartbeatMetrics)
+		processCtx.stageHits[stageHeartbeat]++
+		return true
+	}
+	p.handleExcludedResource(rm, resourceID, stageFiltered, filtered, processCtx.rollup)
 	return false
 }
 
@@ -215,10 +273,14 @@ func (p *processorImp) handleIncludedResource(rm pmetric.ResourceMetrics, resour
 	dest := filtered.ResourceMetrics().AppendEmpty()
 	rm.CopyTo(dest)
 
-	// Add process.atp.enabled=true for resources processed by ATP
+	// Add process.atp.enabled=true and the stage for resources processed by ATP
 	// Exclude stageDefaultInclusion as those weren't evaluated by ATP
 	if includeReason != stageDefaultInclusion && includeReason != "" {
-		dest.Resource().Attributes().PutBool("process.atp.enabled", true)
+		dest.Resource().Attributes().PutBool(atpEnabledAttributeKey, true)
+		atpDetailsMap(dest.Resource(), "stage", p.logger).PutStr("stage", includeReason)
+	}
+	if p.config.Mode == modeShadow {
+		p.annotateShadowDecision(dest.Resource(), shadowDecisionKeep, includeReason)
 	}
 
 	// Remove the internal filter stage attribute from the output, unless debugging is enabled
@@ -227,31 +289,64 @@ func (p *processorImp) handleIncludedResource(rm pmetric.ResourceMetrics, resour
 	}
 }
 
-// handleExcludedResource processes a resource that should be excluded from output
-func (p *processorImp) handleExcludedResource(rm pmetric.ResourceMetrics, resourceID string) {
+// handleExcludedResource processes a resource that should be excluded from output.
+// In shadow mode the resource is forwarded anyway, annotated with the drop decision.
+// A process merged into the _other resource of its host is not forwarded on its own.
+func (p *processorImp) handleExcludedResource(rm pmetric.ResourceMetrics, resourceID, stage string, filtered *pmetric.Metrics, rollup *excludedRollup) {
 	resourceType := getResourceType(rm.Resource().Attributes())
+	aggregated := rollup.add(rm)
 	p.logger.Info("Excluding resource from output",
 		zap.String("resource_id", resourceID),
 		zap.String("resource_type", resourceType),
+		zap.Bool("shadow", p.config.Mode == modeShadow),
+		zap.Bool("aggregated", aggregated),
 		zap.Int("metric_count", countMetricsInResource(rm)))
+
+	if p.config.Mode != modeShadow {
+		return
+	}
+	dest := filtered.ResourceMetrics().AppendEmpty()
+	rm.CopyTo(dest)
+	dest.Resource().Attributes().PutBool(atpEnabledAttributeKey, true)
+	p.annotateShadowDecision(dest.Resource(), shadowDecisionDrop, stage)
+	if !p.config.DebugShowAllFilterStages {
+		dest.Resource().Attributes().Remove(internalFilterStageAttributeKey)
+	}
+}
+
+// annotateShadowDecision records the decision and stage ATP would have applied to a resource in shadow mode
+func (p *processorImp) annotateShadowDecision(resource pcommon.Resource, decision, stage string) {
+	atp := atpDetailsMap(resource, "decision", p.logger)
+	atp.PutStr("decision", decision)
+	atp.PutStr("stage", stage)
 }
 
 // performPostProcessingTasks handles cleanup and final logging
 func (p *processorImp) performPostProcessingTasks(processCtx *processingContext, filtered pmetric.Metrics, start time.Time) {
-	// Perform cleanup of expired entities with controlled frequency
+	// Without the maintenance loop (processor not started), perform cleanup with controlled frequency
 	// Cleanup is synchronous to avoid race conditions in tests and has minimal performance impact
 	// since it runs infrequently (1% chance) and is fast (just iterating and deleting map entries)
-	if processCtx.resourceCount > 0 && p.config.RetentionMinutes > 0 && rand.Float64() < 0.01 {
-		p.cleanupExpiredEntities()
+	if processCtx.resourceCount > 0 && !p.maintenanceRunning.Load() && rand.Float64() < 0.01 {
+		p.cleanupState(time.Now())
 	}
+	// The budget is enforced on every batch, so churn between cleanups stays bounded
+	p.enforceTrackedEntityBudget(processCtx.ctx)
 
 	processingTime := time.Since(start)
 	outputResourceCount := filtered.ResourceMetrics().Len()
+	p.recordBatchTelemetry(processCtx.ctx, processCtx.resourceCount, outputResourceCount, processCtx.keptCount, processCtx.stageHits)
 	outputMetricCount := countOutputMetrics(filtered)
 
 	// Generate summary metrics for customer visibility into filtering effectiveness
-	p.generateFilteringSummaryMetrics(&filtered, processCtx.resourceCount, outputResourceCount,
+	// In shadow mode every resource is forwarded, so the summary reports the kept ones
+	p.generateFilteringSummaryMetrics(&filtered, processCtx.resourceCount, processCtx.keptCount,
 		processCtx.totalMetricCount, outputMetricCount, processCtx.stageHits)
+	p.observeOutputVolume(processCtx, filtered, time.Now())
+
+	// Write the process.atp details in the configured output mode, once per exported resource
+	for i := 0; i < filtered.ResourceMetrics().Len(); i++ {
+		finalizeATPAttributes(filtered.ResourceMetrics().At(i).Resource(), p.config.AttributesMode, p.logger)
+	}
 
 	p.logger.Info("Metrics processing completed",
 		zap.Int("input_resources", processCtx.resourceCount),
@@ -283,25 +378,49 @@ func countOutputMetrics(filtered pmetric.Metrics) int {
 
 // shouldIncludeResource determines if a resource should be included in the filtered output
 func (p *processorImp) shouldIncludeResource(resource pcommon.Resource, rm pmetric.ResourceMetrics) bool {
+	include, _ := p.evaluateResource(resource, rm)
+	return include
+}
+
+// evaluateResource runs the filter stages on a resource. Returns whether a stage includes it, and
+// the metric values it was evaluated with.
+func (p *processorImp) evaluateResource(resource pcommon.Resource, rm pmetric.ResourceMetrics) (bool, map[string]float64) {
 	// Get resource identity and basic info
 	id := buildResourceIdentity(resource)
+
+	// Exclude selectors take priority over every stage
+	if p.isExplicitlyExcluded(resource.Attributes()) {
+		setResourceFilterStage(resource, stageExcludeList)
+		p.logger.Debug("Resource excluded: matches an exclude selector", zap.String("resource_id", id))
+		return false, nil
+	}
+	if p.applyDropConditions(rm) {
+		setResourceFilterStage(resource, stageDropCondition)
+		p.logger.Debug("Resource excluded: a drop condition holds", zap.String("resource_id", id))
+		return false, nil
+	}
+
 	resourceType := getResourceType(resource.Attributes())
-	values := p.extractMetricValues(rm)
+	cfg, rule := p.configFor(resource)
+	values := p.extractMetricValues(rm, cfg)
+	pendingRates := p.convertCumulativeSums(id, rm, values, cfg)
 
 	// Log basic resource info
 	p.logger.Debug("Evaluating resource",
 		zap.String("resource_id", id),
 		zap.String("resource_type", resourceType),
+		zap.String("rule", rule),
 		zap.Int("metric_count", len(values)))
 
 	// This tracks only the MetricThresholds values, not other config data
-	p.captureUsedMetricThresholds(resource, values)
+	p.captureUsedMetricThresholds(resource, id, values, cfg)
 
 	// This ensures we default to INCLUSION for non-targeted resources (e.g., system metrics, unconfigured processes)
-	if !p.isResourceTargeted(values) {
+	// A cumulative sum waiting for its second point still targets the resource
+	if !p.isResourceTargeted(values, cfg) && !pendingRates {
 		setResourceFilterStage(resource, stageDefaultInclusion)
 		p.logger.Debug("Resource included: no specified metrics found (default inclusion)", zap.String("resource_id", id))
-		return true
+		return true, values
 	}
 
 	// Check if this is a zombie process - always include if so
@@ -313,31 +432,34 @@ func (p *processorImp) shouldIncludeResource(resource pcommon.Resource, rm pmetr
 		// Track the entity even if it's a zombie process for statistics
 		p.mu.Lock()
 		defer p.mu.Unlock()
-		p.upsertTrackedEntityForIncludeList(id, values, resource)
-		return true
+		p.upsertTrackedEntityForIncludeList(id, values, resource, rule)
+		return true, values
 	}
 
-	// Check include list FIRST - bypass all filters if in include list
-	if len(p.config.IncludeProcessList) > 0 {
-		processName := extractProcessName(resource.Attributes())
-		match := isProcessInIncludeList(resource.Attributes(), p.config.IncludeProcessList)
-		p.logger.Info("Checking include list",
-			zap.String("process_name", processName),
-			zap.Strings("include_list", p.config.IncludeProcessList),
-			zap.Bool("match", match))
+	// Check include lists FIRST - bypass all filters if in include_process_list or an include selector
+	if p.isExplicitlyIncluded(resource.Attributes()) {
+		setResourceFilterStage(resource, stageIncludeList)
+		p.logger.Info("Resource included: in include list (bypass filters)",
+			zap.String("resource_id", id),
+			zap.String("process_name", extractProcessName(resource.Attributes())))
 
-		if match {
-			setResourceFilterStage(resource, stageIncludeList)
-			p.logger.Info("Resource included: in include list (bypass filters)",
-				zap.String("resource_id", id),
-				zap.String("process_name", processName))
+		// Track the entity even if it's in the include list for statistics
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.upsertTrackedEntityForIncludeList(id, values, resource, rule)
+		return true, values
+	}
 
-			// Track the entity even if it's in the include list for statistics
-			p.mu.Lock()
-			defer p.mu.Unlock()
-			p.upsertTrackedEntityForIncludeList(id, values, resource)
-			return true
-		}
+	// Keep conditions come before the stages that evaluate metric values. A resource kept for some
+	// of its metrics is forwarded with only those.
+	if p.applyKeepConditions(rm) {
+		setResourceFilterStage(resource, stageKeepCondition)
+		p.logger.Debug("Resource included: a keep condition holds", zap.String("resource_id", id))
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.upsertTrackedEntityForIncludeList(id, values, resource, rule)
+		return true, values
 	}
 
 	// Take write lock for entity tracking
@@ -347,50 +469,80 @@ func (p *processorImp) shouldIncludeResource(resource pcommon.Resource, rm pmetr
 	// Check if this is a known entity
 	trackedEntity, exists := p.trackedEntities[id]
 
+	var include bool
 	if exists {
-		return p.evaluateExistingEntity(resource, id, trackedEntity, values)
+		include = p.evaluateExistingEntity(resource, id, trackedEntity, values, cfg, rule)
+	} else {
+		include = p.evaluateNewEntity(resource, id, values, cfg, rule)
 	}
-	return p.evaluateNewEntity(resource, id, values)
+
+	// Learn after evaluation, for included and excluded resources alike
+	p.recordSeasonalSamples(id, values, time.Now(), cfg)
+	return include, values
 }
 
 // evaluateExistingEntity evaluates filter stages for an existing tracked entity
-func (p *processorImp) evaluateExistingEntity(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64) bool {
+// using cfg, the effective configuration of the matched rule.
+func (p *processorImp) evaluateExistingEntity(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64, cfg *Config, rule string) bool {
 	// Update current and max values
 	updateEntityValues(trackedEntity, values)
+	trackedEntity.Rule = rule
 
 	// Always add multi-metric data to process.atp if multi-metric is enabled
 	// This must happen before checking filter stages to ensure data is always present
 	if p.multiMetricEnabled {
-		p.addMultiMetricData(resource, values)
+		p.addMultiMetricData(resource, values, cfg)
 	}
 
 	// Check filter stages in order
-	return p.checkAnomalyDetectionStage(resource, id, trackedEntity, values) ||
-		p.checkThresholdStages(resource, id, trackedEntity, values) ||
-		p.checkMultiMetricStage(resource, id, trackedEntity, values) ||
-		p.checkRetentionStages(resource, id, trackedEntity)
+	include := p.checkAnomalyDetectionStage(resource, id, trackedEntity, values, cfg) ||
+		p.checkThresholdStages(resource, id, trackedEntity, values, cfg) ||
+		p.checkMultiMetricStage(resource, id, trackedEntity, values, cfg) ||
+		p.checkRetentionStages(resource, id, trackedEntity, cfg)
+	if include {
+		p.recordTriggerMetrics(resource, trackedEntity, values, cfg)
+	}
+	return include
 }
 
 // evaluateNewEntity evaluates filter stages for a new entity
-func (p *processorImp) evaluateNewEntity(resource pcommon.Resource, id string, values map[string]float64) bool {
+// using cfg, the effective configuration of the matched rule.
+func (p *processorImp) evaluateNewEntity(resource pcommon.Resource, id string, values map[string]float64, cfg *Config, rule string) bool {
 	// Create new tracked entity
-	newEntity := p.createNewTrackedEntity(id, values, resource)
+	newEntity := p.createNewTrackedEntity(id, values, resource, cfg)
+	newEntity.Rule = rule
+
+	// Continue the history kept while the entity was observed, so its first spike has a baseline
+	if cfg.EnableAnomalyDetection {
+		for m, history := range p.takeObservedHistory(id) {
+			newEntity.MetricHistory[m] = history
+		}
+	}
 
 	// Check filter stages for new entity
-	include, stage := p.checkNewEntityFilterStages(resource, id, newEntity, values)
+	include, stage := p.checkNewEntityFilterStages(resource, id, newEntity, values, cfg)
+
+	// An entity counting enter_breaches is tracked, so the count continues with its next evaluation
+	pending := !include && newEntity.ThresholdBreaches > 0
+
+	// Untracked entities stay observed, with the history the anomaly check just extended
+	if !include && !pending && !p.config.DebugShowAllFilterStages && cfg.EnableAnomalyDetection {
+		p.observeEntity(id, newEntity.MetricHistory, time.Now())
+	}
 
-	// Store entity if it should be included or if debug mode is enabled
-	if include || p.config.DebugShowAllFilterStages {
+	// Store entity if it should be included, is pending or if debug mode is enabled
+	if include || pending || p.config.DebugShowAllFilterStages {
 		p.trackedEntities[id] = newEntity
 
 		if include {
 			setResourceFilterStage(resource, stage)
+			p.recordTriggerMetrics(resource, newEntity, values, cfg)
 			p.logger.Info("Resource included: new resource",
 				zap.String("resource_id", id),
 				zap.String("filter_stage", stage))
 			return true
 		} else if p.config.DebugShowAllFilterStages {
-			return p.handleDebugMode(resource, id, values)
+			return p.handleDebugMode(resource, id, values, cfg)
 		}
 	}
 
@@ -400,26 +552,26 @@ func (p *processorImp) evaluateNewEntity(resource pcommon.Resource, id string, v
 
 // checkNewEntityFilterStages checks all filter stages for a new entity
 // Order matches requirement.md: Anomaly → Threshold → Multi-Metric
-func (p *processorImp) checkNewEntityFilterStages(resource pcommon.Resource, id string, newEntity *trackedEntity, values map[string]float64) (bool, string) {
+func (p *processorImp) checkNewEntityFilterStages(resource pcommon.Resource, id string, newEntity *trackedEntity, values map[string]float64, cfg *Config) (bool, string) {
 	// Always add multi-metric data to process.atp if multi-metric is enabled
 	// This must happen before checking filter stages to ensure data is always present
 	if p.multiMetricEnabled {
-		p.addMultiMetricData(resource, values)
+		p.addMultiMetricData(resource, values, cfg)
 	}
 
 	// Stage 1: Check anomaly detection first (highest priority - detects sudden changes)
-	if include, stage := p.checkNewEntityAnomaly(id, newEntity, values); include {
+	if include, stage := p.checkNewEntityAnomaly(resource, id, newEntity, values, cfg); include {
 		return true, stage
 	}
 
 	// Stage 2: Check threshold stages (dynamic or static - absolute limits)
-	if include, stage := p.checkNewEntityThresholds(id, values); include {
+	if include, stage := p.checkNewEntityThresholds(id, newEntity, values, cfg); include {
 		newEntity.LastExceeded = time.Now() // Update timestamp for retention period tracking
 		return true, stage
 	}
 
 	// Stage 3: Check multi-metric stage (composite scoring - combined stress)
-	if include, stage := p.checkNewEntityMultiMetric(resource, id, values); include {
+	if include, stage := p.checkNewEntityMultiMetric(resource, id, values, cfg); include {
 		newEntity.LastExceeded = time.Now() // Update timestamp for retention period tracking
 		return true, stage
 	}
@@ -442,35 +594,40 @@ func updateEntityValues(trackedEntity *trackedEntity, values map[string]float64)
 			trackedEntity.MaxValues[m] = v
 		}
 	}
+	trackedEntity.LastSeen = time.Now()
 }
 
 // upsertTrackedEntityForIncludeList ensures a tracked entity exists or updates it for include-list resources.
 // Sets LastExceeded so retention logic keeps the entity included.
-func (p *processorImp) upsertTrackedEntityForIncludeList(id string, values map[string]float64, resource pcommon.Resource) {
+func (p *processorImp) upsertTrackedEntityForIncludeList(id string, values map[string]float64, resource pcommon.Resource, rule string) {
 	now := time.Now()
 	if te, exists := p.trackedEntities[id]; !exists {
 		p.trackedEntities[id] = &trackedEntity{
 			Identity:      id,
 			FirstSeen:     now,
 			LastExceeded:  now,
+			LastSeen:      now,
 			CurrentValues: values,
 			MaxValues:     values,
 			Attributes:    snapshotResourceAttributes(resource),
+			Rule:          rule,
 		}
 	} else {
 		updateEntityValues(te, values)
 		te.LastExceeded = now
+		te.Rule = rule
 	}
 }
 
 // checkAnomalyDetectionStage checks for anomaly detection in existing entities
-func (p *processorImp) checkAnomalyDetectionStage(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64) bool {
-	if !p.config.EnableAnomalyDetection {
+func (p *processorImp) checkAnomalyDetectionStage(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64, cfg *Config) bool {
+	if !cfg.EnableAnomalyDetection {
 		return false
 	}
 
-	if isAnomaly, anomalyReason := p.detectAnomaly(trackedEntity, values); isAnomaly {
+	if isAnomaly, anomalyReason := p.detectAnomaly(trackedEntity, values, cfg); isAnomaly {
 		setResourceFilterStage(resource, stageAnomalyDetection)
+		putATPDetails(resource, "anomaly", map[string]any{"reason": anomalyReason}, p.logger)
 		p.logger.Info("Resource included: anomaly detected",
 			zap.String("resource_id", id),
 			zap.String("details", anomalyReason))
@@ -480,58 +637,80 @@ func (p *processorImp) checkAnomalyDetectionStage(resource pcommon.Resource, id
 }
 
 // checkThresholdStages checks dynamic and static threshold stages
-func (p *processorImp) checkThresholdStages(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64) bool {
-	if p.dynamicThresholdsEnabled {
-		return p.checkDynamicThresholds(resource, id, trackedEntity, values)
+func (p *processorImp) checkThresholdStages(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64, cfg *Config) bool {
+	if p.dynamicThresholdsApply(cfg) {
+		return p.checkDynamicThresholds(resource, id, trackedEntity, values, cfg)
 	}
-	return p.checkStaticThresholds(resource, id, trackedEntity, values)
+	return p.checkStaticThresholds(resource, id, trackedEntity, values, cfg)
 }
 
 // checkDynamicThresholds checks dynamic threshold stage for existing entities
-func (p *processorImp) checkDynamicThresholds(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64) bool {
-	for m, v := range values {
-		if threshold, ok := p.dynamicCustomThresholds[m]; ok && v >= threshold {
-			trackedEntity.LastExceeded = time.Now()
-			setResourceFilterStage(resource, stageDynamicThreshold)
-			p.logger.Info("Resource included: dynamic threshold",
-				zap.String("resource_id", id),
-				zap.String("metric", m),
-				zap.Float64("value", v),
-				zap.Float64("threshold", threshold))
-			return true
-		}
+func (p *processorImp) checkDynamicThresholds(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64, cfg *Config) bool {
+	exceeded, threshold, held := p.dynamicThresholdBreach(id, values, cfg)
+	if !trackedEntity.applyThresholdHysteresis(exceeded != "", held, cfg) {
+		return false
 	}
-	return false
+	// Quiet intervals keep the entity without extending its retention
+	if held {
+		trackedEntity.LastExceeded = time.Now()
+	}
+	setResourceFilterStage(resource, stageDynamicThreshold)
+	p.logger.Info("Resource included: dynamic threshold",
+		zap.String("resource_id", id),
+		zap.String("metric", exceeded),
+		zap.Float64("value", values[exceeded]),
+		zap.Float64("threshold", threshold),
+		zap.Bool("held_by_exit_threshold", exceeded == "" && held),
+		zap.Int("quiet_intervals", trackedEntity.QuietIntervals))
+	return true
 }
 
-// checkStaticThresholds checks static threshold stage for existing entities
-func (p *processorImp) checkStaticThresholds(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64) bool {
+// dynamicThresholdBreach returns the first metric at or above the entity's dynamic threshold, "" if none, that
+// threshold, and whether any metric is at or above the exit threshold derived from its dynamic threshold
+func (p *processorImp) dynamicThresholdBreach(id string, values map[string]float64, cfg *Config) (string, float64, bool) {
+	now := time.Now()
+	held := false
 	for m, v := range values {
-		threshold, ok := p.config.MetricThresholds[m]
-		// Strict check: metric must exist in config (guaranteed by extractMetricValues logic, but explicit check ensures safety)
+		threshold, _, ok := p.dynamicThresholdFor(id, m, now, cfg)
 		if !ok {
 			continue
 		}
-
-		if threshold == 0.0 || v >= threshold {
-			trackedEntity.LastExceeded = time.Now()
-			setResourceFilterStage(resource, stageStaticThreshold)
-			p.logger.Debug("Resource included: static threshold",
-				zap.String("resource_id", id),
-				zap.String("metric", m),
-				zap.Float64("value", v),
-				zap.Float64("threshold", threshold))
-			return true
+		if v >= cfg.exitThresholdFor(m, threshold) {
+			held = true
+		}
+		if v >= threshold {
+			return m, threshold, held
 		}
 	}
-	return false
+	return "", 0, held
+}
+
+// checkStaticThresholds checks static threshold stage for existing entities
+func (p *processorImp) checkStaticThresholds(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64, cfg *Config) bool {
+	exceeded, held := staticThresholdBreach(values, cfg)
+	if !trackedEntity.applyThresholdHysteresis(exceeded != "", held, cfg) {
+		return false
+	}
+	// Quiet intervals keep the entity without extending its retention
+	if held {
+		trackedEntity.LastExceeded = time.Now()
+	}
+	setResourceFilterStage(resource, stageStaticThreshold)
+	p.logger.Debug("Resource included: static threshold",
+		zap.String("resource_id", id),
+		zap.String("metric", exceeded),
+		zap.Float64("value", values[exceeded]),
+		zap.Float64("threshold", cfg.MetricThresholds[exceeded]),
+		zap.Bool("held_by_exit_threshold", exceeded == "" && held),
+		zap.Int("quiet_intervals", trackedEntity.QuietIntervals))
+	return true
 }
 
-// addMultiMetricData adds multi-metric data to process.atp attribute
+// addMultiMetricData adds multi-metric data to the process.atp details
 // This is called separately from the inclusion check to ensure data is always present
-func (p *processorImp) addMultiMetricData(resource pcommon.Resource, values map[string]float64) {
-	compScore, _ := p.calculateCompositeGeneric(values)
-	threshold := p.config.CompositeThreshold
+func (p *processorImp) addMultiMetricData(resource pcommon.Resource, values map[string]float64, cfg *Config) {
+	compScore, _ := p.calculateCompositeGeneric(values, cfg)
+	threshold := cfg.CompositeThreshold
 	if threshold <= 0 {
 		threshold = defaultCompositeThreshold
 	}
@@ -540,17 +719,17 @@ func (p *processorImp) addMultiMetricData(resource pcommon.Resource, values map[
 		"composite_score": compScore,
 		"threshold":       threshold,
 	}
-	updateProcessATPAttribute(resource, "multi_metric", multiMetricDetails, p.logger)
+	putATPDetails(resource, "multi_metric", multiMetricDetails, p.logger)
 }
 
 // checkMultiMetricStage checks multi-metric stage for existing entities
-func (p *processorImp) checkMultiMetricStage(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64) bool {
+func (p *processorImp) checkMultiMetricStage(resource pcommon.Resource, id string, trackedEntity *trackedEntity, values map[string]float64, cfg *Config) bool {
 	if !p.multiMetricEnabled {
 		return false
 	}
 
-	compScore, reason := p.calculateCompositeGeneric(values)
-	threshold := p.config.CompositeThreshold
+	compScore, reason := p.calculateCompositeGeneric(values, cfg)
+	threshold := cfg.CompositeThreshold
 	if threshold <= 0 {
 		threshold = defaultCompositeThreshold
 	}
@@ -571,65 +750,76 @@ func (p *processorImp) checkMultiMetricStage(resource pcommon.Resource, id strin
 }
 
 // checkRetentionStages checks anomaly and standard retention stages
-func (p *processorImp) checkRetentionStages(resource pcommon.Resource, id string, trackedEntity *trackedEntity) bool {
-	return p.checkAnomalyRetention(resource, id, trackedEntity) ||
-		p.checkStandardRetention(resource, id, trackedEntity)
+func (p *processorImp) checkRetentionStages(resource pcommon.Resource, id string, trackedEntity *trackedEntity, cfg *Config) bool {
+	return p.checkAnomalyRetention(resource, id, trackedEntity, cfg) ||
+		p.checkStandardRetention(resource, id, trackedEntity, cfg)
 }
 
 // checkAnomalyRetention checks anomaly retention stage
-func (p *processorImp) checkAnomalyRetention(resource pcommon.Resource, id string, trackedEntity *trackedEntity) bool {
-	if !p.config.EnableAnomalyDetection || trackedEntity.LastAnomalyDetected.IsZero() {
-		return false
-	}
-
-	anomalyRetentionMins := 30 // Default
-	if p.config.RetentionMinutes > 0 {
-		anomalyRetentionMins = int(p.config.RetentionMinutes)
-	}
-
-	if time.Since(trackedEntity.LastAnomalyDetected).Minutes() < float64(anomalyRetentionMins) {
+func (p *processorImp) checkAnomalyRetention(resource pcommon.Resource, id string, trackedEntity *trackedEntity, cfg *Config) bool {
+	if inAnomalyRetention(trackedEntity, cfg) {
 		setResourceFilterStage(resource, stageAnomalyRetention)
 		p.logger.Info("Resource included: anomaly retention",
 			zap.String("resource_id", id),
 			zap.Float64("minutes_since_anomaly", time.Since(trackedEntity.LastAnomalyDetected).Minutes()),
-			zap.Int("retention_minutes", anomalyRetentionMins))
+			zap.Int("retention_minutes", anomalyRetentionMinutes(cfg)))
 		return true
 	}
 	return false
 }
 
-// checkStandardRetention checks standard retention stage
-func (p *processorImp) checkStandardRetention(resource pcommon.Resource, id string, trackedEntity *trackedEntity) bool {
-	if p.config.RetentionMinutes <= 0 || trackedEntity.LastExceeded.IsZero() {
+// anomalyRetentionMinutes returns how long an entity is kept after its last anomaly
+func anomalyRetentionMinutes(cfg *Config) int {
+	if cfg.RetentionMinutes > 0 {
+		return int(cfg.RetentionMinutes)
+	}
+	return 30 // Default
+}
+
+// inAnomalyRetention reports whether the entity is still within its anomaly retention window
+func inAnomalyRetention(trackedEntity *trackedEntity, cfg *Config) bool {
+	if !cfg.EnableAnomalyDetection || trackedEntity.LastAnomalyDetected.IsZero() {
 		return false
 	}
+	return time.Since(trackedEntity.LastAnomalyDetected).Minutes() < float64(anomalyRetentionMinutes(cfg))
+}
 
-	retentionWindow := time.Duration(p.config.RetentionMinutes) * time.Minute
-	if time.Since(trackedEntity.LastExceeded) < retentionWindow {
+// checkStandardRetention checks standard retention stage
+func (p *processorImp) checkStandardRetention(resource pcommon.Resource, id string, trackedEntity *trackedEntity, cfg *Config) bool {
+	if inStandardRetention(trackedEntity, cfg) {
 		setResourceFilterStage(resource, stageStandardRetention)
 		p.logger.Info("Resource included: standard retention period",
 			zap.String("resource_id", id),
 			zap.Duration("time_since_exceeded", time.Since(trackedEntity.LastExceeded)),
-			zap.Int64("retention_minutes", p.config.RetentionMinutes))
+			zap.Int64("retention_minutes", cfg.RetentionMinutes))
 		return true
 	}
 	return false
 }
 
+// inStandardRetention reports whether the entity exceeded a threshold within the retention window
+func inStandardRetention(trackedEntity *trackedEntity, cfg *Config) bool {
+	if cfg.RetentionMinutes <= 0 || trackedEntity.LastExceeded.IsZero() {
+		return false
+	}
+	return time.Since(trackedEntity.LastExceeded) < time.Duration(cfg.RetentionMinutes)*time.Minute
+}
+
 // createNewTrackedEntity creates a new tracked entity
-func (p *processorImp) createNewTrackedEntity(id string, values map[string]float64, resource pcommon.Resource) *trackedEntity {
+func (p *processorImp) createNewTrackedEntity(id string, values map[string]float64, resource pcommon.Resource, cfg *Config) *trackedEntity {
 	now := time.Now()
 	newEntity := &trackedEntity{
 		Identity:      id,
 		FirstSeen:     now,
 		LastExceeded:  time.Time{}, // Zero value - only set when threshold is actually exceeded
+		LastSeen:      now,
 		CurrentValues: values,
 		MaxValues:     values,
 		Attributes:    snapshotResourceAttributes(resource),
 	}
 
 	// Initialize history if needed for anomaly detection
-	if p.config.EnableAnomalyDetection {
+	if cfg.EnableAnomalyDetection {
 		newEntity.MetricHistory = make(map[string][]float64)
 		for m, v := range values {
 			newEntity.MetricHistory[m] = []float64{v}
@@ -639,49 +829,41 @@ func (p *processorImp) createNewTrackedEntity(id string, values map[string]float
 	return newEntity
 }
 
-// checkNewEntityThresholds checks threshold stages for new entities
-func (p *processorImp) checkNewEntityThresholds(id string, values map[string]float64) (bool, string) {
-	if p.dynamicThresholdsEnabled {
-		for m, v := range values {
-			if threshold, ok := p.dynamicCustomThresholds[m]; ok && v >= threshold {
-				p.logger.Info("New resource exceeds dynamic threshold",
-					zap.String("resource_id", id),
-					zap.String("metric", m),
-					zap.Float64("value", v),
-					zap.Float64("threshold", threshold))
-				return true, stageDynamicThreshold
-			}
-		}
+// checkNewEntityThresholds checks threshold stages for new entities.
+// With enter_breaches above 1, the first breach only starts the count on newEntity.
+func (p *processorImp) checkNewEntityThresholds(id string, newEntity *trackedEntity, values map[string]float64, cfg *Config) (bool, string) {
+	stage, exceeded, threshold := stageStaticThreshold, "", 0.0
+	if p.dynamicThresholdsApply(cfg) {
+		stage = stageDynamicThreshold
+		exceeded, threshold, _ = p.dynamicThresholdBreach(id, values, cfg)
 	} else {
-		for m, v := range values {
-			threshold, ok := p.config.MetricThresholds[m]
-			// Strict check: metric must exist in config (guaranteed by extractMetricValues logic, but explicit check hurts nothing)
-			if !ok {
-				continue
-			}
+		exceeded, _ = staticThresholdBreach(values, cfg)
+		threshold = cfg.MetricThresholds[exceeded]
+	}
+	if !newEntity.applyThresholdHysteresis(exceeded != "", false, cfg) {
+		return false, ""
+	}
 
-			// If threshold is 0.0, it means "always include if metric present"
-			if threshold == 0.0 || v >= threshold {
-				p.logger.Info("New resource exceeds static threshold",
-					zap.String("resource_id", id),
-					zap.String("metric", m),
-					zap.Float64("value", v),
-					zap.Float64("threshold", threshold))
-				return true, stageStaticThreshold
-			}
-		}
+	message := "New resource exceeds static threshold"
+	if stage == stageDynamicThreshold {
+		message = "New resource exceeds dynamic threshold"
 	}
-	return false, ""
+	p.logger.Info(message,
+		zap.String("resource_id", id),
+		zap.String("metric", exceeded),
+		zap.Float64("value", values[exceeded]),
+		zap.Float64("threshold", threshold))
+	return true, stage
 }
 
 // checkNewEntityMultiMetric checks multi-metric stage for new entities
-func (p *processorImp) checkNewEntityMultiMetric(_ pcommon.Resource, id string, values map[string]float64) (bool, string) {
+func (p *processorImp) checkNewEntityMultiMetric(_ pcommon.Resource, id string, values map[string]float64, cfg *Config) (bool, string) {
 	if !p.multiMetricEnabled {
 		return false, ""
 	}
 
-	compScore, reason := p.calculateCompositeGeneric(values)
-	threshold := p.config.CompositeThreshold
+	compScore, reason := p.calculateCompositeGeneric(values, cfg)
+	threshold := cfg.CompositeThreshold
 	if threshold <= 0 {
 		threshold = defaultCompositeThreshold
 	}
@@ -699,12 +881,13 @@ func (p *processorImp) checkNewEntityMultiMetric(_ pcommon.Resource, id string,
 }
 
 // checkNewEntityAnomaly checks anomaly detection stage for new entities
-func (p *processorImp) checkNewEntityAnomaly(id string, newEntity *trackedEntity, values map[string]float64) (bool, string) {
-	if !p.config.EnableAnomalyDetection {
+func (p *processorImp) checkNewEntityAnomaly(resource pcommon.Resource, id string, newEntity *trackedEntity, values map[string]float64, cfg *Config) (bool, string) {
+	if !cfg.EnableAnomalyDetection {
 		return false, ""
 	}
 
-	if isAnomaly, anomalyReason := p.detectAnomaly(newEntity, values); isAnomaly {
+	if isAnomaly, anomalyReason := p.detectAnomaly(newEntity, values, cfg); isAnomaly {
+		putATPDetails(resource, "anomaly", map[string]any{"reason": anomalyReason}, p.logger)
 		p.logger.Info("New resource shows anomaly",
 			zap.String("resource_id", id),
 			zap.String("reason", anomalyReason))
@@ -714,15 +897,15 @@ func (p *processorImp) checkNewEntityAnomaly(id string, newEntity *trackedEntity
 }
 
 // handleDebugMode handles debug mode for resources that don't match any filter
-func (p *processorImp) handleDebugMode(resource pcommon.Resource, id string, values map[string]float64) bool {
+func (p *processorImp) handleDebugMode(resource pcommon.Resource, id string, values map[string]float64, cfg *Config) bool {
 	// Build detailed debug reason showing why resource didn't match
 	debugDetails := make([]string, 0, 4)
 
 	// Check static/dynamic threshold stage
-	if p.dynamicThresholdsEnabled {
+	if p.dynamicThresholdsApply(cfg) {
 		maxThresholdRatio := 0.0
 		for m, v := range values {
-			if threshold, ok := p.dynamicCustomThresholds[m]; ok && threshold > 0 {
+			if threshold, _, ok := p.dynamicThresholdFor(id, m, time.Now(), cfg); ok && threshold > 0 {
 				ratio := v / threshold
 				if ratio > maxThresholdRatio {
 					maxThresholdRatio = ratio
@@ -733,7 +916,7 @@ func (p *processorImp) handleDebugMode(resource pcommon.Resource, id string, val
 	} else {
 		maxThresholdRatio := 0.0
 		for m, v := range values {
-			if threshold := p.config.MetricThresholds[m]; threshold > 0 {
+			if threshold := cfg.MetricThresholds[m]; threshold > 0 {
 				ratio := v / threshold
 				if ratio > maxThresholdRatio {
 					maxThresholdRatio = ratio
@@ -745,8 +928,8 @@ func (p *processorImp) handleDebugMode(resource pcommon.Resource, id string, val
 
 	// Check multi-metric stage
 	if p.multiMetricEnabled {
-		score, _ := p.calculateCompositeGeneric(values)
-		threshold := p.config.CompositeThreshold
+		score, _ := p.calculateCompositeGeneric(values, cfg)
+		threshold := cfg.CompositeThreshold
 		if threshold <= 0 {
 			threshold = defaultCompositeThreshold
 		}
@@ -754,7 +937,7 @@ func (p *processorImp) handleDebugMode(resource pcommon.Resource, id string, val
 	}
 
 	// Check anomaly detection
-	if p.config.EnableAnomalyDetection {
+	if cfg.EnableAnomalyDetection {
 		debugDetails = append(debugDetails, "anomaly=none")
 	}
 
@@ -794,7 +977,16 @@ func (p *processorImp) generateFilteringSummaryMetrics(filtered *pmetric.Metrics
 	filteredResourceCount := inputResourceCount - outputResourceCount
 	efficiencyRatio := float64(filteredResourceCount) / float64(inputResourceCount)
 
-	// Consolidate summary stats into a JSON object
+	if p.config.AttributesMode == attributesModeNone {
+		return // Summary would be stripped before export
+	}
+
+	stageHitsDetails := make(map[string]any, len(stageHits))
+	for stage, hits := range stageHits {
+		stageHitsDetails[stage] = hits
+	}
+
+	// Consolidate summary stats into the process.atp details
 	summaryDetails := map[string]any{
 		"source":                   "adaptive_telemetry_processor",
 		"metric_type":              "filter_summary",
@@ -802,21 +994,22 @@ func (p *processorImp) generateFilteringSummaryMetrics(filtered *pmetric.Metrics
 		"total_resource_count":     inputResourceCount,
 		"resources_filtered_count": filteredResourceCount,
 		"resources_included_count": outputResourceCount,
-		"stage_hits":               stageHits,
+		"stage_hits":               stageHitsDetails,
 		"evaluation_timestamp":     time.Now().Unix(),
 	}
 
-	// Add filtering summary only to targeted resources' process.atp attribute
+	// Add filtering summary only to targeted resources' process.atp details
 	// This matches the pattern used by multi_metric and threshold_details
 	for i := 0; i < filtered.ResourceMetrics().Len(); i++ {
 		rm := filtered.ResourceMetrics().At(i)
 
 		// Extract metric values to check if this resource is targeted
-		values := p.extractMetricValues(rm)
+		cfg, _ := p.configFor(rm.Resource())
+		values := p.extractMetricValues(rm, cfg)
 
 		// Only add filtering summary to resources that are targeted by the processor
-		if p.isResourceTargeted(values) {
-			updateProcessATPAttribute(rm.Resource(), "filtering_summary", summaryDetails, p.logger)
+		if p.isResourceTargeted(values, cfg) {
+			putATPDetails(rm.Resource(), "filtering_summary", summaryDetails, p.logger)
 		}
 	}
 
@@ -828,18 +1021,18 @@ func (p *processorImp) generateFilteringSummaryMetrics(filtered *pmetric.Metrics
 }
 
 // isResourceTargeted checks if any metric in the values map is present in the configuration
-func (p *processorImp) isResourceTargeted(values map[string]float64) bool {
+func (p *processorImp) isResourceTargeted(values map[string]float64, cfg *Config) bool {
 	// Check static thresholds
-	if p.config.MetricThresholds != nil {
+	if cfg.MetricThresholds != nil {
 		for m := range values {
-			if _, ok := p.config.MetricThresholds[m]; ok {
+			if _, ok := cfg.MetricThresholds[m]; ok {
 				return true
 			}
 		}
 	}
 
 	// Check dynamic thresholds
-	if p.dynamicThresholdsEnabled && p.dynamicCustomThresholds != nil {
+	if p.dynamicThresholdsApply(cfg) && p.dynamicCustomThresholds != nil {
 		for m := range values {
 			if _, ok := p.dynamicCustomThresholds[m]; ok {
 				return true
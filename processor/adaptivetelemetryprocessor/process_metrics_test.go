@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.uber.org/zap/zaptest"
@@ -328,3 +329,70 @@ func createExtendedTestMetrics(resourceAttrs map[string]string, metricValues map
 
 	return metrics
 }
+
+func TestProcessMetricsAttributesMode(t *testing.T) {
+	testCases := []struct {
+		mode  string
+		check func(t *testing.T, attrs pcommon.Map)
+	}{
+		{
+			mode: attributesModeJSON,
+			check: func(t *testing.T, attrs pcommon.Map) {
+				val, ok := attrs.Get("process.atp")
+				require.True(t, ok)
+				assert.Equal(t, pcommon.ValueTypeStr, val.Type())
+				assert.Contains(t, val.Str(), `"stage":"static_threshold"`)
+			},
+		},
+		{
+			mode: attributesModeMap,
+			check: func(t *testing.T, attrs pcommon.Map) {
+				val, ok := attrs.Get("process.atp")
+				require.True(t, ok)
+				require.Equal(t, pcommon.ValueTypeMap, val.Type())
+				stage, _ := val.Map().Get("stage")
+				assert.Equal(t, stageStaticThreshold, stage.Str())
+				_, hasSummary := val.Map().Get("filtering_summary")
+				assert.True(t, hasSummary)
+			},
+		},
+		{
+			mode: attributesModeFlat,
+			check: func(t *testing.T, attrs pcommon.Map) {
+				_, ok := attrs.Get("process.atp")
+				assert.False(t, ok)
+				stage, _ := attrs.Get("atp.stage")
+				assert.Equal(t, stageStaticThreshold, stage.Str())
+				threshold, _ := attrs.Get("atp.threshold_details.system.cpu.utilization.threshold")
+				assert.Equal(t, 80.0, threshold.Double())
+			},
+		},
+		{
+			mode: attributesModeNone,
+			check: func(t *testing.T, attrs pcommon.Map) {
+				assert.Equal(t, map[string]any{"service.name": "atp-service"}, attrs.AsRaw())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			p := &processorImp{
+				logger: zaptest.NewLogger(t),
+				config: &Config{
+					MetricThresholds: map[string]float64{"system.cpu.utilization": 80.0},
+					AttributesMode:   tc.mode,
+				},
+				trackedEntities: make(map[string]*trackedEntity),
+			}
+
+			result, err := p.processMetrics(t.Context(), createExtendedTestMetrics(
+				map[string]string{"service.name": "atp-service"},
+				map[string]float64{"system.cpu.utilization": 90.0},
+			))
+			require.NoError(t, err)
+			require.Equal(t, 1, result.ResourceMetrics().Len())
+			tc.check(t, result.ResourceMetrics().At(0).Resource().Attributes())
+		})
+	}
+}
@@ -4,32 +4,82 @@
 package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
 
 import (
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/component"
 	"go.uber.org/zap"
+
+	"github.com/newrelic/nrdot-collector-components/internal/coreinternal/timeutils"
+	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/metadata"
 )
 
 // processorImp is the main implementation of the adaptive telemetry processor.
 // This file now only contains the core structure definition. All implementation
 // details have been moved to specialized files.
 type processorImp struct {
-	logger       *zap.Logger
-	config       *Config
-	nextConsumer consumer.Metrics
+	logger *zap.Logger
+	config *Config
 
 	trackedEntities    map[string]*trackedEntity
-	mu                 sync.RWMutex // protects trackedEntities & dynamicCustomThresholds
+	mu                 sync.RWMutex // protects trackedEntities, dynamicCustomThresholds & dynamicThresholdStats
 	storage            EntityStateStorage
 	lastPersistenceOp  time.Time
 	persistenceEnabled bool
 
+	componentID component.ID // ID used to request a client from the storage extension
+	startOnce   sync.Once    // Start runs once even when shared by several pipelines
+
 	lastThresholdUpdate      time.Time // Separate: tracks when dynamic thresholds were last updated
 	dynamicThresholdsEnabled bool
 	multiMetricEnabled       bool
 
+	// Rules merged over config, in evaluation order (first match wins)
+	rules []*resolvedRule
+
+	// configMu guards the settings thresholds_file reloads and rules. Batches, signal gating and
+	// maintenance tasks hold it for reading; a reload holds it before p.mu.
+	configMu sync.RWMutex
+	// Reloadable settings of the collector configuration, which thresholds_file overrides
+	baseThresholds reloadableThresholds
+	// Last content read from thresholds_file
+	thresholdsFileContent []byte
+
+	// Last decision of each evaluated resource, guarded by mu. Only kept when inspection_endpoint is set.
+	decisions *decisionLog
+	// Server of inspection_endpoint, from Start to Shutdown
+	inspection *http.Server
+
 	// Dynamic thresholds for metrics (including cpu/memory if configured)
 	dynamicCustomThresholds map[string]float64
+	// How each dynamic threshold was learned, persisted with it
+	dynamicThresholdStats map[string]*dynamicThresholdStats
+	// Seasonal profiles by entity identity, kept independently of trackedEntities retention
+	seasonalProfiles map[string]*seasonalProfile
+	// Rolling metric history of evaluated entities that are not tracked, for anomaly baselines
+	observed *observedTier
+	// Previous points of monotonic cumulative sums by entity identity, to evaluate rates and deltas
+	cumulativeSums map[string]*cumulativeSeries
+
+	// Parsed keep_conditions and drop_conditions
+	keepConditions *conditionSet
+	dropConditions *conditionSet
+
+	// Output volume of the current window, when a volume budget tunes the dynamic thresholds
+	volume *volumeWindow
+
+	// When each entity was last forwarded, for heartbeats
+	heartbeats map[string]time.Time
+
+	// Maintenance loops launched by Start; while they run, batches only record threshold samples
+	maintenanceTickers []*timeutils.PolicyTicker
+	maintenanceRunning atomic.Bool
+	maintenanceMu      sync.RWMutex // held by running ticks for reading, so stopMaintenance can wait for them
+	thresholdSamples   map[string]metricAverageData
+
+	// Internal telemetry reported through the collector's own metrics
+	telemetry *metadata.TelemetryBuilder
 	// Note: Anomaly detection uses LastAnomalyDetected in trackedEntity (separate timestamp)
 }
@@ -5,6 +5,6 @@ package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collecto
 
 // detectAnomaly is a method on the processorImp struct that delegates to the utility function.
 // This method is used by the process_metrics.go code.
-func (p *processorImp) detectAnomaly(entity *trackedEntity, values map[string]float64) (bool, string) {
-	return detectAnomalyUtil(p, entity, values)
+func (p *processorImp) detectAnomaly(entity *trackedEntity, values map[string]float64, cfg *Config) (bool, string) {
+	return detectAnomalyUtil(p, entity, values, cfg)
 }
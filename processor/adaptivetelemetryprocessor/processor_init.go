@@ -5,6 +5,7 @@ package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collecto
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"time"
 
@@ -14,7 +15,10 @@ import (
 )
 
 // newProcessor constructs the processor with configured features and storage.
-func newProcessor(logger *zap.Logger, config *Config, nextConsumer consumer.Metrics) (*processorImp, error) {
+// Internal telemetry is reported through set, and logs go to set.Logger.
+func newProcessor(set component.TelemetrySettings, config *Config) (*processorImp, error) {
+	logger := set.Logger
+
 	// Normalize & validate config first
 	config.Normalize()
 	if err := config.Validate(); err != nil {
@@ -30,8 +34,17 @@ func newProcessor(logger *zap.Logger, config *Config, nextConsumer consumer.Metr
 		logger.Info("DEBUG: EnableStorage config field is nil, using default", zap.Bool("default", true))
 	}
 
-	// Use default storage path based on platform
+	// Use the configured storage path, falling back to the platform default
 	storagePath := getDefaultStoragePath()
+	if config.StoragePath != "" {
+		storagePath = filepath.Clean(config.StoragePath)
+		// Config validation only checks the path lexically; reject symlinked paths before first use
+		if storageEnabled {
+			if err := validateStoragePath(storagePath, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	logger.Info("Initializing adaptivetelemetryprocessor",
 		zap.Int("metric_thresholds_count", len(config.MetricThresholds)),
@@ -50,13 +63,32 @@ func newProcessor(logger *zap.Logger, config *Config, nextConsumer consumer.Metr
 	p := &processorImp{
 		logger:                   logger,
 		config:                   config,
-		nextConsumer:             nextConsumer,
 		trackedEntities:          make(map[string]*trackedEntity),
 		persistenceEnabled:       storageEnabled,
 		dynamicThresholdsEnabled: config.EnableDynamicThresholds,
 		multiMetricEnabled:       config.EnableMultiMetric,
 		lastThresholdUpdate:      time.Now(),
 		dynamicCustomThresholds:  make(map[string]float64),
+		dynamicThresholdStats:    make(map[string]*dynamicThresholdStats),
+		seasonalProfiles:         make(map[string]*seasonalProfile),
+		observed:                 newObservedTier(),
+		cumulativeSums:           make(map[string]*cumulativeSeries),
+		heartbeats:               make(map[string]time.Time),
+		rules:                    resolveRules(config),
+	}
+	var err error
+	if p.keepConditions, err = newConditionSet("keep_conditions", config.KeepConditions, set); err != nil {
+		return nil, err
+	}
+	if p.dropConditions, err = newConditionSet("drop_conditions", config.DropConditions, set); err != nil {
+		return nil, err
+	}
+	if config.volumeBudgetEnabled() {
+		p.volume = &volumeWindow{hostDataPoints: make(map[string]int)}
+	}
+
+	if err := p.initTelemetry(set); err != nil {
+		return nil, fmt.Errorf("failed to create internal telemetry: %w", err)
 	}
 
 	// Seed dynamic thresholds with configured static thresholds
@@ -67,9 +99,22 @@ func newProcessor(logger *zap.Logger, config *Config, nextConsumer consumer.Metr
 		}
 	}
 
+	if config.InspectionEndpoint != "" {
+		p.decisions = newDecisionLog()
+	}
+
+	if config.ThresholdsFile != "" {
+		p.baseThresholds = snapshotThresholds(config)
+		p.reloadThresholdsFile()
+	}
+
 	if p.dynamicThresholdsEnabled {
 		logger.Info("Dynamic thresholds enabled", zap.Float64("smoothing_factor", config.DynamicSmoothingFactor), zap.Int("metrics_tracked", len(p.dynamicCustomThresholds)))
 	}
+	if entries := basenameIncludeEntries(config.IncludeProcessList); len(entries) > 0 && !config.AllowBasenameMatches {
+		logger.Warn("include_process_list entries without a path separator are ignored unless allow_basename_matches is set",
+			zap.Strings("entries", entries))
+	}
 	if p.multiMetricEnabled {
 		logger.Info("Multi-metric evaluation enabled", zap.Float64("composite_threshold", config.CompositeThreshold), zap.Int("weights_count", len(config.Weights)))
 	}
@@ -77,7 +122,11 @@ func newProcessor(logger *zap.Logger, config *Config, nextConsumer consumer.Metr
 		logger.Info("Anomaly detection enabled", zap.Int("history_size", config.AnomalyHistorySize), zap.Float64("change_threshold", config.AnomalyChangeThreshold))
 	}
 
-	if p.persistenceEnabled {
+	switch {
+	case p.persistenceEnabled && config.StorageID != nil:
+		// Client is obtained from the host in Start, state is loaded there
+		logger.Info("Using storage extension for persistent storage", zap.String("storage", config.StorageID.String()))
+	case p.persistenceEnabled:
 		logger.Info("Setting up persistent storage", zap.String("path", storagePath))
 		storageDir := filepath.Dir(storagePath)
 		if err := createDirectoryIfNotExists(storageDir); err != nil {
@@ -118,7 +167,9 @@ func newProcessor(logger *zap.Logger, config *Config, nextConsumer consumer.Metr
 }
 
 // Shutdown cleans up processor resources
-func (p *processorImp) Shutdown(_ context.Context) error {
+func (p *processorImp) Shutdown(ctx context.Context) error {
+	p.stopInspection(ctx)
+	p.stopMaintenance()
 	if p.persistenceEnabled && p.storage != nil {
 		if err := p.persistTrackedEntities(); err != nil {
 			p.logger.Warn("Failed to persist tracked entities during shutdown", zap.Error(err))
@@ -127,11 +178,45 @@ func (p *processorImp) Shutdown(_ context.Context) error {
 			p.logger.Warn("Failed to close storage during shutdown", zap.Error(err))
 		}
 	}
+	if p.telemetry != nil {
+		p.telemetry.Shutdown()
+	}
 	return nil
 }
 
-// Start is a no-op for this processor
-func (*processorImp) Start(_ context.Context, _ component.Host) error {
+// Start connects to the storage extension, if one is configured, loads persisted state and
+// launches the maintenance loops. It only runs once when the processor is shared by several pipelines.
+func (p *processorImp) Start(ctx context.Context, host component.Host) error {
+	var err error
+	p.startOnce.Do(func() {
+		if err = p.start(ctx, host); err != nil {
+			return
+		}
+		if p.config.InspectionEndpoint != "" {
+			if err = p.startInspection(); err != nil {
+				return
+			}
+		}
+		p.startMaintenance()
+	})
+	return err
+}
+
+func (p *processorImp) start(ctx context.Context, host component.Host) error {
+	if !p.persistenceEnabled || p.config.StorageID == nil {
+		return nil
+	}
+
+	client, err := getStorageClient(ctx, host, *p.config.StorageID, p.componentID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage client: %w", err)
+	}
+	p.storage = newExtensionStorage(client)
+
+	if err := p.loadTrackedEntities(); err != nil {
+		p.logger.Warn("Failed to load tracked entities, starting with empty state",
+			zap.Error(err))
+	}
 	return nil
 }
 
@@ -0,0 +1,119 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// processorStateVersion is the schema version of the persisted processor state.
+// Bump it and add a migration step in decodeProcessorState when the schema changes.
+const processorStateVersion = 1
+
+// processorState is the processor-level state persisted next to the tracked entities
+type processorState struct {
+	Version             int                            `json:"version"`
+	LastThresholdUpdate time.Time                      `json:"last_threshold_update"`
+	DynamicThresholds   map[string]*persistedThreshold `json:"dynamic_thresholds"`
+}
+
+// persistedThreshold is a learned dynamic threshold
+type persistedThreshold struct {
+	Value     float64   `json:"value"`
+	Base      float64   `json:"base"`    // Static threshold it was learned from
+	Samples   int64     `json:"samples"` // Values it was learned from
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// dynamicThresholdStats records how a dynamic threshold was learned since it was seeded
+type dynamicThresholdStats struct {
+	samples   int64
+	updatedAt time.Time
+}
+
+// encodeProcessorState encodes state with the current schema version
+func encodeProcessorState(state *processorState) ([]byte, error) {
+	state.Version = processorStateVersion
+	return json.Marshal(state)
+}
+
+// decodeProcessorState decodes persisted processor state. State from a newer, unsupported version
+// fails to decode instead of being silently discarded.
+func decodeProcessorState(data []byte) (*processorState, error) {
+	var state processorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode processor state: %w", err)
+	}
+	if state.Version > processorStateVersion {
+		return nil, fmt.Errorf("processor state version %d is newer than supported version %d", state.Version, processorStateVersion)
+	}
+	return &state, nil
+}
+
+// recordThresholdUpdate records that the dynamic threshold of metric was updated from samples values.
+// Callers hold p.mu.
+func (p *processorImp) recordThresholdUpdate(metric string, samples int, at time.Time) {
+	if p.dynamicThresholdStats == nil {
+		p.dynamicThresholdStats = make(map[string]*dynamicThresholdStats)
+	}
+	stats, ok := p.dynamicThresholdStats[metric]
+	if !ok {
+		stats = &dynamicThresholdStats{}
+		p.dynamicThresholdStats[metric] = stats
+	}
+	stats.samples += int64(samples)
+	stats.updatedAt = at
+}
+
+// snapshotProcessorState returns the processor state to persist. Callers hold p.mu for reading.
+func (p *processorImp) snapshotProcessorState() *processorState {
+	state := &processorState{
+		LastThresholdUpdate: p.lastThresholdUpdate,
+		DynamicThresholds:   make(map[string]*persistedThreshold, len(p.dynamicCustomThresholds)),
+	}
+	for metric, value := range p.dynamicCustomThresholds {
+		t := &persistedThreshold{Value: value, Base: p.config.MetricThresholds[metric]}
+		if stats, ok := p.dynamicThresholdStats[metric]; ok {
+			t.Samples = stats.samples
+			t.UpdatedAt = stats.updatedAt
+		}
+		state.DynamicThresholds[metric] = t
+	}
+	return state
+}
+
+// restoreProcessorState reconciles persisted state with the current configuration. Learned thresholds
+// are restored for metrics whose static threshold is unchanged, within min_thresholds and max_thresholds.
+// Metrics new to the configuration keep the threshold seeded from it, and thresholds of removed metrics,
+// or of metrics whose static threshold changed, are dropped. Callers hold p.mu.
+func (p *processorImp) restoreProcessorState(state *processorState, now time.Time) {
+	if !state.LastThresholdUpdate.IsZero() && state.LastThresholdUpdate.Before(now) {
+		p.lastThresholdUpdate = state.LastThresholdUpdate
+	}
+	if !p.dynamicThresholdsEnabled {
+		return
+	}
+
+	restored, dropped := 0, 0
+	for metric, t := range state.DynamicThresholds {
+		base, configured := p.config.MetricThresholds[metric]
+		if !configured || base <= 0 || t.Base != base || !isValidThreshold(t.Value) {
+			dropped++
+			continue
+		}
+		p.dynamicCustomThresholds[metric] = p.applyThresholdConstraints(metric, t.Value)
+		p.recordThresholdUpdate(metric, int(t.Samples), t.UpdatedAt)
+		restored++
+	}
+
+	p.logger.Info("Restored dynamic thresholds from storage",
+		zap.Int("restored", restored),
+		zap.Int("seeded", len(p.dynamicCustomThresholds)-restored),
+		zap.Int("dropped", dropped),
+		zap.Time("last_threshold_update", p.lastThresholdUpdate))
+}
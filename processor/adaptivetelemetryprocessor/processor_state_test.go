@@ -0,0 +1,142 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStateTestProcessor(t *testing.T, storage EntityStateStorage, thresholds map[string]float64) *processorImp {
+	p := newTestProcessor(t, &Config{}, withMetricThresholds(thresholds), withDynamicThresholds)
+	p.storage = storage
+	p.persistenceEnabled = true
+	return p
+}
+
+func TestProcessorStateFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	storage := newFileStorageForTesting(path, "")
+
+	state, err := storage.LoadProcessorState()
+	require.NoError(t, err)
+	assert.Nil(t, state, "nothing persisted yet")
+
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	saved := &processorState{
+		LastThresholdUpdate: updated,
+		DynamicThresholds: map[string]*persistedThreshold{
+			"process.cpu.utilization": {Value: 62.5, Base: 50, Samples: 120, UpdatedAt: updated},
+		},
+	}
+	require.NoError(t, storage.SaveProcessorState(saved))
+	state, err = storage.LoadProcessorState()
+	require.NoError(t, err)
+	assert.Equal(t, processorStateVersion, state.Version)
+	assert.Equal(t, saved.DynamicThresholds, state.DynamicThresholds)
+	assert.True(t, updated.Equal(state.LastThresholdUpdate))
+
+	// Saving entities leaves the processor state in place
+	require.NoError(t, storage.Save(map[string]*trackedEntity{}))
+	state, err = storage.LoadProcessorState()
+	require.NoError(t, err)
+	assert.Len(t, state.DynamicThresholds, 1)
+
+	require.NoError(t, os.WriteFile(path+processorStateFileSuffix, []byte(`{"version": 99}`), 0o600))
+	_, err = storage.LoadProcessorState()
+	assert.ErrorContains(t, err, "processor state version 99 is newer than supported version 1")
+}
+
+func TestProcessorStateExtensionStorage(t *testing.T) {
+	client := newMemoryStorageClient()
+	s := newExtensionStorage(client)
+
+	state, err := s.LoadProcessorState()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	saved := &processorState{DynamicThresholds: map[string]*persistedThreshold{"process.cpu.utilization": {Value: 62.5, Base: 50}}}
+	require.NoError(t, s.SaveProcessorState(saved))
+	require.NoError(t, s.SaveProcessorState(saved))
+	assert.Equal(t, []string{extensionStorageProcessorKey}, client.setKeys, "unchanged state is not written again")
+
+	state, err = newExtensionStorage(client).LoadProcessorState()
+	require.NoError(t, err)
+	assert.Equal(t, saved.DynamicThresholds, state.DynamicThresholds)
+
+	client.data[extensionStorageProcessorKey] = []byte("{")
+	_, err = newExtensionStorage(client).LoadProcessorState()
+	assert.ErrorContains(t, err, "failed to decode processor state")
+}
+
+func TestDynamicThresholdsSurviveRestart(t *testing.T) {
+	storage := newFileStorageForTesting(filepath.Join(t.TempDir(), "state.db"), "")
+	p := newStateTestProcessor(t, storage, map[string]float64{
+		"process.cpu.utilization":    50.0,
+		"process.memory.utilization": 40.0,
+		"process.disk.io":            1000.0,
+	})
+
+	// Learn from a batch of hot processes
+	p.lastThresholdUpdate = time.Now().Add(-time.Hour)
+	md := createTestProcessMetrics("/usr/bin/app", 10, 90)
+	addProcessToMetrics(md, "/usr/bin/app", 11, 70)
+	p.updateDynamicThresholds(md)
+	learned := p.dynamicCustomThresholds["process.cpu.utilization"]
+	require.Greater(t, learned, 50.0)
+	assert.Equal(t, int64(2), p.dynamicThresholdStats["process.cpu.utilization"].samples)
+	p.dynamicCustomThresholds["process.memory.utilization"] = 45.0
+	lastUpdate := p.lastThresholdUpdate
+	require.NoError(t, p.persistTrackedEntities())
+
+	// Restart with a configuration that removes disk, adds threads and changes memory
+	restarted := newStateTestProcessor(t, storage, map[string]float64{
+		"process.cpu.utilization":    50.0,
+		"process.memory.utilization": 30.0,
+		"process.threads":            200.0,
+	})
+	require.NoError(t, restarted.loadTrackedEntities())
+
+	assert.Equal(t, map[string]float64{
+		"process.cpu.utilization":    learned, // restored
+		"process.memory.utilization": 30.0,    // static threshold changed: learning restarts
+		"process.threads":            200.0,   // new metric: seeded
+	}, restarted.dynamicCustomThresholds)
+	require.Contains(t, restarted.dynamicThresholdStats, "process.cpu.utilization")
+	assert.Equal(t, int64(2), restarted.dynamicThresholdStats["process.cpu.utilization"].samples)
+	assert.NotContains(t, restarted.dynamicThresholdStats, "process.memory.utilization")
+	assert.True(t, lastUpdate.Equal(restarted.lastThresholdUpdate))
+}
+
+func TestRestoreProcessorStateConstraints(t *testing.T) {
+	p := newStateTestProcessor(t, nil, map[string]float64{"process.cpu.utilization": 50.0})
+	p.config.MaxThresholds = map[string]float64{"process.cpu.utilization": 60.0}
+
+	future := time.Now().Add(time.Hour)
+	start := p.lastThresholdUpdate
+	p.restoreProcessorState(&processorState{
+		LastThresholdUpdate: future,
+		DynamicThresholds: map[string]*persistedThreshold{
+			"process.cpu.utilization": {Value: 80.0, Base: 50.0},
+		},
+	}, time.Now())
+
+	assert.Equal(t, 60.0, p.dynamicCustomThresholds["process.cpu.utilization"], "within max_thresholds")
+	assert.Equal(t, start, p.lastThresholdUpdate, "an update time in the future is ignored")
+}
+
+func TestRestoreProcessorStateWithoutDynamicThresholds(t *testing.T) {
+	p := newStateTestProcessor(t, nil, map[string]float64{"process.cpu.utilization": 50.0})
+	p.dynamicThresholdsEnabled = false
+
+	p.restoreProcessorState(&processorState{
+		DynamicThresholds: map[string]*persistedThreshold{"process.cpu.utilization": {Value: 80.0, Base: 50.0}},
+	}, time.Now())
+	assert.Equal(t, 50.0, p.dynamicCustomThresholds["process.cpu.utilization"])
+}
@@ -17,7 +17,6 @@ import (
 
 func TestNewProcessor(t *testing.T) {
 	logger := zaptest.NewLogger(t)
-	nextConsumer := consumertest.NewNop()
 
 	testCases := []struct {
 		name        string
@@ -82,7 +81,7 @@ func TestNewProcessor(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			proc, err := newProcessor(logger, tc.config, nextConsumer)
+			proc, err := newProcessor(telemetrySettings(logger), tc.config)
 
 			if tc.expectedErr != "" {
 				require.Error(t, err)
@@ -95,7 +94,6 @@ func TestNewProcessor(t *testing.T) {
 				// Verify processor fields are set correctly
 				assert.Equal(t, logger, proc.logger)
 				assert.Equal(t, tc.config, proc.config)
-				assert.Equal(t, nextConsumer, proc.nextConsumer)
 				assert.NotNil(t, proc.trackedEntities)
 				assert.NotNil(t, proc.dynamicCustomThresholds)
 
@@ -121,14 +119,13 @@ func TestProcessorStartShutdownWithStorage(t *testing.T) {
 	t.Skip("Storage now uses default platform-specific paths")
 
 	logger := zaptest.NewLogger(t)
-	nextConsumer := consumertest.NewNop()
 
 	config := &Config{
 		RetentionMinutes: 10,
 		EnableStorage:    func() *bool { b := false; return &b }(), // Disable storage for this test
 	}
 
-	proc, err := newProcessor(logger, config, nextConsumer)
+	proc, err := newProcessor(telemetrySettings(logger), config)
 	require.NoError(t, err)
 	assert.NotNil(t, proc)
 	assert.True(t, proc.persistenceEnabled)
@@ -156,7 +153,7 @@ func TestProcessorStartShutdownWithStorage(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify data was persisted by creating a new processor and checking
-	proc2, err := newProcessor(logger, config, nextConsumer)
+	proc2, err := newProcessor(telemetrySettings(logger), config)
 	require.NoError(t, err)
 
 	// Verify entity was loaded
@@ -171,14 +168,13 @@ func TestProcessorStartShutdownWithStorage(t *testing.T) {
 
 func TestProcessorCleanupExpiredEntities(t *testing.T) {
 	logger := zaptest.NewLogger(t)
-	nextConsumer := consumertest.NewNop()
 
 	config := &Config{
 		RetentionMinutes: 10,
 		EnableStorage:    func() *bool { b := false; return &b }(), // Disable storage for this test
 	}
 
-	proc, err := newProcessor(logger, config, nextConsumer)
+	proc, err := newProcessor(telemetrySettings(logger), config)
 	require.NoError(t, err)
 
 	now := time.Now()
@@ -252,7 +248,7 @@ func TestConsumeMetricsBasic(t *testing.T) {
 		EnableStorage: func() *bool { b := false; return &b }(), // Disable storage
 	}
 
-	proc, err := newProcessor(logger, config, nextConsumer)
+	proc, err := newProcessor(telemetrySettings(logger), config)
 	require.NoError(t, err)
 
 	// Create test metrics that exceed threshold
@@ -262,7 +258,7 @@ func TestConsumeMetricsBasic(t *testing.T) {
 	)
 
 	// Process metrics
-	err = proc.ConsumeMetrics(t.Context(), md)
+	err = proc.consumeMetrics(t.Context(), md, nextConsumer)
 	require.NoError(t, err)
 
 	// Create test metrics that don't exceed threshold
@@ -272,7 +268,7 @@ func TestConsumeMetricsBasic(t *testing.T) {
 	)
 
 	// Process metrics again (should filter out)
-	err = proc.ConsumeMetrics(t.Context(), md)
+	err = proc.consumeMetrics(t.Context(), md, nextConsumer)
 	require.NoError(t, err)
 }
 
@@ -378,7 +374,7 @@ func TestCalculateCompositeGeneric(t *testing.T) {
 				config: tc.config,
 			}
 
-			score, _ := p.calculateCompositeGeneric(tc.values)
+			score, _ := p.calculateCompositeGeneric(tc.values, p.config)
 			assert.InDelta(t, tc.expected, score, 0.001)
 		})
 	}
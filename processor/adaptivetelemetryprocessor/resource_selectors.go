@@ -0,0 +1,95 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// validateSelectors checks the include and exclude selectors, and that process names are only
+// matched when allow_basename_matches is set
+func (cfg *Config) validateSelectors() error {
+	lists := []struct {
+		field     string
+		selectors []RuleSelector
+	}{{"include", cfg.Include}, {"exclude", cfg.Exclude}}
+	for _, list := range lists {
+		field := list.field
+		for i := range list.selectors {
+			s := &list.selectors[i]
+			if s.isEmpty() {
+				return fmt.Errorf("%s[%d] must set at least one criterion", field, i)
+			}
+			if err := s.validate(); err != nil {
+				return fmt.Errorf("%s[%d]: %w", field, i, err)
+			}
+			if len(s.ExecutableNames) > 0 && !cfg.AllowBasenameMatches {
+				return fmt.Errorf("%s[%d]: executable_names require allow_basename_matches", field, i)
+			}
+		}
+	}
+	for i := range cfg.Rules {
+		if len(cfg.Rules[i].Match.ExecutableNames) > 0 && !cfg.AllowBasenameMatches {
+			return fmt.Errorf("rule %q: executable_names require allow_basename_matches", cfg.Rules[i].Name)
+		}
+	}
+	return nil
+}
+
+// isExplicitlyIncluded reports whether a resource is on include_process_list or matches an include selector
+func (p *processorImp) isExplicitlyIncluded(attrs pcommon.Map) bool {
+	if isProcessInIncludeList(attrs, p.config.IncludeProcessList) {
+		return true
+	}
+	if p.config.AllowBasenameMatches && isProcessNameInIncludeList(attrs, p.config.IncludeProcessList) {
+		return true
+	}
+	return matchesAnySelector(p.config.Include, attrs)
+}
+
+// isExplicitlyExcluded reports whether a resource matches an exclude selector
+func (p *processorImp) isExplicitlyExcluded(attrs pcommon.Map) bool {
+	return matchesAnySelector(p.config.Exclude, attrs)
+}
+
+// matchesAnySelector reports whether a resource with the given attributes matches any of the selectors
+func matchesAnySelector(selectors []RuleSelector, attrs pcommon.Map) bool {
+	for i := range selectors {
+		if selectors[i].matches(attrs) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProcessNameInIncludeList checks the include list entries without a path separator against the
+// process name. Only used with allow_basename_matches, since any process can take a name.
+func isProcessNameInIncludeList(attrs pcommon.Map, includeList []string) bool {
+	name := extractProcessName(attrs)
+	if name == "" {
+		return false
+	}
+	for _, entry := range includeList {
+		entry = strings.TrimSpace(entry)
+		if entry != "" && !strings.ContainsAny(entry, "/\\") && entry == name {
+			return true
+		}
+	}
+	return false
+}
+
+// basenameIncludeEntries returns the include list entries that only match with allow_basename_matches
+func basenameIncludeEntries(includeList []string) []string {
+	var entries []string
+	for _, entry := range includeList {
+		entry = strings.TrimSpace(entry)
+		if entry != "" && !strings.ContainsAny(entry, "/\\") {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,222 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func processAttrs(path string) pcommon.Map {
+	attrs := pcommon.NewMap()
+	attrs.PutStr("process.executable.path", path)
+	attrs.PutInt("process.pid", 42)
+	return attrs
+}
+
+func TestRuleSelectorMatching(t *testing.T) {
+	testCases := []struct {
+		name     string
+		selector RuleSelector
+		path     string
+		expected bool
+	}{
+		{
+			name:     "Glob path",
+			selector: RuleSelector{ExecutablePaths: []string{"/opt/*/bin/java"}},
+			path:     "/opt/jdk17/bin/java",
+			expected: true,
+		},
+		{
+			name:     "Glob does not cross separators",
+			selector: RuleSelector{ExecutablePaths: []string{"/opt/*/bin/java"}},
+			path:     "/opt/jdk/17/bin/java",
+			expected: false,
+		},
+		{
+			name:     "Regexp path",
+			selector: RuleSelector{MatchType: matchTypeRegexp, ExecutablePaths: []string{"/usr/s?bin/nginx"}},
+			path:     "/usr/bin/nginx",
+			expected: true,
+		},
+		{
+			name:     "Regexp is anchored",
+			selector: RuleSelector{MatchType: matchTypeRegexp, ExecutablePaths: []string{"/usr/s?bin/nginx"}},
+			path:     "/tmp/usr/sbin/nginx-debug",
+			expected: false,
+		},
+		{
+			name:     "Executable name",
+			selector: RuleSelector{ExecutableNames: []string{"java*"}},
+			path:     "/opt/jdk17/bin/java",
+			expected: false, // process.executable.name is not set
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.selector.validate())
+			assert.Equal(t, tc.expected, tc.selector.matches(processAttrs(tc.path)))
+		})
+	}
+}
+
+func TestRuleSelectorSliceAttribute(t *testing.T) {
+	attrs := processAttrs("/usr/bin/python3")
+	args := attrs.PutEmptySlice("process.command_args")
+	args.AppendEmpty().SetStr("/usr/bin/python3")
+	args.AppendEmpty().SetStr("--role=primary")
+
+	assert.True(t, (&RuleSelector{Attributes: map[string]string{"process.command_args": "--role=*"}}).matches(attrs))
+	assert.False(t, (&RuleSelector{Attributes: map[string]string{"process.command_args": "--role=replica"}}).matches(attrs))
+}
+
+func TestSelectorValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      Config
+		errorString string
+	}{
+		{
+			name:        "Empty selector",
+			config:      Config{Exclude: []RuleSelector{{}}},
+			errorString: "exclude[0] must set at least one criterion",
+		},
+		{
+			name:        "Executable names without opt-in",
+			config:      Config{Include: []RuleSelector{{ExecutableNames: []string{"java"}}}},
+			errorString: "include[0]: executable_names require allow_basename_matches",
+		},
+		{
+			name:        "Rule executable names without opt-in",
+			config:      Config{Rules: []RuleConfig{{Name: "jvm", Match: RuleSelector{ExecutableNames: []string{"java"}}}}},
+			errorString: `rule "jvm": executable_names require allow_basename_matches`,
+		},
+		{
+			name:        "Invalid regexp",
+			config:      Config{Include: []RuleSelector{{MatchType: matchTypeRegexp, ExecutablePaths: []string{"/usr/(bin"}}}},
+			errorString: "include[0]: invalid executable_paths pattern",
+		},
+		{
+			name:        "Invalid match type",
+			config:      Config{Exclude: []RuleSelector{{MatchType: "exact", ResourceType: "process"}}},
+			errorString: "exclude[0]: match_type must be",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errorString)
+		})
+	}
+
+	cfg := Config{AllowBasenameMatches: true, Include: []RuleSelector{{ExecutableNames: []string{"java"}}}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestIncludeSelectorBypassesFilters(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		Include: []RuleSelector{{Attributes: map[string]string{"k8s.deployment.name": "checkout-*"}}},
+	})
+
+	md := createTestProcessMetrics("/usr/bin/app", 10, 1)
+	md.ResourceMetrics().At(0).Resource().Attributes().PutStr("k8s.deployment.name", "checkout-api")
+	addProcessToMetrics(md, "/usr/bin/other", 11, 1)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	require.Len(t, pids, 1)
+	assert.Contains(t, pids[10], `"stage":"include_list"`)
+}
+
+func TestExcludeTakesPriority(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		IncludeProcessList: []string{"/usr/sbin/nginx"},
+		HeartbeatInterval:  time.Minute,
+		TopN:               map[string]int{"process.cpu.utilization": 5},
+		Exclude: []RuleSelector{{
+			MatchType:       matchTypeRegexp,
+			ExecutablePaths: []string{"/usr/(s)?bin/(nginx|hog)"},
+		}},
+	})
+
+	md := createTestProcessMetrics("/usr/sbin/nginx", 10, 1)
+	addProcessToMetrics(md, "/usr/bin/hog", 11, 95)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len(), "no include list, threshold, heartbeat or top N")
+	assert.Empty(t, p.trackedEntities)
+}
+
+func TestExcludeInShadowMode(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		Mode:    modeShadow,
+		Exclude: []RuleSelector{{ExecutablePaths: []string{"/usr/bin/hog"}}},
+	})
+
+	out, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/hog", 11, 95))
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	require.Len(t, pids, 1)
+	assert.Contains(t, pids[11], `"decision":"drop"`)
+	assert.Contains(t, pids[11], `"stage":"exclude_list"`)
+}
+
+func TestExcludeNonProcessResource(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		Exclude: []RuleSelector{{ResourceType: "service:*"}},
+	})
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "debug-sidecar")
+	rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len(), "exclusion applies before default inclusion")
+
+	forward, _ := p.shouldForwardSignalResource(rm.Resource())
+	assert.False(t, forward)
+}
+
+func TestBasenameIncludeListOptIn(t *testing.T) {
+	testCases := []struct {
+		name     string
+		allow    bool
+		expected int
+	}{
+		{name: "Refused by default", allow: false, expected: 0},
+		{name: "Allowed with opt-in", allow: true, expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProcessor(t, &Config{
+				IncludeProcessList:   []string{"app"},
+				AllowBasenameMatches: tc.allow,
+			})
+
+			out, err := p.processMetrics(t.Context(), createTestProcessMetrics("app", 10, 1))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out.ResourceMetrics().Len())
+
+			forward, _ := p.shouldForwardSignalResource(createTestProcessMetrics("app", 10, 1).ResourceMetrics().At(0).Resource())
+			assert.Equal(t, tc.allow, forward)
+		})
+	}
+}
+
+func TestBasenameIncludeEntries(t *testing.T) {
+	assert.Equal(t, []string{"redis-server"}, basenameIncludeEntries([]string{"/usr/sbin/nginx", " redis-server ", ""}))
+}
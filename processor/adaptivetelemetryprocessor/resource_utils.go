@@ -5,6 +5,7 @@ package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collecto
 
 import (
 	"encoding/json"
+	"slices"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -192,39 +193,145 @@ func countMetricsInResource(rm pmetric.ResourceMetrics) int {
 	return count
 }
 
-// updateProcessATPAttribute updates the process.atp JSON attribute with new data under the given key
+// countDataPointsInResource counts the data points of all metrics in a resource
+func countDataPointsInResource(rm pmetric.ResourceMetrics) int {
+	count := 0
+	for i := 0; i < rm.ScopeMetrics().Len(); i++ {
+		metrics := rm.ScopeMetrics().At(i).Metrics()
+		for j := 0; j < metrics.Len(); j++ {
+			m := metrics.At(j)
+			switch m.Type() {
+			case pmetric.MetricTypeGauge:
+				count += m.Gauge().DataPoints().Len()
+			case pmetric.MetricTypeSum:
+				count += m.Sum().DataPoints().Len()
+			case pmetric.MetricTypeHistogram:
+				count += m.Histogram().DataPoints().Len()
+			case pmetric.MetricTypeExponentialHistogram:
+				count += m.ExponentialHistogram().DataPoints().Len()
+			case pmetric.MetricTypeSummary:
+				count += m.Summary().DataPoints().Len()
+			}
+		}
+	}
+	return count
+}
+
+// keepMetrics removes the metrics of a resource that are not listed in metrics, and the scopes left empty.
+// All metrics are kept when metrics is empty.
+func keepMetrics(rm pmetric.ResourceMetrics, metrics []string) {
+	if len(metrics) == 0 {
+		return
+	}
+	keep := make(map[string]bool, len(metrics))
+	for _, name := range metrics {
+		keep[name] = true
+	}
+	rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+		sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+			return !keep[m.Name()]
+		})
+		return sm.Metrics().Len() == 0
+	})
+}
+
+// hasAnyMetric reports whether the resource has a metric listed in metrics. Always true when metrics is empty,
+// as keepMetrics then keeps all metrics
+func hasAnyMetric(rm pmetric.ResourceMetrics, metrics []string) bool {
+	if len(metrics) == 0 {
+		return true
+	}
+	for i := 0; i < rm.ScopeMetrics().Len(); i++ {
+		ms := rm.ScopeMetrics().At(i).Metrics()
+		for j := 0; j < ms.Len(); j++ {
+			if slices.Contains(metrics, ms.At(j).Name()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// putATPDetails stores data under key in the native process.atp map of the resource.
+// Details are kept as a map while the resource is evaluated and converted to the configured
+// output mode once by finalizeATPAttributes. A JSON string left by an upstream ATP is merged.
 // The logger parameter is optional and can be nil
-func updateProcessATPAttribute(resource pcommon.Resource, key string, data any, logger *zap.Logger) {
+func putATPDetails(resource pcommon.Resource, key string, data map[string]any, logger *zap.Logger) {
+	atp := atpDetailsMap(resource, key, logger)
+	if err := atp.PutEmptyMap(key).FromRaw(data); err != nil && logger != nil {
+		logger.Error("Failed to store process.atp details",
+			zap.Error(err),
+			zap.String("key", key))
+	}
+}
+
+// atpDetailsMap returns the native process.atp map of the resource, creating it if needed
+func atpDetailsMap(resource pcommon.Resource, key string, logger *zap.Logger) pcommon.Map {
 	attrs := resource.Attributes()
-	var atpData map[string]any
+	val, ok := attrs.Get(atpAttributeKey)
+	if ok && val.Type() == pcommon.ValueTypeMap {
+		return val.Map()
+	}
 
-	// Check if attribute exists and parse it
-	if val, ok := attrs.Get("process.atp"); ok {
+	atp := pcommon.NewMap()
+	if ok {
 		existingJSON := val.AsString()
-		// Try to unmarshal existing data
-		if err := json.Unmarshal([]byte(existingJSON), &atpData); err != nil {
-			// Log the corruption and start with a clean map to avoid propagating corruption
-			if logger != nil {
-				logger.Warn("Failed to unmarshal process.atp attribute, starting fresh",
-					zap.Error(err),
-					zap.String("corrupted_json", existingJSON),
-					zap.String("new_key", key))
-			}
-			// atpData remains nil and will be initialized below
+		var atpData map[string]any
+		if err := json.Unmarshal([]byte(existingJSON), &atpData); err == nil {
+			_ = atp.FromRaw(atpData)
+		} else if logger != nil {
+			// Start with a clean map to avoid propagating corruption
+			logger.Warn("Failed to unmarshal process.atp attribute, starting fresh",
+				zap.Error(err),
+				zap.String("corrupted_json", existingJSON),
+				zap.String("new_key", key))
 		}
 	}
+	atp.MoveTo(attrs.PutEmptyMap(atpAttributeKey))
+	v, _ := attrs.Get(atpAttributeKey)
+	return v.Map()
+}
 
-	if atpData == nil {
-		atpData = make(map[string]any)
+// finalizeATPAttributes converts the native process.atp map of an exported resource to mode
+func finalizeATPAttributes(resource pcommon.Resource, mode string, logger *zap.Logger) {
+	attrs := resource.Attributes()
+	if mode == attributesModeNone {
+		attrs.Remove(atpAttributeKey)
+		attrs.Remove(atpEnabledAttributeKey)
+		return
 	}
 
-	atpData[key] = data
+	val, ok := attrs.Get(atpAttributeKey)
+	if !ok || val.Type() != pcommon.ValueTypeMap {
+		return
+	}
 
-	if jsonData, err := json.Marshal(atpData); err == nil {
-		attrs.PutStr("process.atp", string(jsonData))
-	} else if logger != nil {
-		logger.Error("Failed to marshal process.atp attribute",
-			zap.Error(err),
-			zap.String("key", key))
+	switch mode {
+	case attributesModeMap:
+	case attributesModeFlat:
+		atp := pcommon.NewMap()
+		val.Map().MoveTo(atp)
+		attrs.Remove(atpAttributeKey)
+		putFlattened(attrs, atpFlatAttributePrefix, atp)
+	default:
+		if jsonData, err := json.Marshal(val.Map().AsRaw()); err == nil {
+			attrs.PutStr(atpAttributeKey, string(jsonData))
+		} else {
+			if logger != nil {
+				logger.Error("Failed to marshal process.atp attribute", zap.Error(err))
+			}
+			attrs.Remove(atpAttributeKey)
+		}
+	}
+}
+
+// putFlattened copies the leaves of m into attrs, joining nested keys with dots under prefix
+func putFlattened(attrs pcommon.Map, prefix string, m pcommon.Map) {
+	for k, v := range m.All() {
+		if v.Type() == pcommon.ValueTypeMap {
+			putFlattened(attrs, prefix+k+".", v.Map())
+			continue
+		}
+		v.CopyTo(attrs.PutEmpty(prefix + k))
 	}
 }
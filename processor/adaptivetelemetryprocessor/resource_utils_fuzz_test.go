@@ -9,10 +9,11 @@ import (
 	"go.opentelemetry.io/collector/pdata/pcommon"
 )
 
-func FuzzUpdateProcessATPAttribute(f *testing.F) {
+func FuzzPutATPDetails(f *testing.F) {
 	f.Fuzz(func(_ *testing.T, existing string) {
 		resource := pcommon.NewResource()
 		resource.Attributes().PutStr("process.atp", existing)
-		updateProcessATPAttribute(resource, "testkey", "testvalue", nil)
+		putATPDetails(resource, "testkey", map[string]any{"key": "testvalue"}, nil)
+		finalizeATPAttributes(resource, attributesModeJSON, nil)
 	})
 }
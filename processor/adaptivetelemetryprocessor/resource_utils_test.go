@@ -281,15 +281,16 @@ func TestPersistenceAndLoading(t *testing.T) {
 	assert.Equal(t, map[string]string{"type": "process", "name": "app2"}, entity2.Attributes)
 }
 
-func TestUpdateProcessATPAttribute(t *testing.T) {
+func TestPutATPDetails(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 
 	t.Run("Add new key to empty attribute", func(t *testing.T) {
 		resource := pcommon.NewResource()
 		data := map[string]any{"key1": "value1"}
 
-		updateProcessATPAttribute(resource, "test_key", data, logger)
+		putATPDetails(resource, "test_key", data, logger)
 
+		finalizeATPAttributes(resource, attributesModeJSON, logger)
 		val, ok := resource.Attributes().Get("process.atp")
 		require.True(t, ok, "process.atp attribute should exist")
 
@@ -304,12 +305,13 @@ func TestUpdateProcessATPAttribute(t *testing.T) {
 
 		// Add first key
 		data1 := map[string]any{"metric1": "value1"}
-		updateProcessATPAttribute(resource, "threshold_details", data1, logger)
+		putATPDetails(resource, "threshold_details", data1, logger)
 
 		// Add second key
 		data2 := map[string]any{"score": 0.75}
-		updateProcessATPAttribute(resource, "multi_metric", data2, logger)
+		putATPDetails(resource, "multi_metric", data2, logger)
 
+		finalizeATPAttributes(resource, attributesModeJSON, logger)
 		val, ok := resource.Attributes().Get("process.atp")
 		require.True(t, ok)
 
@@ -330,8 +332,9 @@ func TestUpdateProcessATPAttribute(t *testing.T) {
 
 		// Try to add new data - should start fresh and log warning
 		data := map[string]any{"new_key": "new_value"}
-		updateProcessATPAttribute(resource, "threshold_details", data, logger)
+		putATPDetails(resource, "threshold_details", data, logger)
 
+		finalizeATPAttributes(resource, attributesModeJSON, logger)
 		val, ok := resource.Attributes().Get("process.atp")
 		require.True(t, ok)
 
@@ -349,8 +352,9 @@ func TestUpdateProcessATPAttribute(t *testing.T) {
 		data := map[string]any{"key1": "value1"}
 
 		// Should not panic with nil logger
-		updateProcessATPAttribute(resource, "test_key", data, nil)
+		putATPDetails(resource, "test_key", data, nil)
 
+		finalizeATPAttributes(resource, attributesModeJSON, nil)
 		val, ok := resource.Attributes().Get("process.atp")
 		require.True(t, ok)
 
@@ -360,3 +364,69 @@ func TestUpdateProcessATPAttribute(t *testing.T) {
 		assert.Equal(t, data, atpData["test_key"])
 	})
 }
+
+func TestFinalizeATPAttributes(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newResource := func() pcommon.Resource {
+		resource := pcommon.NewResource()
+		resource.Attributes().PutBool("process.atp.enabled", true)
+		atpDetailsMap(resource, "stage", logger).PutStr("stage", stageStaticThreshold)
+		putATPDetails(resource, "threshold_details", map[string]any{
+			"cpu": map[string]any{"threshold": 5.0, "threshold_type": "static"},
+		}, logger)
+		putATPDetails(resource, "anomaly", map[string]any{"reason": "spike"}, logger)
+		return resource
+	}
+
+	t.Run("json", func(t *testing.T) {
+		resource := newResource()
+		finalizeATPAttributes(resource, attributesModeJSON, logger)
+
+		val, ok := resource.Attributes().Get("process.atp")
+		require.True(t, ok)
+		require.Equal(t, pcommon.ValueTypeStr, val.Type())
+		var atpData map[string]any
+		require.NoError(t, json.Unmarshal([]byte(val.Str()), &atpData))
+		assert.Equal(t, stageStaticThreshold, atpData["stage"])
+		assert.Equal(t, map[string]any{"reason": "spike"}, atpData["anomaly"])
+	})
+
+	t.Run("map", func(t *testing.T) {
+		resource := newResource()
+		finalizeATPAttributes(resource, attributesModeMap, logger)
+
+		val, ok := resource.Attributes().Get("process.atp")
+		require.True(t, ok)
+		require.Equal(t, pcommon.ValueTypeMap, val.Type())
+		stage, _ := val.Map().Get("stage")
+		assert.Equal(t, stageStaticThreshold, stage.Str())
+		details, _ := val.Map().Get("threshold_details")
+		cpu, _ := details.Map().Get("cpu")
+		threshold, _ := cpu.Map().Get("threshold")
+		assert.Equal(t, 5.0, threshold.Double())
+	})
+
+	t.Run("flat", func(t *testing.T) {
+		resource := newResource()
+		finalizeATPAttributes(resource, attributesModeFlat, logger)
+
+		attrs := resource.Attributes()
+		_, ok := attrs.Get("process.atp")
+		assert.False(t, ok)
+		assert.Equal(t, map[string]any{
+			"process.atp.enabled":                      true,
+			"atp.stage":                                stageStaticThreshold,
+			"atp.threshold_details.cpu.threshold":      5.0,
+			"atp.threshold_details.cpu.threshold_type": "static",
+			"atp.anomaly.reason":                       "spike",
+		}, attrs.AsRaw())
+	})
+
+	t.Run("none", func(t *testing.T) {
+		resource := newResource()
+		resource.Attributes().PutStr("service.name", "app")
+		finalizeATPAttributes(resource, attributesModeNone, logger)
+
+		assert.Equal(t, map[string]any{"service.name": "app"}, resource.Attributes().AsRaw())
+	})
+}
@@ -0,0 +1,273 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// resolvedRule is a rule with its settings merged over the top-level configuration
+type resolvedRule struct {
+	name  string
+	match RuleSelector
+	cfg   *Config
+}
+
+// validateRules checks rule names are set and unique, and that selectors and settings are valid
+func validateRules(rules []RuleConfig) error {
+	seen := make(map[string]bool, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("rules[%d]: name cannot be empty", i)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("rules[%d]: duplicate rule name %q", i, rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if err := rule.Match.validate(); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		for metric, threshold := range rule.MetricThresholds {
+			if threshold < 0 {
+				return fmt.Errorf("rule %q: threshold for metric %q must be >= 0, got %v", rule.Name, metric, threshold)
+			}
+		}
+		if rule.CompositeThreshold < 0 {
+			return fmt.Errorf("rule %q: composite_threshold must be >= 0, got %v", rule.Name, rule.CompositeThreshold)
+		}
+		if rule.RetentionMinutes < 0 {
+			return fmt.Errorf("rule %q: retention_minutes must be >= 0, got %d", rule.Name, rule.RetentionMinutes)
+		}
+		if rule.AnomalyMethod != "" && !isValidAnomalyMethod(rule.AnomalyMethod) {
+			return fmt.Errorf("rule %q: anomaly_method must be one of %v, got %q", rule.Name, validAnomalyMethods, rule.AnomalyMethod)
+		}
+		for metric, method := range rule.AnomalyMethods {
+			if !isValidAnomalyMethod(method) {
+				return fmt.Errorf("rule %q: anomaly_methods[%s] must be one of %v, got %q", rule.Name, metric, validAnomalyMethods, method)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the match type and that all patterns in the selector are well formed
+func (s *RuleSelector) validate() error {
+	switch s.MatchType {
+	case "", matchTypeGlob, matchTypeRegexp:
+	default:
+		return fmt.Errorf("match_type must be %q or %q, got %q", matchTypeGlob, matchTypeRegexp, s.MatchType)
+	}
+	if err := s.checkPattern(path.Match, s.ResourceType); err != nil {
+		return fmt.Errorf("invalid resource_type pattern %q: %w", s.ResourceType, err)
+	}
+	for _, pattern := range s.ExecutablePaths {
+		if pattern == "" {
+			return errors.New("executable_paths cannot contain empty patterns")
+		}
+		if err := s.checkPattern(filepath.Match, pattern); err != nil {
+			return fmt.Errorf("invalid executable_paths pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range s.ExecutableNames {
+		if pattern == "" {
+			return errors.New("executable_names cannot contain empty patterns")
+		}
+		if err := s.checkPattern(path.Match, pattern); err != nil {
+			return fmt.Errorf("invalid executable_names pattern %q: %w", pattern, err)
+		}
+	}
+	for key, pattern := range s.Attributes {
+		if err := s.checkPattern(path.Match, pattern); err != nil {
+			return fmt.Errorf("invalid attributes[%s] pattern %q: %w", key, pattern, err)
+		}
+	}
+	return nil
+}
+
+// checkPattern reports whether pattern is a valid glob for glob, or a valid regexp with the regexp match type
+func (s *RuleSelector) checkPattern(glob func(pattern, name string) (bool, error), pattern string) error {
+	if s.MatchType == matchTypeRegexp {
+		_, err := selectorRegexp(pattern)
+		return err
+	}
+	_, err := glob(pattern, "")
+	return err
+}
+
+// matches reports whether a resource with the given attributes is selected
+func (s *RuleSelector) matches(attrs pcommon.Map) bool {
+	if s.ResourceType != "" && !s.matchPattern(path.Match, s.ResourceType, getResourceType(attrs)) {
+		return false
+	}
+
+	if len(s.ExecutablePaths) > 0 {
+		execPath := extractProcessExecutablePath(attrs)
+		if execPath == "" || !s.matchesAny(filepath.Match, s.ExecutablePaths, execPath) {
+			return false
+		}
+	}
+
+	if len(s.ExecutableNames) > 0 {
+		name := extractProcessName(attrs)
+		if name == "" || !s.matchesAny(path.Match, s.ExecutableNames, name) {
+			return false
+		}
+	}
+
+	for key, pattern := range s.Attributes {
+		v, ok := attrs.Get(key)
+		if !ok || !s.matchAttribute(pattern, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchAttribute reports whether an attribute value matches pattern. A slice, such as
+// process.command_args, matches when any of its elements does.
+func (s *RuleSelector) matchAttribute(pattern string, v pcommon.Value) bool {
+	if v.Type() != pcommon.ValueTypeSlice {
+		return s.matchPattern(path.Match, pattern, v.AsString())
+	}
+	for _, elem := range v.Slice().All() {
+		if s.matchPattern(path.Match, pattern, elem.AsString()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether value matches any of the patterns
+func (s *RuleSelector) matchesAny(glob func(pattern, name string) (bool, error), patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if s.matchPattern(glob, pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern reports whether value matches pattern: with glob by default, or as a regexp anchored
+// to the whole value with the regexp match type
+func (s *RuleSelector) matchPattern(glob func(pattern, name string) (bool, error), pattern, value string) bool {
+	if s.MatchType == matchTypeRegexp {
+		re, err := selectorRegexp(pattern)
+		return err == nil && re.MatchString(value)
+	}
+	ok, _ := glob(pattern, value)
+	return ok
+}
+
+// selectorRegexps caches the compiled regexps of selector patterns, which are only known from the configuration
+var selectorRegexps sync.Map
+
+// selectorRegexp returns the regexp of a pattern, anchored to the whole value
+func selectorRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := selectorRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	selectorRegexps.Store(pattern, re)
+	return re, nil
+}
+
+// withRule returns a copy of cfg with the rule's settings applied and normalized
+func (cfg *Config) withRule(rule *RuleConfig) *Config {
+	merged := *cfg
+	merged.Rules = nil
+
+	if rule.MetricThresholds != nil {
+		merged.MetricThresholds = rule.MetricThresholds
+		// Rule thresholds are explicit per resource type, so they are not replaced by learned ones
+		merged.EnableDynamicThresholds = false
+	}
+	if rule.Weights != nil {
+		merged.Weights = rule.Weights
+	}
+	if rule.CompositeThreshold > 0 {
+		merged.CompositeThreshold = rule.CompositeThreshold
+	}
+	if rule.RetentionMinutes > 0 {
+		merged.RetentionMinutes = rule.RetentionMinutes
+	}
+	if rule.EnableAnomalyDetection != nil {
+		merged.EnableAnomalyDetection = *rule.EnableAnomalyDetection
+	}
+	if rule.AnomalyChangeThreshold > 0 {
+		merged.AnomalyChangeThreshold = rule.AnomalyChangeThreshold
+	}
+	if rule.AnomalyMethod != "" {
+		merged.AnomalyMethod = rule.AnomalyMethod
+	}
+	if rule.AnomalyMethods != nil {
+		merged.AnomalyMethods = rule.AnomalyMethods
+	}
+	if rule.AnomalyScoreThreshold > 0 {
+		merged.AnomalyScoreThreshold = rule.AnomalyScoreThreshold
+	}
+
+	merged.Normalize()
+	return &merged
+}
+
+// resolveRules merges every rule over the top-level configuration, preserving rule order
+func resolveRules(cfg *Config) []*resolvedRule {
+	rules := make([]*resolvedRule, 0, len(cfg.Rules))
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		rules = append(rules, &resolvedRule{
+			name:  rule.Name,
+			match: rule.Match,
+			cfg:   cfg.withRule(rule),
+		})
+	}
+	return rules
+}
+
+// configFor returns the effective configuration for a resource and the name of the rule it matched.
+// The first matching rule wins; resources matching no rule use the top-level configuration.
+func (p *processorImp) configFor(resource pcommon.Resource) (*Config, string) {
+	if len(p.rules) == 0 {
+		return p.config, ""
+	}
+	attrs := resource.Attributes()
+	for _, rule := range p.rules {
+		if rule.match.matches(attrs) {
+			return rule.cfg, rule.name
+		}
+	}
+	return p.config, ""
+}
+
+// configForEntity returns the effective configuration of the rule a tracked entity was last evaluated with
+func (p *processorImp) configForEntity(trackedEntity *trackedEntity) *Config {
+	if trackedEntity == nil || trackedEntity.Rule == "" {
+		return p.config
+	}
+	for _, rule := range p.rules {
+		if rule.name == trackedEntity.Rule {
+			return rule.cfg
+		}
+	}
+	// Rule was removed from the configuration since the entity was persisted
+	return p.config
+}
+
+// dynamicThresholdsApply reports whether dynamic thresholds are used for resources evaluated with cfg
+func (p *processorImp) dynamicThresholdsApply(cfg *Config) bool {
+	return p.dynamicThresholdsEnabled && (cfg == p.config || cfg.EnableDynamicThresholds)
+}
@@ -0,0 +1,214 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestRuleSelectorMatches(t *testing.T) {
+	testCases := []struct {
+		name     string
+		selector RuleSelector
+		attrs    map[string]any
+		expected bool
+	}{
+		{
+			name:     "Empty selector matches everything",
+			selector: RuleSelector{},
+			attrs:    map[string]any{"host.name": "h1"},
+			expected: true,
+		},
+		{
+			name:     "Resource type glob",
+			selector: RuleSelector{ResourceType: "proc*"},
+			attrs:    map[string]any{"process.pid": int64(1)},
+			expected: true,
+		},
+		{
+			name:     "Resource type mismatch",
+			selector: RuleSelector{ResourceType: resourceTypeProcess},
+			attrs:    map[string]any{"host.name": "h1"},
+			expected: false,
+		},
+		{
+			name:     "Executable path glob",
+			selector: RuleSelector{ExecutablePaths: []string{"/usr/sbin/nginx", "/usr/lib/postgresql/*/bin/postgres"}},
+			attrs:    map[string]any{"process.pid": int64(1), "process.executable.path": "/usr/lib/postgresql/16/bin/postgres"},
+			expected: true,
+		},
+		{
+			name:     "Executable path required",
+			selector: RuleSelector{ExecutablePaths: []string{"/usr/sbin/*"}},
+			attrs:    map[string]any{"process.pid": int64(1)},
+			expected: false,
+		},
+		{
+			name:     "All attributes must match",
+			selector: RuleSelector{Attributes: map[string]string{"k8s.namespace.name": "prod-*", "team": "db"}},
+			attrs:    map[string]any{"k8s.namespace.name": "prod-eu", "team": "web"},
+			expected: false,
+		},
+		{
+			name:     "Missing attribute",
+			selector: RuleSelector{Attributes: map[string]string{"k8s.namespace.name": "*"}},
+			attrs:    map[string]any{"host.name": "h1"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attrs := pcommon.NewMap()
+			require.NoError(t, attrs.FromRaw(tc.attrs))
+			assert.Equal(t, tc.expected, tc.selector.matches(attrs))
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	testCases := []struct {
+		name        string
+		rules       []RuleConfig
+		errorString string
+	}{
+		{
+			name:  "Valid rules",
+			rules: []RuleConfig{{Name: "db", Match: RuleSelector{ResourceType: "process"}}, {Name: "rest"}},
+		},
+		{
+			name:        "Missing name",
+			rules:       []RuleConfig{{}},
+			errorString: "rules[0]: name cannot be empty",
+		},
+		{
+			name:        "Duplicate name",
+			rules:       []RuleConfig{{Name: "db"}, {Name: "db"}},
+			errorString: "rules[1]: duplicate rule name \"db\"",
+		},
+		{
+			name:        "Malformed glob",
+			rules:       []RuleConfig{{Name: "db", Match: RuleSelector{ExecutablePaths: []string{"/usr/[bin"}}}},
+			errorString: "invalid executable_paths pattern",
+		},
+		{
+			name:        "Negative threshold",
+			rules:       []RuleConfig{{Name: "db", MetricThresholds: map[string]float64{"process.cpu.utilization": -1}}},
+			errorString: "rule \"db\": threshold for metric",
+		},
+		{
+			name:        "Invalid anomaly method",
+			rules:       []RuleConfig{{Name: "db", AnomalyMethod: "median"}},
+			errorString: "rule \"db\": anomaly_method must be one of",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRules(tc.rules)
+			if tc.errorString == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errorString)
+		})
+	}
+}
+
+func TestWithRuleInheritsTopLevelSettings(t *testing.T) {
+	cfg := &Config{
+		MetricThresholds:        map[string]float64{"process.cpu.utilization": 5.0},
+		Weights:                 map[string]float64{"process.cpu.utilization": 1.0},
+		EnableDynamicThresholds: true,
+		RetentionMinutes:        20,
+		AnomalyMethod:           anomalyMethodZScore,
+	}
+	cfg.Normalize()
+
+	merged := cfg.withRule(&RuleConfig{
+		Name:             "db",
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 40.0},
+		RetentionMinutes: 10,
+	})
+
+	assert.Equal(t, 40.0, merged.MetricThresholds["process.cpu.utilization"])
+	assert.False(t, merged.EnableDynamicThresholds, "rule thresholds are not replaced by learned ones")
+	assert.Equal(t, int64(10), merged.RetentionMinutes)
+	assert.Equal(t, cfg.Weights, merged.Weights)
+	assert.Equal(t, anomalyMethodZScore, merged.AnomalyMethod)
+	// Top-level configuration is left untouched
+	assert.Equal(t, 5.0, cfg.MetricThresholds["process.cpu.utilization"])
+	assert.Equal(t, int64(20), cfg.RetentionMinutes)
+}
+
+func TestRulesFirstMatchWins(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 5.0},
+		Rules: []RuleConfig{
+			{
+				Name:             "databases",
+				Match:            RuleSelector{ExecutablePaths: []string{"/usr/sbin/mysqld"}},
+				MetricThresholds: map[string]float64{"process.cpu.utilization": 40.0},
+			},
+			{
+				Name:             "processes",
+				Match:            RuleSelector{ResourceType: resourceTypeProcess},
+				MetricThresholds: map[string]float64{"process.cpu.utilization": 10.0},
+			},
+		},
+	})
+
+	md := createTestProcessMetrics("/usr/sbin/mysqld", 1, 20.0)
+	addProcessToMetrics(md, "/usr/bin/sidecar", 2, 20.0)
+	addProcessToMetrics(md, "/usr/bin/idle", 3, 7.0)
+
+	cfg, rule := p.configFor(md.ResourceMetrics().At(0).Resource())
+	assert.Equal(t, "databases", rule)
+	assert.Equal(t, 40.0, cfg.MetricThresholds["process.cpu.utilization"])
+
+	filtered, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	// The database is below its own threshold, the sidecar exceeds the generic process rule
+	var included []string
+	for i := 0; i < filtered.ResourceMetrics().Len(); i++ {
+		if v, ok := filtered.ResourceMetrics().At(i).Resource().Attributes().Get("process.executable.path"); ok {
+			included = append(included, v.Str())
+		}
+	}
+	assert.Equal(t, []string{"/usr/bin/sidecar"}, included)
+
+	for _, te := range p.trackedEntities {
+		assert.Equal(t, "processes", te.Rule)
+	}
+}
+
+func TestRuleRetentionAppliesToEntity(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 5.0},
+		RetentionMinutes: 30,
+		Rules: []RuleConfig{
+			{Name: "short", Match: RuleSelector{ExecutablePaths: []string{"/usr/bin/batch"}}, RetentionMinutes: 5},
+		},
+	})
+
+	exceeded := time.Now().Add(-10 * time.Minute)
+	p.trackedEntities["short"] = &trackedEntity{Identity: "short", Rule: "short", LastExceeded: exceeded}
+	p.trackedEntities["default"] = &trackedEntity{Identity: "default", LastExceeded: exceeded}
+	p.trackedEntities["removed"] = &trackedEntity{Identity: "removed", Rule: "no-longer-configured", LastExceeded: exceeded}
+
+	assert.False(t, inStandardRetention(p.trackedEntities["short"], p.configForEntity(p.trackedEntities["short"])))
+	assert.True(t, inStandardRetention(p.trackedEntities["default"], p.configForEntity(p.trackedEntities["default"])))
+
+	p.cleanupExpiredEntities()
+	assert.NotContains(t, p.trackedEntities, "short")
+	assert.Contains(t, p.trackedEntities, "default")
+	assert.Contains(t, p.trackedEntities, "removed", "entities of removed rules fall back to the top-level retention")
+}
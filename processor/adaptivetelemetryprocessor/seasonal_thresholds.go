@@ -0,0 +1,251 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// seasonalDayLayout formats the local date a daily summary belongs to
+const seasonalDayLayout = "2006-01-02"
+
+// seasonalProfile is what an entity normally reaches per metric and time bucket, summarized per day.
+// Profiles are kept by the processor independently of tracked entities, so learning survives
+// retention eviction and covers resources that are never included. Their number is capped by
+// max_tracked_entities.
+type seasonalProfile struct {
+	LastSeen time.Time `json:"last_seen"`
+	// Metrics maps metric name to bucket to daily summaries, oldest first
+	Metrics map[string]map[int][]seasonalDay `json:"metrics"`
+}
+
+// seasonalDay summarizes the samples of one time bucket on one day
+type seasonalDay struct {
+	Day   string  `json:"day"`
+	Max   float64 `json:"max"`
+	Sum   float64 `json:"sum"`
+	Count int     `json:"count"`
+}
+
+// seasonalBucket returns the time bucket a sample taken at t belongs to:
+// the hour of day (0-23) or the hour of week (0-167, starting Sunday 00:00).
+func seasonalBucket(seasonality string, t time.Time) int {
+	if seasonality == seasonalityHourOfWeek {
+		return int(t.Weekday())*24 + t.Hour()
+	}
+	return t.Hour()
+}
+
+// seasonalEnabled reports whether seasonal profiles are learned for resources evaluated with cfg
+func (p *processorImp) seasonalEnabled(cfg *Config) bool {
+	return p.dynamicThresholdsApply(cfg) && p.config.DynamicThresholdMode == dynamicThresholdModeSeasonal
+}
+
+// getSeasonalWindowSize returns the number of daily summaries kept per bucket, with default
+func (p *processorImp) getSeasonalWindowSize() int {
+	if p.config.SeasonalWindowSize > 0 {
+		return p.config.SeasonalWindowSize
+	}
+	return defaultSeasonalWindowSize
+}
+
+// getSeasonalMinSamples returns the past days a bucket needs before its threshold is used, with default
+func (p *processorImp) getSeasonalMinSamples() int {
+	if p.config.SeasonalMinSamples > 0 {
+		return p.config.SeasonalMinSamples
+	}
+	return defaultSeasonalMinSamples
+}
+
+// seasonalProfileTTL is how long a profile is kept without samples: the span its daily summaries cover
+func (p *processorImp) seasonalProfileTTL() time.Duration {
+	span := time.Duration(p.getSeasonalWindowSize()) * 24 * time.Hour
+	if p.config.Seasonality == seasonalityHourOfWeek {
+		span *= 7
+	}
+	return span
+}
+
+// recordSeasonalSamples folds the current values into the entity's summary for the current bucket and day.
+// Callers hold p.mu and record after evaluation, so the current value never influences its own threshold.
+func (p *processorImp) recordSeasonalSamples(id string, values map[string]float64, now time.Time, cfg *Config) {
+	if !p.seasonalEnabled(cfg) {
+		return
+	}
+
+	if p.seasonalProfiles == nil {
+		p.seasonalProfiles = make(map[string]*seasonalProfile)
+	}
+	profile := p.seasonalProfiles[id]
+	if profile == nil {
+		profile = &seasonalProfile{Metrics: make(map[string]map[int][]seasonalDay)}
+		p.seasonalProfiles[id] = profile
+	}
+	profile.LastSeen = now
+
+	bucket := seasonalBucket(p.config.Seasonality, now)
+	day := now.Format(seasonalDayLayout)
+	windowSize := p.getSeasonalWindowSize()
+
+	for metricName, value := range values {
+		if _, has := p.config.MetricThresholds[metricName]; !has || !isValidMetricValue(value) {
+			continue
+		}
+
+		buckets := profile.Metrics[metricName]
+		if buckets == nil {
+			buckets = make(map[int][]seasonalDay)
+			profile.Metrics[metricName] = buckets
+		}
+
+		days := buckets[bucket]
+		if n := len(days); n > 0 && days[n-1].Day == day {
+			days[n-1].Max = math.Max(days[n-1].Max, value)
+			days[n-1].Sum += value
+			days[n-1].Count++
+		} else {
+			days = append(days, seasonalDay{Day: day, Max: value, Sum: value, Count: 1})
+			if len(days) > windowSize {
+				days = days[len(days)-windowSize:]
+			}
+		}
+		buckets[bucket] = days
+	}
+}
+
+// seasonalThreshold returns the threshold learned for the entity, metric and current time bucket from
+// the daily peaks of previous days. Returns false until the bucket has seasonal_min_samples past days.
+func (p *processorImp) seasonalThreshold(id, metricName string, now time.Time, cfg *Config) (float64, bool) {
+	if !p.seasonalEnabled(cfg) {
+		return 0, false
+	}
+	profile := p.seasonalProfiles[id]
+	if profile == nil {
+		return 0, false
+	}
+
+	today := now.Format(seasonalDayLayout)
+	var peaks []float64
+	for _, d := range profile.Metrics[metricName][seasonalBucket(p.config.Seasonality, now)] {
+		if d.Day != today {
+			peaks = append(peaks, d.Max)
+		}
+	}
+	if len(peaks) < p.getSeasonalMinSamples() {
+		return 0, false
+	}
+
+	target := p.calculateTarget(p.config.MetricThresholds[metricName], peaks)
+	return p.applyThresholdConstraints(metricName, target), true
+}
+
+// dynamicThresholdFor returns the dynamic threshold of metric for the entity: the threshold of its current
+// seasonal bucket once the bucket has seasonal_min_samples past days, the global dynamic threshold until then,
+// and whether the metric has one. The string is the threshold type shown in threshold_details. Callers hold p.mu.
+func (p *processorImp) dynamicThresholdFor(id, metricName string, now time.Time, cfg *Config) (float64, string, bool) {
+	global, ok := p.dynamicCustomThresholds[metricName]
+	if !ok {
+		return 0, "", false
+	}
+	if seasonal, ok := p.seasonalThreshold(id, metricName, now, cfg); ok {
+		return seasonal, "seasonal", true
+	}
+	return global, "dynamic", true
+}
+
+// seasonallyExpected reports whether value is within what the entity normally reaches at this time,
+// so an anomaly on it is expected (for example a nightly batch job starting at 2am) and not reported
+func (p *processorImp) seasonallyExpected(id, metricName string, value float64, now time.Time, cfg *Config) bool {
+	threshold, ok := p.seasonalThreshold(id, metricName, now, cfg)
+	if !ok || value >= threshold {
+		return false
+	}
+	p.logger.Debug("Anomaly expected for this time bucket",
+		zap.String("entity_id", id),
+		zap.String("metric", metricName),
+		zap.Float64("value", value),
+		zap.Float64("seasonal_threshold", threshold))
+	return true
+}
+
+// cleanupSeasonalProfiles drops profiles of entities that have not been seen for the whole window
+func (p *processorImp) cleanupSeasonalProfiles(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	expiry := now.Add(-p.seasonalProfileTTL())
+	for id, profile := range p.seasonalProfiles {
+		if profile.LastSeen.Before(expiry) {
+			delete(p.seasonalProfiles, id)
+		}
+	}
+}
+
+// enforceSeasonalProfileBudget evicts seasonal profiles beyond maxProfiles: those of untracked entities first,
+// then the least recently seen. Profiles are learned for every evaluated entity, so without the cap they would
+// grow with process churn until they expire. Callers hold p.mu.
+func (p *processorImp) enforceSeasonalProfileBudget(maxProfiles int) {
+	excess := len(p.seasonalProfiles) - maxProfiles
+	if excess <= 0 {
+		return
+	}
+
+	ids := slices.Collect(maps.Keys(p.seasonalProfiles))
+	slices.SortFunc(ids, func(a, b string) int {
+		_, aTracked := p.trackedEntities[a]
+		_, bTracked := p.trackedEntities[b]
+		if aTracked != bTracked {
+			if bTracked {
+				return -1
+			}
+			return 1
+		}
+		return p.seasonalProfiles[a].LastSeen.Compare(p.seasonalProfiles[b].LastSeen)
+	})
+	for _, id := range ids[:excess] {
+		delete(p.seasonalProfiles, id)
+	}
+
+	p.logger.Debug("Evicted seasonal profiles over the budget",
+		zap.Int("evicted_count", excess),
+		zap.Int("max_tracked_entities", maxProfiles))
+}
+
+// calculateTarget computes the target threshold from observed values.
+// With dynamic_threshold_percentile set the target is that percentile of the values,
+// otherwise it is the mean scaled up by genericScalingFactor. The static threshold is the floor.
+func (p *processorImp) calculateTarget(base float64, values []float64) float64 {
+	var target float64
+	if p.config.DynamicThresholdPercentile > 0 {
+		target = calculatePercentile(values, p.config.DynamicThresholdPercentile)
+	} else {
+		target = calculateAverage(values) * (1 + genericScalingFactor)
+	}
+	return math.Max(base, target)
+}
+
+// calculatePercentile returns the pth percentile (0-100] of values using linear interpolation
+func calculatePercentile(values []float64, percentile float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := percentile / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,304 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap/zaptest"
+)
+
+// withSeasonalThresholds learns thresholds by entity and time bucket
+func withSeasonalThresholds(cfg *Config) {
+	cfg.EnableDynamicThresholds = true
+	cfg.DynamicThresholdMode = dynamicThresholdModeSeasonal
+}
+
+// recordDays records one sample per day at the given hour, for the days before now, oldest first
+func recordDays(p *processorImp, id string, now time.Time, values ...float64) {
+	for i, v := range values {
+		at := now.AddDate(0, 0, i-len(values))
+		p.recordSeasonalSamples(id, map[string]float64{"process.cpu.utilization": v}, at, p.config)
+	}
+}
+
+func TestSeasonalBucket(t *testing.T) {
+	// Wednesday 02:30
+	ts := time.Date(2024, 1, 3, 2, 30, 0, 0, time.Local)
+
+	assert.Equal(t, 2, seasonalBucket(seasonalityHourOfDay, ts))
+	assert.Equal(t, 3*24+2, seasonalBucket(seasonalityHourOfWeek, ts))
+}
+
+func TestCalculatePercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+
+	assert.InDelta(t, 3.0, calculatePercentile(values, 50), 0.001)
+	assert.InDelta(t, 4.6, calculatePercentile(values, 90), 0.001)
+	assert.InDelta(t, 5.0, calculatePercentile(values, 100), 0.001)
+	assert.Equal(t, 0.0, calculatePercentile(nil, 95))
+	// Input is not reordered
+	assert.Equal(t, []float64{5, 1, 4, 2, 3}, values)
+}
+
+func TestRecordSeasonalSamplesDailySummaries(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds:   map[string]float64{"process.cpu.utilization": 10.0},
+		SeasonalWindowSize: 3,
+	}, withSeasonalThresholds)
+	at2am := time.Date(2024, 1, 3, 2, 0, 0, 0, time.Local)
+
+	// Every batch within the same bucket and day folds into one summary
+	for i, v := range []float64{3, 9, 6} {
+		p.recordSeasonalSamples("entity", map[string]float64{
+			"process.cpu.utilization": v,
+			"unconfigured.metric":     1.0,
+		}, at2am.Add(time.Duration(i)*time.Minute), p.config)
+	}
+	days := p.seasonalProfiles["entity"].Metrics["process.cpu.utilization"][2]
+	assert.Equal(t, []seasonalDay{{Day: "2024-01-03", Max: 9, Sum: 18, Count: 3}}, days)
+	assert.NotContains(t, p.seasonalProfiles["entity"].Metrics, "unconfigured.metric")
+
+	// One summary per day, capped at seasonal_window_size days
+	for day := 1; day <= 4; day++ {
+		p.recordSeasonalSamples("entity", map[string]float64{"process.cpu.utilization": float64(day)}, at2am.AddDate(0, 0, day), p.config)
+	}
+	days = p.seasonalProfiles["entity"].Metrics["process.cpu.utilization"][2]
+	require.Len(t, days, 3)
+	assert.Equal(t, "2024-01-05", days[0].Day)
+	assert.Equal(t, "2024-01-07", days[2].Day)
+	assert.Equal(t, at2am.AddDate(0, 0, 4), p.seasonalProfiles["entity"].LastSeen)
+}
+
+func TestSeasonalThresholdLearnsFromPreviousDays(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds:   map[string]float64{"process.cpu.utilization": 10.0},
+		SeasonalMinSamples: 3,
+	}, withSeasonalThresholds)
+	now := time.Date(2024, 1, 10, 2, 0, 0, 0, time.Local)
+
+	recordDays(p, "entity", now, 80, 80)
+	_, ok := p.seasonalThreshold("entity", "process.cpu.utilization", now, p.config)
+	assert.False(t, ok, "two past days are not enough")
+
+	// Samples from today never count towards today's threshold
+	for i := 0; i < 10; i++ {
+		p.recordSeasonalSamples("entity", map[string]float64{"process.cpu.utilization": 80.0}, now, p.config)
+	}
+	_, ok = p.seasonalThreshold("entity", "process.cpu.utilization", now, p.config)
+	assert.False(t, ok)
+
+	// Tomorrow, three past days are known
+	threshold, ok := p.seasonalThreshold("entity", "process.cpu.utilization", now.AddDate(0, 0, 1), p.config)
+	require.True(t, ok)
+	assert.InDelta(t, 96.0, threshold, 0.001) // 80 * (1 + genericScalingFactor)
+
+	// Unknown entities and other buckets have no seasonal threshold
+	_, ok = p.seasonalThreshold("other", "process.cpu.utilization", now.AddDate(0, 0, 1), p.config)
+	assert.False(t, ok)
+	_, ok = p.seasonalThreshold("entity", "process.cpu.utilization", now.AddDate(0, 0, 1).Add(time.Hour), p.config)
+	assert.False(t, ok)
+}
+
+func TestSeasonalThresholdNightlyJob(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds:           map[string]float64{"process.cpu.utilization": 10.0},
+		MaxThresholds:              map[string]float64{"process.cpu.utilization": 200.0},
+		SeasonalMinSamples:         3,
+		DynamicThresholdPercentile: 95,
+	}, withSeasonalThresholds)
+	at2am := time.Date(2024, 1, 10, 2, 0, 0, 0, time.Local)
+	atNoon := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+
+	// The job is busy every night at 2am and idle at noon
+	recordDays(p, "batch-job", at2am, 70, 80, 90, 85)
+	recordDays(p, "batch-job", atNoon, 1, 1, 1, 1)
+
+	// At 2am, 85% is expected for this entity
+	threshold, _ := p.seasonalThreshold("batch-job", "process.cpu.utilization", at2am, p.config)
+	assert.Greater(t, threshold, 85.0)
+	assert.True(t, p.seasonallyExpected("batch-job", "process.cpu.utilization", 85.0, at2am, p.config))
+
+	// At noon the static threshold is the floor, so the same load stands out
+	threshold, _ = p.seasonalThreshold("batch-job", "process.cpu.utilization", atNoon, p.config)
+	assert.Equal(t, 10.0, threshold)
+	assert.False(t, p.seasonallyExpected("batch-job", "process.cpu.utilization", 85.0, atNoon, p.config))
+}
+
+func TestSeasonalThresholdIsEntityDynamicThreshold(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds:   map[string]float64{"process.cpu.utilization": 10.0},
+		SeasonalMinSamples: 3,
+	}, withSeasonalThresholds)
+	now := time.Now()
+	values := map[string]float64{"process.cpu.utilization": 55.0}
+
+	// 55% is normal for a steady heavy consumer at this time, below its seasonal threshold of 60
+	heavy := &trackedEntity{Identity: "heavy"}
+	recordDays(p, heavy.Identity, now, 50, 50, 50)
+	threshold, thresholdType, ok := p.dynamicThresholdFor(heavy.Identity, "process.cpu.utilization", now, p.config)
+	require.True(t, ok)
+	assert.Equal(t, "seasonal", thresholdType)
+	assert.InDelta(t, 60.0, threshold, 0.001)
+	assert.False(t, p.checkDynamicThresholds(pcommon.NewResource(), heavy.Identity, heavy, values, p.config))
+
+	// Until its bucket has enough past days, an entity is evaluated against the global dynamic threshold
+	young := &trackedEntity{Identity: "young"}
+	recordDays(p, young.Identity, now, 50, 50)
+	threshold, thresholdType, ok = p.dynamicThresholdFor(young.Identity, "process.cpu.utilization", now, p.config)
+	require.True(t, ok)
+	assert.Equal(t, "dynamic", thresholdType)
+	assert.Equal(t, p.dynamicCustomThresholds["process.cpu.utilization"], threshold)
+	assert.True(t, p.checkDynamicThresholds(pcommon.NewResource(), young.Identity, young, values, p.config))
+
+	_, _, ok = p.dynamicThresholdFor(heavy.Identity, "process.memory.usage", now, p.config)
+	assert.False(t, ok, "no dynamic threshold without a static one")
+}
+
+func TestSeasonalProfilesHeldToEntityBudget(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds:   map[string]float64{"process.cpu.utilization": 10.0},
+		MaxTrackedEntities: 2,
+	}, withSeasonalThresholds)
+	now := time.Now()
+	p.trackedEntities["tracked"] = &trackedEntity{Identity: "tracked", LastSeen: now}
+	for i, id := range []string{"tracked", "old", "recent"} {
+		p.recordSeasonalSamples(id, map[string]float64{"process.cpu.utilization": 5.0}, now.Add(time.Duration(i)*time.Minute), p.config)
+	}
+	p.seasonalProfiles["tracked"].LastSeen = now.Add(-time.Hour)
+
+	// Untracked profiles go first, the least recently seen of them first
+	p.enforceTrackedEntityBudget(t.Context())
+	assert.Len(t, p.seasonalProfiles, 2)
+	assert.Contains(t, p.seasonalProfiles, "tracked")
+	assert.Contains(t, p.seasonalProfiles, "recent")
+
+	// Evaluating more entities than the budget keeps the profiles bounded
+	md := pmetric.NewMetrics()
+	for i := 0; i < 5; i++ {
+		addProcessToMetrics(md, "/usr/bin/app", 100+i, 5.0)
+	}
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Len(t, p.seasonalProfiles, 2)
+}
+
+func TestSeasonalProfilesOutliveRetention(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 10.0},
+		RetentionMinutes: 30,
+	}, withSeasonalThresholds)
+	now := time.Now()
+	p.trackedEntities["entity"] = &trackedEntity{Identity: "entity", LastExceeded: now.Add(-time.Hour)}
+	p.recordSeasonalSamples("entity", map[string]float64{"process.cpu.utilization": 5.0}, now, p.config)
+	p.recordSeasonalSamples("stale", map[string]float64{"process.cpu.utilization": 5.0}, now.AddDate(0, 0, -31), p.config)
+
+	p.cleanupExpiredEntities()
+	assert.NotContains(t, p.trackedEntities, "entity")
+	assert.Contains(t, p.seasonalProfiles, "entity")
+
+	// Profiles only expire once not seen for the whole window (30 days by default)
+	p.cleanupSeasonalProfiles(now)
+	assert.Contains(t, p.seasonalProfiles, "entity")
+	assert.NotContains(t, p.seasonalProfiles, "stale")
+}
+
+func TestSeasonalProfilesPersisted(t *testing.T) {
+	storage := newFileStorageForTesting(filepath.Join(t.TempDir(), "state.db"), "")
+	profiles := map[string]*seasonalProfile{
+		"entity": {
+			LastSeen: time.Date(2024, 1, 3, 2, 0, 0, 0, time.UTC),
+			Metrics: map[string]map[int][]seasonalDay{
+				"process.cpu.utilization": {2: {{Day: "2024-01-02", Max: 80, Sum: 150, Count: 2}}},
+			},
+		},
+	}
+
+	loaded, err := storage.LoadSeasonalProfiles()
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+
+	require.NoError(t, storage.SaveSeasonalProfiles(profiles))
+	loaded, err = storage.LoadSeasonalProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, profiles, loaded)
+
+	// Saving entities leaves the profiles in place
+	require.NoError(t, storage.Save(map[string]*trackedEntity{}))
+	loaded, err = storage.LoadSeasonalProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, profiles, loaded)
+}
+
+func TestSeasonalAnomalySuppressionEndToEnd(t *testing.T) {
+	cfg := &Config{
+		MetricThresholds:        map[string]float64{"process.cpu.utilization": 50.0},
+		EnableDynamicThresholds: true,
+		DynamicThresholdMode:    dynamicThresholdModeSeasonal,
+		SeasonalMinSamples:      3,
+		EnableAnomalyDetection:  true,
+		AnomalyHistorySize:      3,
+		AnomalyMinDataPoints:    3,
+		AnomalyChangeThreshold:  200.0,
+		RetentionMinutes:        30,
+		EnableStorage:           ptrBool(false),
+	}
+	p, err := newProcessor(telemetrySettings(zaptest.NewLogger(t)), cfg)
+	require.NoError(t, err)
+
+	batch := func(heavy, nightly, adhoc float64) pmetric.Metrics {
+		md := createTestProcessMetrics("/usr/bin/heavy", 1, heavy)
+		addProcessToMetrics(md, "/usr/bin/nightly", 2, nightly)
+		addProcessToMetrics(md, "/usr/bin/adhoc", 3, adhoc)
+		return md
+	}
+	ids := map[string]string{}
+	md := batch(0, 0, 0)
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		res := md.ResourceMetrics().At(i).Resource()
+		name, _ := res.Attributes().Get("process.executable.name")
+		ids[name.Str()] = buildResourceIdentity(res)
+	}
+
+	// On previous days at this hour the heavy process ran at 90% and the nightly job at about 30%
+	now := time.Now()
+	recordDays(p, ids["/usr/bin/heavy"], now, 90, 90, 90)
+	recordDays(p, ids["/usr/bin/nightly"], now, 28, 32, 30)
+
+	// All three start above the threshold and are tracked, then the nightly job and the ad hoc process go idle
+	for _, values := range [][3]float64{{90, 60, 60}, {90, 1, 1}, {90, 1, 1}, {90, 1, 1}} {
+		_, err = p.processMetrics(t.Context(), batch(values[0], values[1], values[2]))
+		require.NoError(t, err)
+	}
+	p.mu.Lock()
+	for _, te := range p.trackedEntities {
+		te.LastExceeded = now.Add(-time.Hour) // out of retention
+	}
+	p.mu.Unlock()
+
+	// Both jump to 30%: a 2900% change over their idle history
+	filtered, err := p.processMetrics(t.Context(), batch(90, 30, 30))
+	require.NoError(t, err)
+
+	var included []string
+	for i := 0; i < filtered.ResourceMetrics().Len(); i++ {
+		if v, ok := filtered.ResourceMetrics().At(i).Resource().Attributes().Get("process.executable.name"); ok {
+			included = append(included, v.Str())
+		}
+	}
+	// 90% is the heavy process's usual level at this hour, and the nightly job's rise is expected
+	assert.ElementsMatch(t, []string{"/usr/bin/adhoc"}, included)
+
+	// Every batch is learned, including the ones where the resource was excluded
+	today := p.seasonalProfiles[ids["/usr/bin/adhoc"]].Metrics["process.cpu.utilization"][seasonalBucket(cfg.Seasonality, time.Now())]
+	require.NotEmpty(t, today)
+	assert.Equal(t, 5, today[len(today)-1].Count)
+	assert.Equal(t, 60.0, today[len(today)-1].Max)
+}
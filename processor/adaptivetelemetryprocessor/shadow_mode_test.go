@@ -0,0 +1,88 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+
+	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/metadatatest"
+)
+
+func shadowTestMetrics() pmetric.Metrics {
+	md := createTestProcessMetrics("/usr/bin/busy", 1, 80.0)
+	addProcessToMetrics(md, "/usr/bin/idle", 2, 1.0)
+	addProcessToMetrics(md, "/usr/bin/other", 3, 2.0)
+	return md
+}
+
+func TestShadowModeForwardsAndAnnotates(t *testing.T) {
+	shadow, tel := newTelemetryTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 50.0},
+		Mode:             modeShadow,
+		AttributesMode:   attributesModeMap,
+	})
+	enforce, _ := newTelemetryTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 50.0},
+	})
+
+	result, err := shadow.processMetrics(t.Context(), shadowTestMetrics())
+	require.NoError(t, err)
+	enforced, err := enforce.processMetrics(t.Context(), shadowTestMetrics())
+	require.NoError(t, err)
+
+	// Every resource is forwarded, with the decision enforce mode applied
+	require.Equal(t, 3, result.ResourceMetrics().Len())
+	assert.Equal(t, 1, enforced.ResourceMetrics().Len())
+	decisions := map[string]string{}
+	for i := 0; i < result.ResourceMetrics().Len(); i++ {
+		attrs := result.ResourceMetrics().At(i).Resource().Attributes()
+		path, _ := attrs.Get("process.executable.path")
+		atp, ok := attrs.Get("process.atp")
+		require.True(t, ok)
+		decision, _ := atp.Map().Get("decision")
+		stage, _ := atp.Map().Get("stage")
+		decisions[path.Str()] = decision.Str() + "/" + stage.Str()
+		_, internal := attrs.Get(internalFilterStageAttributeKey)
+		assert.False(t, internal)
+	}
+	assert.Equal(t, map[string]string{
+		"/usr/bin/busy":  shadowDecisionKeep + "/" + stageStaticThreshold,
+		"/usr/bin/idle":  shadowDecisionDrop + "/" + stageFiltered,
+		"/usr/bin/other": shadowDecisionDrop + "/" + stageFiltered,
+	}, decisions)
+
+	// Shadow mode tracks the same entities as enforce mode, unlike debug_show_all_filter_stages
+	assert.Len(t, shadow.trackedEntities, len(enforce.trackedEntities))
+
+	metadatatest.AssertEqualProcessorAdaptivetelemetryOutgoingResources(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 3}}, metricdatatest.IgnoreTimestamp())
+	kept, incoming := 1.0, 3.0 // one of three resources kept
+	metadatatest.AssertEqualProcessorAdaptivetelemetryReductionRatio(t, tel,
+		[]metricdata.DataPoint[float64]{{Value: 1 - kept/incoming, Attributes: attribute.NewSet(attribute.String("mode", modeShadow))}},
+		metricdatatest.IgnoreTimestamp())
+}
+
+func TestShadowModeForwardsLogs(t *testing.T) {
+	proc := newSignalGatingTestProcessor(t)
+	proc.config.Mode = modeShadow
+
+	ld := plog.NewLogs()
+	putProcessAttributes(ld.ResourceLogs().AppendEmpty().Resource().Attributes(), "/usr/bin/unknown", 300)
+
+	sink := new(consumertest.LogsSink)
+	lp := &logsProcessor{sharedLifecycle: &sharedLifecycle{proc: proc}, nextConsumer: sink}
+	require.NoError(t, lp.ConsumeLogs(t.Context(), ld))
+
+	require.Len(t, sink.AllLogs(), 1)
+	assert.Equal(t, 1, sink.AllLogs()[0].ResourceLogs().Len())
+}
@@ -0,0 +1,104 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+)
+
+// sharedProcessors holds one processorImp per Config. The collector creates a separate processor
+// instance for every pipeline a component is used in, but all of them receive the same *Config,
+// so keying on it lets metrics, logs and traces pipelines evaluate against the same entity state.
+var sharedProcessors = &processorRegistry{entries: make(map[*Config]*registryEntry)}
+
+// registryEntry is a shared processor and the number of pipeline instances still using it.
+type registryEntry struct {
+	proc *processorImp
+	refs int
+}
+
+// processorRegistry tracks shared processors by their configuration.
+type processorRegistry struct {
+	mu      sync.Mutex
+	entries map[*Config]*registryEntry
+}
+
+// acquire returns the processor shared by cfg, creating it on first use, and takes a reference on it.
+func (r *processorRegistry) acquire(set processor.Settings, cfg *Config) (*processorImp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[cfg]; ok {
+		entry.refs++
+		return entry.proc, nil
+	}
+
+	proc, err := newProcessor(set.TelemetrySettings, cfg)
+	if err != nil {
+		return nil, err
+	}
+	proc.componentID = set.ID
+	r.entries[cfg] = &registryEntry{proc: proc, refs: 1}
+	return proc, nil
+}
+
+// release drops a reference on the processor shared by cfg.
+// Returns true when the caller held the last reference and the shared processor should be shut down.
+func (r *processorRegistry) release(cfg *Config) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[cfg]
+	if !ok {
+		return true
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		return false
+	}
+	delete(r.entries, cfg)
+	return true
+}
+
+// sharedLifecycle implements the component lifecycle for a pipeline-specific view over a shared processorImp.
+// The shared processor is only shut down (and its state persisted) once every pipeline using it has shut down.
+type sharedLifecycle struct {
+	proc         *processorImp
+	shutdownOnce sync.Once
+}
+
+// newSharedLifecycle acquires the processor shared by cfg.
+func newSharedLifecycle(set processor.Settings, cfg *Config) (*sharedLifecycle, error) {
+	proc, err := sharedProcessors.acquire(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &sharedLifecycle{proc: proc}, nil
+}
+
+// Start starts the shared processor
+func (s *sharedLifecycle) Start(ctx context.Context, host component.Host) error {
+	return s.proc.Start(ctx, host)
+}
+
+// Shutdown releases this pipeline's reference and shuts down the shared processor if it was the last one
+func (s *sharedLifecycle) Shutdown(ctx context.Context) error {
+	var err error
+	s.shutdownOnce.Do(func() {
+		if sharedProcessors.release(s.proc.config) {
+			err = s.proc.Shutdown(ctx)
+		}
+	})
+	return err
+}
+
+// Capabilities indicates that this processor mutates data
+func (*sharedLifecycle) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: true}
+}
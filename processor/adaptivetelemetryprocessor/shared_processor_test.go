@@ -0,0 +1,76 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/processor"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSharedProcessorAcrossSignals(t *testing.T) {
+	cfg := &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 5.0},
+		EnableStorage:    ptrBool(false),
+	}
+	settings := processor.Settings{
+		TelemetrySettings: telemetrySettings(zaptest.NewLogger(t)),
+	}
+
+	mp, err := createMetricsProcessor(t.Context(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	lp, err := createLogsProcessor(t.Context(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	tp, err := createTracesProcessor(t.Context(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+
+	// All pipelines built from the same config share one processorImp
+	shared := mp.(*metricsProcessor).proc
+	assert.Same(t, shared, lp.(*logsProcessor).proc)
+	assert.Same(t, shared, tp.(*tracesProcessor).proc)
+
+	// A different config gets its own processor
+	otherCfg := &Config{EnableStorage: ptrBool(false)}
+	other, err := createLogsProcessor(t.Context(), settings, otherCfg, consumertest.NewNop())
+	require.NoError(t, err)
+	assert.NotSame(t, shared, other.(*logsProcessor).proc)
+	require.NoError(t, other.Shutdown(t.Context()))
+
+	// The shared processor stays registered until every pipeline has shut down
+	require.NoError(t, mp.Shutdown(t.Context()))
+	require.NoError(t, mp.Shutdown(t.Context()), "repeated shutdown must not release twice")
+	require.NoError(t, lp.Shutdown(t.Context()))
+	sharedProcessors.mu.Lock()
+	_, registered := sharedProcessors.entries[cfg]
+	sharedProcessors.mu.Unlock()
+	assert.True(t, registered)
+
+	require.NoError(t, tp.Shutdown(t.Context()))
+	sharedProcessors.mu.Lock()
+	_, registered = sharedProcessors.entries[cfg]
+	sharedProcessors.mu.Unlock()
+	assert.False(t, registered)
+}
+
+func TestSharedProcessorInvalidConfig(t *testing.T) {
+	cfg := &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": -1.0},
+	}
+	settings := processor.Settings{
+		TelemetrySettings: telemetrySettings(zaptest.NewLogger(t)),
+	}
+
+	lp, err := createLogsProcessor(t.Context(), settings, cfg, consumertest.NewNop())
+	require.Error(t, err)
+	assert.Nil(t, lp)
+
+	sharedProcessors.mu.Lock()
+	_, registered := sharedProcessors.entries[cfg]
+	sharedProcessors.mu.Unlock()
+	assert.False(t, registered)
+}
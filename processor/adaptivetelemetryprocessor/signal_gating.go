@@ -0,0 +1,70 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+)
+
+// shouldForwardSignalResource decides whether logs or spans for a resource are forwarded.
+// Nothing is evaluated here: the decision reuses the tracked entity state built from metrics,
+// keyed by the same resource identity.
+//
+// Only process resources are gated. A process passes while it is "hot":
+// - resources matching an exclude selector or a resource drop condition never pass
+// - zombie processes, processes on the include lists and those a resource keep condition holds for always pass
+// - tracked entities pass while inside anomaly or standard retention
+// - anything else (untracked or expired) is dropped
+//
+// Returns whether the resource should be forwarded and the stage that allowed it.
+func (p *processorImp) shouldForwardSignalResource(resource pcommon.Resource) (bool, string) {
+	attrs := resource.Attributes()
+
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+
+	// Exclude selectors take priority, whatever the resource type
+	if p.isExplicitlyExcluded(attrs) || p.isDroppedByConditions(resource) {
+		return false, ""
+	}
+
+	// Non-process resources are not gated, matching default inclusion for metrics
+	if getResourceType(attrs) != resourceTypeProcess {
+		return true, stageDefaultInclusion
+	}
+
+	if isZombieProcess(attrs) {
+		return true, stageZombieProcess
+	}
+
+	if p.isExplicitlyIncluded(attrs) {
+		return true, stageIncludeList
+	}
+	if p.isKeptByConditions(resource) {
+		return true, stageKeepCondition
+	}
+
+	id := buildResourceIdentity(resource)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	te, exists := p.trackedEntities[id]
+	if !exists {
+		p.logger.Debug("Dropping signal resource: entity not tracked", zap.String("resource_id", id))
+		return false, ""
+	}
+
+	cfg := p.configForEntity(te)
+	if inAnomalyRetention(te, cfg) {
+		return true, stageAnomalyRetention
+	}
+	if inStandardRetention(te, cfg) {
+		return true, stageStandardRetention
+	}
+
+	p.logger.Debug("Dropping signal resource: entity outside retention", zap.String("resource_id", id))
+	return false, ""
+}
@@ -0,0 +1,178 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func newSignalGatingTestProcessor(t *testing.T) *processorImp {
+	return newTestProcessor(t, &Config{IncludeProcessList: []string{"/usr/sbin/nginx"}})
+}
+
+func putProcessAttributes(attrs pcommon.Map, execPath string, pid int64) {
+	attrs.PutStr("host.name", "testhost")
+	attrs.PutInt("process.pid", pid)
+	attrs.PutStr("process.executable.path", execPath)
+}
+
+func TestShouldForwardSignalResource(t *testing.T) {
+	proc := newSignalGatingTestProcessor(t)
+
+	// Build entity state the way the metrics pipeline would
+	md := createTestProcessMetrics("/usr/bin/busy", 100, 80.0)
+	addProcessToMetrics(md, "/usr/bin/idle", 200, 1.0)
+	_, err := proc.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		setup         func(pcommon.Resource)
+		expectForward bool
+		expectStage   string
+	}{
+		{
+			name: "Process exceeding threshold is within retention",
+			setup: func(r pcommon.Resource) {
+				putProcessAttributes(r.Attributes(), "/usr/bin/busy", 100)
+			},
+			expectForward: true,
+			expectStage:   stageStandardRetention,
+		},
+		{
+			name: "Idle process is dropped",
+			setup: func(r pcommon.Resource) {
+				putProcessAttributes(r.Attributes(), "/usr/bin/idle", 200)
+			},
+			expectForward: false,
+		},
+		{
+			name: "Unknown process is dropped",
+			setup: func(r pcommon.Resource) {
+				putProcessAttributes(r.Attributes(), "/usr/bin/unknown", 300)
+			},
+			expectForward: false,
+		},
+		{
+			name: "Include list process always passes",
+			setup: func(r pcommon.Resource) {
+				putProcessAttributes(r.Attributes(), "/usr/sbin/nginx", 400)
+			},
+			expectForward: true,
+			expectStage:   stageIncludeList,
+		},
+		{
+			name: "Zombie process always passes",
+			setup: func(r pcommon.Resource) {
+				putProcessAttributes(r.Attributes(), "/usr/bin/defunct", 500)
+				r.Attributes().PutStr("process.state", "Z")
+			},
+			expectForward: true,
+			expectStage:   stageZombieProcess,
+		},
+		{
+			name: "Non-process resource is not gated",
+			setup: func(r pcommon.Resource) {
+				r.Attributes().PutStr("service.name", "checkout")
+			},
+			expectForward: true,
+			expectStage:   stageDefaultInclusion,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := pcommon.NewResource()
+			tc.setup(res)
+
+			forward, stage := proc.shouldForwardSignalResource(res)
+			assert.Equal(t, tc.expectForward, forward)
+			assert.Equal(t, tc.expectStage, stage)
+		})
+	}
+}
+
+func TestShouldForwardSignalResourceRetentionExpiry(t *testing.T) {
+	proc := newSignalGatingTestProcessor(t)
+	proc.config.EnableAnomalyDetection = true
+
+	res := pcommon.NewResource()
+	putProcessAttributes(res.Attributes(), "/usr/bin/worker", 42)
+	id := buildResourceIdentity(res)
+
+	// Recent anomaly keeps the entity hot even without a threshold breach
+	proc.trackedEntities[id] = &trackedEntity{
+		Identity:            id,
+		LastAnomalyDetected: time.Now().Add(-time.Minute),
+	}
+	forward, stage := proc.shouldForwardSignalResource(res)
+	assert.True(t, forward)
+	assert.Equal(t, stageAnomalyRetention, stage)
+
+	// Both retention windows elapsed
+	proc.trackedEntities[id].LastAnomalyDetected = time.Now().Add(-2 * time.Hour)
+	proc.trackedEntities[id].LastExceeded = time.Now().Add(-2 * time.Hour)
+	forward, _ = proc.shouldForwardSignalResource(res)
+	assert.False(t, forward)
+}
+
+func TestConsumeLogsGatedByEntityState(t *testing.T) {
+	proc := newSignalGatingTestProcessor(t)
+	_, err := proc.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/busy", 100, 80.0))
+	require.NoError(t, err)
+
+	sink := new(consumertest.LogsSink)
+	lp := &logsProcessor{sharedLifecycle: &sharedLifecycle{proc: proc}, nextConsumer: sink}
+
+	ld := plog.NewLogs()
+	for _, pid := range []int64{100, 200} {
+		rl := ld.ResourceLogs().AppendEmpty()
+		putProcessAttributes(rl.Resource().Attributes(), "/usr/bin/busy", pid)
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log line")
+	}
+
+	require.NoError(t, lp.ConsumeLogs(t.Context(), ld))
+	require.Len(t, sink.AllLogs(), 1)
+	out := sink.AllLogs()[0]
+	require.Equal(t, 1, out.ResourceLogs().Len())
+	pid, _ := out.ResourceLogs().At(0).Resource().Attributes().Get("process.pid")
+	assert.Equal(t, int64(100), pid.Int())
+
+	// Batches where every resource is filtered are not forwarded
+	sink.Reset()
+	ld = plog.NewLogs()
+	putProcessAttributes(ld.ResourceLogs().AppendEmpty().Resource().Attributes(), "/usr/bin/idle", 200)
+	require.NoError(t, lp.ConsumeLogs(t.Context(), ld))
+	assert.Empty(t, sink.AllLogs())
+}
+
+func TestConsumeTracesGatedByEntityState(t *testing.T) {
+	proc := newSignalGatingTestProcessor(t)
+	_, err := proc.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/busy", 100, 80.0))
+	require.NoError(t, err)
+
+	sink := new(consumertest.TracesSink)
+	tp := &tracesProcessor{sharedLifecycle: &sharedLifecycle{proc: proc}, nextConsumer: sink}
+
+	td := ptrace.NewTraces()
+	for _, pid := range []int64{100, 200} {
+		rs := td.ResourceSpans().AppendEmpty()
+		putProcessAttributes(rs.Resource().Attributes(), "/usr/bin/busy", pid)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	}
+	svc := td.ResourceSpans().AppendEmpty()
+	svc.Resource().Attributes().PutStr("service.name", "checkout")
+
+	require.NoError(t, tp.ConsumeTraces(t.Context(), td))
+	require.Len(t, sink.AllTraces(), 1)
+	assert.Equal(t, 2, sink.AllTraces()[0].ResourceSpans().Len(), "hot process and non-process resource should pass")
+}
@@ -14,11 +14,45 @@ import (
 	"sync"
 )
 
+// storageFormatVersion is the version of the envelope written by fileStorage.
+// Bump it and add a migration step in decodeStorageFile when the persisted schema changes.
+const storageFormatVersion = 1
+
+// storageEnvelope is the on-disk format of the state file
+type storageEnvelope struct {
+	Version  int                       `json:"version"`
+	Entities map[string]*trackedEntity `json:"entities"`
+}
+
+// seasonalProfilesFileSuffix is appended to the state file path for the seasonal profiles file
+const seasonalProfilesFileSuffix = ".seasonal"
+
+// processorStateFileSuffix is appended to the state file path for the processor state file
+const processorStateFileSuffix = ".processor"
+
+// seasonalProfilesEnvelope is the on-disk format of the seasonal profiles file
+type seasonalProfilesEnvelope struct {
+	Version  int                         `json:"version"`
+	Profiles map[string]*seasonalProfile `json:"profiles"`
+}
+
 type EntityStateStorage interface {
 	Load() (map[string]*trackedEntity, error)
 
 	Save(map[string]*trackedEntity) error
 
+	// LoadSeasonalProfiles and SaveSeasonalProfiles persist seasonal profiles separately from
+	// entities, since profiles outlive the retention of the entity they belong to
+	LoadSeasonalProfiles() (map[string]*seasonalProfile, error)
+
+	SaveSeasonalProfiles(map[string]*seasonalProfile) error
+
+	// LoadProcessorState and SaveProcessorState persist processor-level state, such as the learned
+	// dynamic thresholds. LoadProcessorState returns nil when no state was persisted yet.
+	LoadProcessorState() (*processorState, error)
+
+	SaveProcessorState(*processorState) error
+
 	Close() error
 }
 
@@ -60,12 +94,50 @@ func (s *fileStorage) Load() (map[string]*trackedEntity, error) {
 		return nil, err
 	}
 
-	var entities map[string]*trackedEntity
-	if err := json.Unmarshal(data, &entities); err != nil {
+	return decodeStorageFile(data)
+}
+
+// decodeStorageFile decodes the state file, migrating older formats to the current one.
+// Files written before the envelope was introduced hold a bare entity map and are treated as version 0.
+func decodeStorageFile(data []byte) (map[string]*trackedEntity, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
 		return nil, err
 	}
 
-	return entities, nil
+	version := 0
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			// Not an envelope: a legacy entity map can't have a numeric "version" entry
+			version = 0
+		} else if _, hasEntities := fields["entities"]; !hasEntities {
+			version = 0
+		}
+	}
+
+	switch {
+	case version == 0:
+		// Legacy format: the whole file is the entity map
+		var entities map[string]*trackedEntity
+		if err := json.Unmarshal(data, &entities); err != nil {
+			return nil, fmt.Errorf("failed to decode legacy state file: %w", err)
+		}
+		if entities == nil {
+			entities = make(map[string]*trackedEntity)
+		}
+		return entities, nil
+	case version > storageFormatVersion:
+		return nil, fmt.Errorf("state file version %d is newer than supported version %d", version, storageFormatVersion)
+	}
+
+	var envelope storageEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode state file version %d: %w", version, err)
+	}
+	if envelope.Entities == nil {
+		envelope.Entities = make(map[string]*trackedEntity)
+	}
+	return envelope.Entities, nil
 }
 
 func (s *fileStorage) Save(entities map[string]*trackedEntity) error {
@@ -86,12 +158,158 @@ func (s *fileStorage) Save(entities map[string]*trackedEntity) error {
 		}
 	}
 
-	data, err := json.MarshalIndent(entities, "", "  ")
+	data, err := json.MarshalIndent(storageEnvelope{Version: storageFormatVersion, Entities: entities}, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filePath, data, 0o600)
+	return writeFileAtomic(s.filePath, data)
+}
+
+// writeFileAtomic replaces the file at path with data so that a crash leaves either the old or the new
+// content, never a truncated file. The data is written to a temp file in the same directory, synced
+// and renamed over the target; the directory is then synced so the rename itself is durable.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if err := tmp.Chmod(0o600); err != nil && runtime.GOOS != "windows" {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	committed = true
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes directory metadata (the rename) to disk. Best effort: not supported on Windows.
+func syncDir(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+func (s *fileStorage) seasonalProfilesPath() string {
+	return s.filePath + seasonalProfilesFileSuffix
+}
+
+func (s *fileStorage) LoadSeasonalProfiles() (map[string]*seasonalProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.seasonalProfilesPath())
+	if os.IsNotExist(err) {
+		return make(map[string]*seasonalProfile), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope seasonalProfilesEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode seasonal profiles file: %w", err)
+	}
+	if envelope.Version > storageFormatVersion {
+		return nil, fmt.Errorf("seasonal profiles file version %d is newer than supported version %d", envelope.Version, storageFormatVersion)
+	}
+	if envelope.Profiles == nil {
+		envelope.Profiles = make(map[string]*seasonalProfile)
+	}
+	return envelope.Profiles, nil
+}
+
+func (s *fileStorage) SaveSeasonalProfiles(profiles map[string]*seasonalProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.seasonalProfilesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if !s.skipValidation {
+		if err := checkPathForSymlinks(path, s.allowedBaseDir); err != nil {
+			return fmt.Errorf("symlink validation failed before write: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(seasonalProfilesEnvelope{Version: storageFormatVersion, Profiles: profiles})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+func (s *fileStorage) processorStatePath() string {
+	return s.filePath + processorStateFileSuffix
+}
+
+func (s *fileStorage) LoadProcessorState() (*processorState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.processorStatePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeProcessorState(data)
+}
+
+func (s *fileStorage) SaveProcessorState(state *processorState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.processorStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if !s.skipValidation {
+		if err := checkPathForSymlinks(path, s.allowedBaseDir); err != nil {
+			return fmt.Errorf("symlink validation failed before write: %w", err)
+		}
+	}
+
+	data, err := encodeProcessorState(state)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
 }
 
 func (*fileStorage) Close() error {
@@ -172,7 +390,7 @@ func getDefaultStoragePath() string {
 
 // validateStoragePath validates that the storage path is secure and within the platform-specific allowed directory.
 // Security checks performed:
-// 1. Path must be under the allowed directory (Linux: /var/lib/nrdot-collector/, Windows: %LOCALAPPDATA%\nrdot-collector\)
+// 1. Path must be a file under the allowed directory (Linux: /var/lib/nrdot-collector/, Windows: %LOCALAPPDATA%\nrdot-collector\)
 // 2. No component in the path can be a symlink (prevents redirection attacks)
 // 3. No component in the path can be a Windows reparse point/junction (prevents redirection attacks on Windows)
 // 4. Path traversal is prevented (no .. escapes)
@@ -183,11 +401,31 @@ func getDefaultStoragePath() string {
 //
 // Returns an error if validation fails, nil otherwise.
 func validateStoragePath(storagePath string, _ []string) error {
+	if err := validateStoragePathSyntax(storagePath); err != nil {
+		return err
+	}
+
+	// Check for symlinks in the entire path
+	// We need to check each component from /var/lib/nrdot-collector/ onwards
+	if err := checkPathForSymlinks(filepath.Clean(storagePath), getAllowedStorageDirectory()); err != nil {
+		return fmt.Errorf("symlink detected in storage path: %w", err)
+	}
+
+	return nil
+}
+
+// validateStoragePathSyntax performs checks 1 and 4 of validateStoragePath without touching the filesystem,
+// so it is safe to use during config validation. Symlinks are checked when the processor is created
+// and again before every write.
+func validateStoragePathSyntax(storagePath string) error {
 	if storagePath == "" {
 		return errors.New("storage_path cannot be empty")
 	}
 
 	allowedDir := getAllowedStorageDirectory()
+	if allowedDir == "" {
+		return errors.New("storage_path cannot be used: no writable storage directory is available on this platform")
+	}
 
 	// Clean the path to resolve . and ..
 	cleanPath := filepath.Clean(storagePath)
@@ -197,15 +435,14 @@ func validateStoragePath(storagePath string, _ []string) error {
 		return fmt.Errorf("storage_path must be an absolute path under %s, got relative path: %q", allowedDir, storagePath)
 	}
 
-	// Check if path is under the allowed directory
-	if !strings.HasPrefix(cleanPath+string(filepath.Separator), allowedDir) && cleanPath != strings.TrimSuffix(allowedDir, string(filepath.Separator)) {
-		return fmt.Errorf("storage_path must be under %s, got: %q", allowedDir, cleanPath)
+	// The allowed directory itself can't hold the state file
+	if cleanPath == filepath.Clean(allowedDir) {
+		return fmt.Errorf("storage_path must be a file under %s, got the directory itself", allowedDir)
 	}
 
-	// Check for symlinks in the entire path
-	// We need to check each component from /var/lib/nrdot-collector/ onwards
-	if err := checkPathForSymlinks(cleanPath, allowedDir); err != nil {
-		return fmt.Errorf("symlink detected in storage path: %w", err)
+	// Check if path is under the allowed directory
+	if !strings.HasPrefix(cleanPath+string(filepath.Separator), allowedDir) {
+		return fmt.Errorf("storage_path must be under %s, got: %q", allowedDir, cleanPath)
 	}
 
 	return nil
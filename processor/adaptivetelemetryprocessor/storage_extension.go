@@ -0,0 +1,250 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+)
+
+const (
+	// extensionStorageIndexKey holds the JSON list of entity identities currently persisted.
+	// Storage clients cannot enumerate keys, so the index is what Load uses to find entities.
+	extensionStorageIndexKey = "entity_index"
+	// extensionStorageKeyPrefix prefixes the per-entity keys
+	extensionStorageKeyPrefix = "entity/"
+	// extensionStorageSeasonalKey holds all seasonal profiles
+	extensionStorageSeasonalKey = "seasonal_profiles"
+	// extensionStorageProcessorKey holds the processor-level state
+	extensionStorageProcessorKey = "processor_state"
+)
+
+// extensionStorage persists tracked entities through a collector storage extension client,
+// one key per entity. Save only writes entities whose encoding changed since the last
+// successful Save (or Load), and deletes keys of entities that are no longer tracked.
+type extensionStorage struct {
+	client          storage.Client
+	mu              sync.Mutex
+	written         map[string][]byte // last persisted encoding per entity identity
+	writtenSeasonal []byte            // last persisted encoding of the seasonal profiles
+	writtenState    []byte            // last persisted encoding of the processor state
+}
+
+func newExtensionStorage(client storage.Client) *extensionStorage {
+	return &extensionStorage{
+		client:  client,
+		written: make(map[string][]byte),
+	}
+}
+
+// getStorageClient looks up the storage extension by ID on the host and creates a client for this processor
+func getStorageClient(ctx context.Context, host component.Host, storageID, componentID component.ID) (storage.Client, error) {
+	ext, found := host.GetExtensions()[storageID]
+	if !found {
+		return nil, fmt.Errorf("storage extension %q not found", storageID)
+	}
+
+	storageExt, ok := ext.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a storage extension", storageID)
+	}
+
+	return storageExt.GetClient(ctx, component.KindProcessor, componentID, "")
+}
+
+func entityStorageKey(id string) string {
+	return extensionStorageKeyPrefix + id
+}
+
+func (s *extensionStorage) Load() (map[string]*trackedEntity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	entities := make(map[string]*trackedEntity)
+
+	indexData, err := s.client.Get(ctx, extensionStorageIndexKey)
+	if err != nil {
+		return nil, err
+	}
+	if indexData == nil {
+		// Nothing persisted yet
+		return entities, nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal(indexData, &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode entity index: %w", err)
+	}
+
+	ops := make([]*storage.Operation, 0, len(ids))
+	for _, id := range ids {
+		ops = append(ops, storage.GetOperation(entityStorageKey(id)))
+	}
+	if err := s.client.Batch(ctx, ops...); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if op.Value == nil {
+			// Listed in the index but missing, e.g. a previous Save was interrupted
+			continue
+		}
+		var entity trackedEntity
+		if err := json.Unmarshal(op.Value, &entity); err != nil {
+			return nil, fmt.Errorf("failed to decode entity %q: %w", op.Key, err)
+		}
+		id := strings.TrimPrefix(op.Key, extensionStorageKeyPrefix)
+		entities[id] = &entity
+		s.written[id] = op.Value
+	}
+
+	return entities, nil
+}
+
+func (s *extensionStorage) Save(entities map[string]*trackedEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ops := make([]*storage.Operation, 0)
+	pending := make(map[string][]byte)
+	indexChanged := false
+
+	for id, entity := range entities {
+		data, err := json.Marshal(entity)
+		if err != nil {
+			return err
+		}
+		previous, persisted := s.written[id]
+		if !persisted {
+			indexChanged = true
+		}
+		if persisted && bytes.Equal(previous, data) {
+			continue
+		}
+		ops = append(ops, storage.SetOperation(entityStorageKey(id), data))
+		pending[id] = data
+	}
+
+	var removed []string
+	for id := range s.written {
+		if _, ok := entities[id]; !ok {
+			ops = append(ops, storage.DeleteOperation(entityStorageKey(id)))
+			removed = append(removed, id)
+			indexChanged = true
+		}
+	}
+
+	if indexChanged {
+		ids := make([]string, 0, len(entities))
+		for id := range entities {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		indexData, err := json.Marshal(ids)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, storage.SetOperation(extensionStorageIndexKey, indexData))
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if err := s.client.Batch(context.Background(), ops...); err != nil {
+		return err
+	}
+
+	for id, data := range pending {
+		s.written[id] = data
+	}
+	for _, id := range removed {
+		delete(s.written, id)
+	}
+	return nil
+}
+
+func (s *extensionStorage) LoadSeasonalProfiles() (map[string]*seasonalProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profiles := make(map[string]*seasonalProfile)
+	data, err := s.client.Get(context.Background(), extensionStorageSeasonalKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return profiles, nil
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to decode seasonal profiles: %w", err)
+	}
+	s.writtenSeasonal = data
+	return profiles, nil
+}
+
+func (s *extensionStorage) SaveSeasonalProfiles(profiles map[string]*seasonalProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(s.writtenSeasonal, data) {
+		return nil
+	}
+	if err := s.client.Set(context.Background(), extensionStorageSeasonalKey, data); err != nil {
+		return err
+	}
+	s.writtenSeasonal = data
+	return nil
+}
+
+func (s *extensionStorage) LoadProcessorState() (*processorState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.client.Get(context.Background(), extensionStorageProcessorKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	state, err := decodeProcessorState(data)
+	if err != nil {
+		return nil, err
+	}
+	s.writtenState = data
+	return state, nil
+}
+
+func (s *extensionStorage) SaveProcessorState(state *processorState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := encodeProcessorState(state)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(s.writtenState, data) {
+		return nil
+	}
+	if err := s.client.Set(context.Background(), extensionStorageProcessorKey, data); err != nil {
+		return err
+	}
+	s.writtenState = data
+	return nil
+}
+
+func (s *extensionStorage) Close() error {
+	return s.client.Close(context.Background())
+}
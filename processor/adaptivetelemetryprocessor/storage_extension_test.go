@@ -0,0 +1,220 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.uber.org/zap/zaptest"
+)
+
+// memoryStorageClient is an in-memory storage.Client that records the keys written
+type memoryStorageClient struct {
+	mu      sync.Mutex
+	data    map[string][]byte
+	setKeys []string
+	delKeys []string
+	closed  bool
+}
+
+func newMemoryStorageClient() *memoryStorageClient {
+	return &memoryStorageClient{data: make(map[string][]byte)}
+}
+
+func (c *memoryStorageClient) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	err := c.Batch(ctx, op)
+	return op.Value, err
+}
+
+func (c *memoryStorageClient) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, storage.SetOperation(key, value))
+}
+
+func (c *memoryStorageClient) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, storage.DeleteOperation(key))
+}
+
+func (c *memoryStorageClient) Batch(_ context.Context, ops ...*storage.Operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value = c.data[op.Key]
+		case storage.Set:
+			c.data[op.Key] = op.Value
+			c.setKeys = append(c.setKeys, op.Key)
+		case storage.Delete:
+			delete(c.data, op.Key)
+			c.delKeys = append(c.delKeys, op.Key)
+		}
+	}
+	return nil
+}
+
+func (c *memoryStorageClient) Close(context.Context) error {
+	c.closed = true
+	return nil
+}
+
+func (c *memoryStorageClient) resetRecorded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setKeys = nil
+	c.delKeys = nil
+}
+
+// memoryStorageExtension hands out a single shared memoryStorageClient
+type memoryStorageExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	client *memoryStorageClient
+}
+
+func (e *memoryStorageExtension) GetClient(context.Context, component.Kind, component.ID, string) (storage.Client, error) {
+	return e.client, nil
+}
+
+// storageHost is a component.Host exposing the given extensions
+type storageHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *storageHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+func TestExtensionStorageRoundTrip(t *testing.T) {
+	client := newMemoryStorageClient()
+	s := newExtensionStorage(client)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	entities := map[string]*trackedEntity{
+		"process.1@host": {
+			Identity:      "process.1@host",
+			FirstSeen:     now,
+			LastExceeded:  now,
+			CurrentValues: map[string]float64{"process.cpu.utilization": 10.0},
+			MaxValues:     map[string]float64{"process.cpu.utilization": 12.0},
+		},
+		"process.2@host": {
+			Identity:  "process.2@host",
+			FirstSeen: now,
+		},
+	}
+	require.NoError(t, s.Save(entities))
+
+	// A fresh storage instance on the same backend sees everything
+	loaded, err := newExtensionStorage(client).Load()
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, 10.0, loaded["process.1@host"].CurrentValues["process.cpu.utilization"])
+	assert.True(t, now.Equal(loaded["process.1@host"].LastExceeded))
+}
+
+func TestExtensionStorageWritesOnlyChangedEntities(t *testing.T) {
+	client := newMemoryStorageClient()
+	s := newExtensionStorage(client)
+
+	entities := map[string]*trackedEntity{
+		"a": {Identity: "a", CurrentValues: map[string]float64{"m": 1}},
+		"b": {Identity: "b", CurrentValues: map[string]float64{"m": 1}},
+	}
+	require.NoError(t, s.Save(entities))
+	assert.ElementsMatch(t, []string{"entity/a", "entity/b", extensionStorageIndexKey}, client.setKeys)
+
+	// Unchanged state writes nothing
+	client.resetRecorded()
+	require.NoError(t, s.Save(entities))
+	assert.Empty(t, client.setKeys)
+	assert.Empty(t, client.delKeys)
+
+	// Changing one entity only rewrites that key; the index is untouched
+	client.resetRecorded()
+	entities["a"].CurrentValues["m"] = 2
+	require.NoError(t, s.Save(entities))
+	assert.Equal(t, []string{"entity/a"}, client.setKeys)
+
+	// Removing an entity deletes its key and rewrites the index
+	client.resetRecorded()
+	delete(entities, "b")
+	require.NoError(t, s.Save(entities))
+	assert.Equal(t, []string{"entity/b"}, client.delKeys)
+	assert.Equal(t, []string{extensionStorageIndexKey}, client.setKeys)
+
+	loaded, err := newExtensionStorage(client).Load()
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, 2.0, loaded["a"].CurrentValues["m"])
+}
+
+func TestExtensionStorageLoadEmptyAndCorrupt(t *testing.T) {
+	client := newMemoryStorageClient()
+
+	loaded, err := newExtensionStorage(client).Load()
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+
+	client.data[extensionStorageIndexKey] = []byte("not json")
+	_, err = newExtensionStorage(client).Load()
+	require.Error(t, err)
+}
+
+func TestProcessorStartWithStorageExtension(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+	client := newMemoryStorageClient()
+	require.NoError(t, newExtensionStorage(client).Save(map[string]*trackedEntity{
+		"process.1@host": {Identity: "process.1@host", FirstSeen: time.Now()},
+	}))
+
+	cfg := &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 5.0},
+		StorageID:        &storageID,
+	}
+	settings := processortest.NewNopSettings(typ)
+	settings.Logger = zaptest.NewLogger(t)
+
+	mp, err := createMetricsProcessor(t.Context(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	proc := mp.(*metricsProcessor).proc
+	assert.Nil(t, proc.storage, "storage client is only created on Start")
+
+	host := &storageHost{
+		Host:       componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{storageID: &memoryStorageExtension{client: client}},
+	}
+	require.NoError(t, mp.Start(t.Context(), host))
+	assert.IsType(t, &extensionStorage{}, proc.storage)
+	assert.Contains(t, proc.trackedEntities, "process.1@host")
+
+	require.NoError(t, mp.Shutdown(t.Context()))
+	assert.True(t, client.closed)
+}
+
+func TestProcessorStartWithMissingStorageExtension(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+	cfg := &Config{StorageID: &storageID}
+	settings := processortest.NewNopSettings(typ)
+	settings.Logger = zaptest.NewLogger(t)
+
+	mp, err := createMetricsProcessor(t.Context(), settings, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+
+	err = mp.Start(t.Context(), componenttest.NewNopHost())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+	require.NoError(t, mp.Shutdown(t.Context()))
+}
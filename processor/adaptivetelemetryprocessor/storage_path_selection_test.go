@@ -223,6 +223,11 @@ func TestValidateStoragePath_WithDynamicAllowedDirectory(t *testing.T) {
 	err := validateStoragePath(validPath, nil)
 	assert.NoError(t, err, "Should accept path under actual allowed directory")
 
+	// The allowed directory itself is not a valid state file path
+	err = validateStoragePath(baseDir, nil)
+	assert.Error(t, err, "Should reject the allowed directory itself")
+	assert.Contains(t, err.Error(), "got the directory itself")
+
 	// Test that paths outside allowed directory are rejected
 	invalidPath := "/tmp/test.db"
 	if runtime.GOOS == "windows" {
@@ -4,8 +4,10 @@
 package adaptivetelemetryprocessor
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -122,3 +124,111 @@ func TestCreateDirectoryIfNotExists(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, info.IsDir())
 }
+
+func TestFileStorageAtomicSave(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "test.db")
+	storage := newFileStorageForTesting(filePath, "")
+
+	testEntities := map[string]*trackedEntity{
+		"entity1": {Identity: "entity1", CurrentValues: map[string]float64{"metric1": 1}},
+	}
+	require.NoError(t, storage.Save(testEntities))
+
+	testEntities["entity2"] = &trackedEntity{Identity: "entity2", CurrentValues: map[string]float64{"metric1": 2}}
+	require.NoError(t, storage.Save(testEntities))
+
+	// Only the state file should remain, no temp files
+	dirEntries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, 1)
+	assert.Equal(t, "test.db", dirEntries[0].Name())
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	// File is written in the versioned envelope
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	var envelope storageEnvelope
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, storageFormatVersion, envelope.Version)
+	assert.Len(t, envelope.Entities, 2)
+}
+
+func TestFileStorageLoadLegacyFormat(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+
+	// Files written before the envelope hold a bare entity map
+	legacy := `{"entity1": {"identity": "entity1", "current_values": {"metric1": 10.5}}}`
+	require.NoError(t, os.WriteFile(filePath, []byte(legacy), 0o600))
+
+	storage := newFileStorageForTesting(filePath, "")
+	entities, err := storage.Load()
+	require.NoError(t, err)
+	require.Contains(t, entities, "entity1")
+	assert.Equal(t, 10.5, entities["entity1"].CurrentValues["metric1"])
+
+	// The next save migrates the file to the current version
+	require.NoError(t, storage.Save(entities))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	var envelope storageEnvelope
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, storageFormatVersion, envelope.Version)
+	assert.Contains(t, envelope.Entities, "entity1")
+}
+
+func TestFileStorageLoadVersions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expectError bool
+		expectedLen int
+	}{
+		{
+			name:        "current version",
+			content:     `{"version": 1, "entities": {"a": {"identity": "a"}}}`,
+			expectedLen: 1,
+		},
+		{
+			name:        "current version without entities",
+			content:     `{"version": 1, "entities": null}`,
+			expectedLen: 0,
+		},
+		{
+			name:        "newer version is rejected",
+			content:     `{"version": 99, "entities": {}}`,
+			expectError: true,
+		},
+		{
+			name:        "legacy map with entity named version",
+			content:     `{"version": {"identity": "version"}}`,
+			expectedLen: 1,
+		},
+		{
+			name:        "truncated file",
+			content:     `{"version": 1, "enti`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "test.db")
+			require.NoError(t, os.WriteFile(filePath, []byte(tc.content), 0o600))
+
+			entities, err := newFileStorageForTesting(filePath, "").Load()
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, entities)
+			assert.Len(t, entities, tc.expectedLen)
+		})
+	}
+}
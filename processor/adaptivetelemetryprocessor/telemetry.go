@@ -0,0 +1,84 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/metadata"
+)
+
+// initTelemetry creates the internal telemetry instruments and registers the callbacks of the
+// asynchronous gauges, which read the processor state when the collector collects its own metrics
+func (p *processorImp) initTelemetry(set component.TelemetrySettings) error {
+	tb, err := metadata.NewTelemetryBuilder(set)
+	if err != nil {
+		return err
+	}
+	p.telemetry = tb
+
+	if err := tb.RegisterProcessorAdaptivetelemetryTrackedEntitiesCallback(func(_ context.Context, o metric.Int64Observer) error {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		o.Observe(int64(len(p.trackedEntities)))
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return tb.RegisterProcessorAdaptivetelemetryDynamicThresholdCallback(func(_ context.Context, o metric.Float64Observer) error {
+		if !p.dynamicThresholdsEnabled {
+			return nil
+		}
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		for metricName, threshold := range p.dynamicCustomThresholds {
+			o.Observe(threshold, metric.WithAttributes(attribute.String(telemetryAttrMetricName, metricName)))
+		}
+		return nil
+	})
+}
+
+// recordBatchTelemetry records the resources received and forwarded for a batch, the stages that included them,
+// and the reduction ratio from the kept resources, which differ from the forwarded ones in shadow mode
+func (p *processorImp) recordBatchTelemetry(ctx context.Context, incoming, outgoing, kept int, stageHits map[string]int) {
+	if p.telemetry == nil {
+		return
+	}
+	p.telemetry.ProcessorAdaptivetelemetryIncomingResources.Add(ctx, int64(incoming))
+	p.telemetry.ProcessorAdaptivetelemetryOutgoingResources.Add(ctx, int64(outgoing))
+	if incoming > 0 {
+		mode := p.config.Mode
+		if mode == "" {
+			mode = modeEnforce
+		}
+		p.telemetry.ProcessorAdaptivetelemetryReductionRatio.Record(ctx, 1-float64(kept)/float64(incoming),
+			metric.WithAttributes(attribute.String(telemetryAttrMode, mode)))
+	}
+	for stage, hits := range stageHits {
+		if stage == "" {
+			continue
+		}
+		p.telemetry.ProcessorAdaptivetelemetryStageHits.Add(ctx, int64(hits),
+			metric.WithAttributes(attribute.String(telemetryAttrStage, stage)))
+	}
+}
+
+// recordPersistenceTelemetry records the duration of a load or save started at start, and whether it failed
+func (p *processorImp) recordPersistenceTelemetry(operation string, start time.Time, err error) {
+	if p.telemetry == nil {
+		return
+	}
+	ctx := context.Background()
+	attrs := metric.WithAttributes(attribute.String(telemetryAttrOperation, operation))
+	p.telemetry.ProcessorAdaptivetelemetryPersistenceDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+	if err != nil {
+		p.telemetry.ProcessorAdaptivetelemetryPersistenceFailures.Add(ctx, 1, attrs)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor/internal/metadatatest"
+)
+
+// telemetrySettings returns no-op telemetry settings that log to logger
+func telemetrySettings(logger *zap.Logger) component.TelemetrySettings {
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = logger
+	return set
+}
+
+func newTelemetryTestProcessor(t *testing.T, cfg *Config, opts ...testProcessorOption) (*processorImp, *componenttest.Telemetry) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+
+	set := tel.NewTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	return newTestProcessorWithSettings(t, set, cfg, opts...), tel
+}
+
+func TestBatchTelemetry(t *testing.T) {
+	p, tel := newTelemetryTestProcessor(t, &Config{
+		MetricThresholds:        map[string]float64{"process.cpu.utilization": 10.0},
+		EnableDynamicThresholds: true,
+	})
+
+	md := createTestProcessMetrics("/usr/bin/busy", 1, 50.0)
+	addProcessToMetrics(md, "/usr/bin/idle", 2, 1.0)
+	addProcessToMetrics(md, "/usr/bin/other", 3, 2.0)
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	metadatatest.AssertEqualProcessorAdaptivetelemetryIncomingResources(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 3}}, metricdatatest.IgnoreTimestamp())
+	metadatatest.AssertEqualProcessorAdaptivetelemetryOutgoingResources(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 1}}, metricdatatest.IgnoreTimestamp())
+	kept, incoming := 1.0, 3.0 // one of three resources kept
+	metadatatest.AssertEqualProcessorAdaptivetelemetryReductionRatio(t, tel,
+		[]metricdata.DataPoint[float64]{{Value: 1 - kept/incoming, Attributes: attribute.NewSet(attribute.String("mode", modeEnforce))}},
+		metricdatatest.IgnoreTimestamp())
+	metadatatest.AssertEqualProcessorAdaptivetelemetryStageHits(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 1, Attributes: attribute.NewSet(attribute.String("stage", stageDynamicThreshold))}},
+		metricdatatest.IgnoreTimestamp())
+	metadatatest.AssertEqualProcessorAdaptivetelemetryTrackedEntities(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 1}}, metricdatatest.IgnoreTimestamp())
+	metadatatest.AssertEqualProcessorAdaptivetelemetryDynamicThreshold(t, tel,
+		[]metricdata.DataPoint[float64]{{Value: 10.0, Attributes: attribute.NewSet(attribute.String("metric_name", "process.cpu.utilization"))}},
+		metricdatatest.IgnoreTimestamp())
+}
+
+func TestPersistenceTelemetry(t *testing.T) {
+	p, tel := newTelemetryTestProcessor(t, &Config{
+		MetricThresholds: map[string]float64{"process.cpu.utilization": 10.0},
+	})
+	storage := &mockStorage{}
+	p.storage = storage
+	p.persistenceEnabled = true
+
+	require.NoError(t, p.loadTrackedEntities())
+	require.NoError(t, p.persistTrackedEntities())
+	storage.saveError = errors.New("disk full")
+	require.Error(t, p.persistTrackedEntities())
+
+	load := attribute.NewSet(attribute.String("operation", "load"))
+	save := attribute.NewSet(attribute.String("operation", "save"))
+	metadatatest.AssertEqualProcessorAdaptivetelemetryPersistenceDuration(t, tel,
+		[]metricdata.HistogramDataPoint[float64]{{Attributes: load, Count: 1}, {Attributes: save, Count: 2}},
+		metricdatatest.IgnoreTimestamp(), metricdatatest.IgnoreValue())
+	metadatatest.AssertEqualProcessorAdaptivetelemetryPersistenceFailures(t, tel,
+		[]metricdata.DataPoint[int64]{{Value: 1, Attributes: save}}, metricdatatest.IgnoreTimestamp())
+}
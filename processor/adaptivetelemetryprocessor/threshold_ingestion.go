@@ -21,15 +21,15 @@ func isValidThreshold(threshold float64) bool {
 	return isValidMetricValue(threshold) && threshold > 0
 }
 
-// determineEffectiveThreshold selects the appropriate threshold (dynamic or static)
-func (p *processorImp) determineEffectiveThreshold(metricName string, staticThreshold float64) (float64, string, bool) {
+// determineEffectiveThreshold selects the appropriate threshold of the entity (seasonal, dynamic or static)
+func (p *processorImp) determineEffectiveThreshold(id, metricName string, staticThreshold float64, cfg *Config) (float64, string, bool) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
 	// Try dynamic threshold first if enabled
-	if p.dynamicThresholdsEnabled && p.dynamicCustomThresholds != nil {
-		if dt, exists := p.dynamicCustomThresholds[metricName]; exists && isValidThreshold(dt) {
-			return dt, "dynamic", true
+	if p.dynamicThresholdsApply(cfg) && p.dynamicCustomThresholds != nil {
+		if dt, thresholdType, exists := p.dynamicThresholdFor(id, metricName, time.Now(), cfg); exists && isValidThreshold(dt) {
+			return dt, thresholdType, true
 		}
 	}
 
@@ -52,7 +52,7 @@ func addThresholdAttributes(thresholdsDetails map[string]any, metricName string,
 }
 
 // captureUsedMetricThresholds captures only metric thresholds that are actually evaluated
-func (p *processorImp) captureUsedMetricThresholds(resource pcommon.Resource, values map[string]float64) {
+func (p *processorImp) captureUsedMetricThresholds(resource pcommon.Resource, id string, values map[string]float64, cfg *Config) {
 	defer func() {
 		if r := recover(); r != nil && p.logger != nil {
 			p.logger.Error("panic in captureUsedMetricThresholds", zap.Any("error", r))
@@ -60,7 +60,7 @@ func (p *processorImp) captureUsedMetricThresholds(resource pcommon.Resource, va
 	}()
 
 	// Early returns for invalid states
-	if p == nil || p.config == nil || p.config.MetricThresholds == nil || len(values) == 0 {
+	if p == nil || cfg == nil || cfg.MetricThresholds == nil || len(values) == 0 {
 		return
 	}
 
@@ -72,12 +72,12 @@ func (p *processorImp) captureUsedMetricThresholds(resource pcommon.Resource, va
 			continue
 		}
 
-		staticThreshold, hasStatic := p.config.MetricThresholds[metricName]
+		staticThreshold, hasStatic := cfg.MetricThresholds[metricName]
 		if !hasStatic || !isValidMetricValue(metricValue) {
 			continue
 		}
 
-		effectiveThreshold, thresholdType, isValid := p.determineEffectiveThreshold(metricName, staticThreshold)
+		effectiveThreshold, thresholdType, isValid := p.determineEffectiveThreshold(id, metricName, staticThreshold, cfg)
 		if !isValid {
 			continue
 		}
@@ -87,7 +87,7 @@ func (p *processorImp) captureUsedMetricThresholds(resource pcommon.Resource, va
 	}
 
 	if len(thresholdsDetails) > 0 {
-		updateProcessATPAttribute(resource, "threshold_details", thresholdsDetails, p.logger)
+		putATPDetails(resource, "threshold_details", thresholdsDetails, p.logger)
 	}
 
 	if p.logger != nil && capturedCount > 0 {
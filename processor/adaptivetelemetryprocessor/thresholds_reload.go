@@ -0,0 +1,200 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// thresholdsFile is the content of thresholds_file. JSON is accepted as YAML.
+// Settings the file leaves out keep their value from the collector configuration.
+type thresholdsFile struct {
+	MetricThresholds   map[string]float64 `yaml:"metric_thresholds"`
+	Weights            map[string]float64 `yaml:"weights"`
+	CompositeThreshold *float64           `yaml:"composite_threshold"`
+	MinThresholds      map[string]float64 `yaml:"min_thresholds"`
+	MaxThresholds      map[string]float64 `yaml:"max_thresholds"`
+}
+
+// reloadableThresholds are the settings thresholds_file overrides
+type reloadableThresholds struct {
+	metricThresholds   map[string]float64
+	weights            map[string]float64
+	compositeThreshold float64
+	minThresholds      map[string]float64
+	maxThresholds      map[string]float64
+}
+
+// snapshotThresholds returns the reloadable settings of cfg
+func snapshotThresholds(cfg *Config) reloadableThresholds {
+	return reloadableThresholds{
+		metricThresholds:   cfg.MetricThresholds,
+		weights:            cfg.Weights,
+		compositeThreshold: cfg.CompositeThreshold,
+		minThresholds:      cfg.MinThresholds,
+		maxThresholds:      cfg.MaxThresholds,
+	}
+}
+
+// withFile returns t overridden by the settings set in f
+func (t reloadableThresholds) withFile(f *thresholdsFile) reloadableThresholds {
+	if f.MetricThresholds != nil {
+		t.metricThresholds = f.MetricThresholds
+	}
+	if f.Weights != nil {
+		t.weights = f.Weights
+	}
+	if f.CompositeThreshold != nil {
+		t.compositeThreshold = *f.CompositeThreshold
+	}
+	if f.MinThresholds != nil {
+		t.minThresholds = f.MinThresholds
+	}
+	if f.MaxThresholds != nil {
+		t.maxThresholds = f.MaxThresholds
+	}
+	return t
+}
+
+// applyTo sets the reloadable settings of cfg. The maps are replaced, never modified in place.
+func (t reloadableThresholds) applyTo(cfg *Config) {
+	cfg.MetricThresholds = t.metricThresholds
+	cfg.Weights = t.weights
+	cfg.CompositeThreshold = t.compositeThreshold
+	cfg.MinThresholds = t.minThresholds
+	cfg.MaxThresholds = t.maxThresholds
+}
+
+// diff describes the changes from t to next, one entry per changed setting
+func (t reloadableThresholds) diff(next reloadableThresholds) []string {
+	var changes []string
+	changes = append(changes, diffThresholdMap("metric_thresholds", t.metricThresholds, next.metricThresholds)...)
+	changes = append(changes, diffThresholdMap("weights", t.weights, next.weights)...)
+	if t.compositeThreshold != next.compositeThreshold {
+		changes = append(changes, fmt.Sprintf("composite_threshold: %v -> %v", t.compositeThreshold, next.compositeThreshold))
+	}
+	changes = append(changes, diffThresholdMap("min_thresholds", t.minThresholds, next.minThresholds)...)
+	changes = append(changes, diffThresholdMap("max_thresholds", t.maxThresholds, next.maxThresholds)...)
+	return changes
+}
+
+// diffThresholdMap describes the added, changed and removed entries of a map, sorted by metric
+func diffThresholdMap(setting string, prev, next map[string]float64) []string {
+	var changes []string
+	for _, metric := range slices.Sorted(maps.Keys(next)) {
+		old, ok := prev[metric]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s.%s: added %v", setting, metric, next[metric]))
+		case old != next[metric]:
+			changes = append(changes, fmt.Sprintf("%s.%s: %v -> %v", setting, metric, old, next[metric]))
+		}
+	}
+	for _, metric := range slices.Sorted(maps.Keys(prev)) {
+		if _, ok := next[metric]; !ok {
+			changes = append(changes, fmt.Sprintf("%s.%s: removed %v", setting, metric, prev[metric]))
+		}
+	}
+	return changes
+}
+
+// parseThresholdsFile decodes the content of thresholds_file, rejecting unknown settings
+func parseThresholdsFile(data []byte) (*thresholdsFile, error) {
+	var f thresholdsFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// reloadThresholdsFile applies thresholds_file when its content changed since the last check.
+// The update is validated as part of the whole configuration; an unreadable or invalid file is
+// logged and the previous thresholds are kept. Only the maintenance loop and newProcessor call it.
+func (p *processorImp) reloadThresholdsFile() {
+	path := p.config.ThresholdsFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		p.logger.Warn("Failed to read thresholds file, keeping the previous thresholds",
+			zap.String("path", path), zap.Error(err))
+		return
+	}
+	if p.thresholdsFileContent != nil && bytes.Equal(data, p.thresholdsFileContent) {
+		return
+	}
+	// Remembered even when rejected, so an invalid file is reported once rather than on every check
+	p.thresholdsFileContent = data
+
+	next, err := p.thresholdsFromFile(data)
+	if err != nil {
+		p.logger.Error("Rejected thresholds file update, keeping the previous thresholds",
+			zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	p.configMu.Lock()
+	changes := snapshotThresholds(p.config).diff(next)
+	if len(changes) == 0 {
+		p.configMu.Unlock()
+		return
+	}
+	prevThresholds := p.config.MetricThresholds
+	next.applyTo(p.config)
+	p.rules = resolveRules(p.config)
+	p.mu.Lock()
+	p.reseedDynamicThresholds(prevThresholds)
+	p.mu.Unlock()
+	p.configMu.Unlock()
+
+	p.logger.Info("Applied thresholds file update", zap.String("path", path), zap.Strings("changes", changes))
+}
+
+// thresholdsFromFile returns the collector configuration overridden by the content of thresholds_file,
+// once the configuration it results in is valid
+func (p *processorImp) thresholdsFromFile(data []byte) (reloadableThresholds, error) {
+	f, err := parseThresholdsFile(data)
+	if err != nil {
+		return reloadableThresholds{}, err
+	}
+	next := p.baseThresholds.withFile(f)
+	candidate := *p.config
+	next.applyTo(&candidate)
+	if err := candidate.Validate(); err != nil {
+		return reloadableThresholds{}, err
+	}
+	return next, nil
+}
+
+// reseedDynamicThresholds restarts the dynamic threshold of each metric whose static threshold changed
+// from the new value, so an update takes effect at once; learned thresholds of unchanged metrics are kept.
+// Callers hold p.configMu and p.mu.
+func (p *processorImp) reseedDynamicThresholds(prev map[string]float64) {
+	for metric := range prev {
+		if _, ok := p.config.MetricThresholds[metric]; !ok {
+			delete(p.dynamicCustomThresholds, metric)
+			delete(p.dynamicThresholdStats, metric)
+		}
+	}
+	for metric, base := range p.config.MetricThresholds {
+		if old, ok := prev[metric]; ok && old == base {
+			continue
+		}
+		delete(p.dynamicThresholdStats, metric)
+		if base > 0 {
+			p.dynamicCustomThresholds[metric] = base
+		} else {
+			delete(p.dynamicCustomThresholds, metric)
+		}
+	}
+}
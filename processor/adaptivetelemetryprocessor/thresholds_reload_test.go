@@ -0,0 +1,163 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func writeThresholdsFile(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func newReloadTestProcessor(t *testing.T, content string, cfg *Config) (*processorImp, string, *observer.ObservedLogs) {
+	path := filepath.Join(t.TempDir(), "thresholds.yaml")
+	writeThresholdsFile(t, path, content)
+	cfg.ThresholdsFile = path
+
+	core, logs := observer.New(zap.InfoLevel)
+	p := newTestProcessorWithSettings(t, telemetrySettings(zap.New(core)), cfg)
+	return p, path, logs
+}
+
+func TestThresholdsFileAppliedAtStartup(t *testing.T) {
+	p, _, _ := newReloadTestProcessor(t, "metric_thresholds:\n  process.cpu.utilization: 90\n", &Config{})
+
+	out, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/app", 10, 80))
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+	assert.Equal(t, 50.0, p.baseThresholds.metricThresholds["process.cpu.utilization"], "collector configuration kept as the base")
+}
+
+func TestThresholdsFileReload(t *testing.T) {
+	p, path, logs := newReloadTestProcessor(t, "{}", &Config{
+		EnableDynamicThresholds: true,
+		EnableMultiMetric:       true,
+		CompositeThreshold:      1.5,
+		Rules: []RuleConfig{{
+			Name:  "nginx",
+			Match: RuleSelector{ExecutablePaths: []string{"/usr/sbin/nginx"}},
+		}},
+	})
+	p.dynamicCustomThresholds["process.cpu.utilization"] = 70.0 // learned
+
+	// Opening the floodgates: lower the threshold during an incident
+	writeThresholdsFile(t, path, `{"metric_thresholds": {"process.cpu.utilization": 5, "process.memory.usage": 1000}, "composite_threshold": 2}`)
+	p.reloadThresholdsFile()
+
+	assert.Equal(t, map[string]float64{"process.cpu.utilization": 5, "process.memory.usage": 1000}, p.dynamicCustomThresholds, "changed thresholds restart from the new value")
+	assert.Equal(t, 2.0, p.config.CompositeThreshold)
+	assert.Equal(t, 5.0, p.rules[0].cfg.MetricThresholds["process.cpu.utilization"], "rules inherit the reloaded thresholds")
+
+	out, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/sbin/nginx", 10, 8))
+	require.NoError(t, err)
+	assert.Equal(t, 1, out.ResourceMetrics().Len())
+
+	entries := logs.FilterMessage("Applied thresholds file update").All()
+	require.Len(t, entries, 1)
+	assert.ElementsMatch(t, []any{
+		"metric_thresholds.process.cpu.utilization: 50 -> 5",
+		"metric_thresholds.process.memory.usage: added 1000",
+		"composite_threshold: 1.5 -> 2",
+	}, entries[0].ContextMap()["changes"])
+
+	// Unchanged content is not applied again
+	p.reloadThresholdsFile()
+	assert.Equal(t, 1, logs.FilterMessage("Applied thresholds file update").Len())
+
+	// Settings left out of the file return to the collector configuration
+	writeThresholdsFile(t, path, "weights:\n  process.cpu.utilization: 1\n")
+	p.reloadThresholdsFile()
+	assert.Equal(t, map[string]float64{"process.cpu.utilization": 50}, p.config.MetricThresholds)
+	assert.Equal(t, map[string]float64{"process.cpu.utilization": 50}, p.dynamicCustomThresholds)
+	assert.Equal(t, 1.5, p.config.CompositeThreshold)
+	assert.Equal(t, map[string]float64{"process.cpu.utilization": 1}, p.config.Weights)
+}
+
+func TestThresholdsFileRejectsInvalidUpdates(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		errorString string
+	}{
+		{name: "Malformed", content: "metric_thresholds: [", errorString: "yaml"},
+		{name: "Unknown setting", content: "metric_threshold:\n  process.cpu.utilization: 5\n", errorString: "field metric_threshold not found"},
+		{name: "Negative threshold", content: "metric_thresholds:\n  process.cpu.utilization: -1\n", errorString: "must be >= 0"},
+		{name: "Wrong type", content: `{"metric_thresholds": {"process.cpu.utilization": "high"}}`, errorString: "cannot unmarshal"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, path, logs := newReloadTestProcessor(t, "metric_thresholds:\n  process.cpu.utilization: 20\n", &Config{})
+
+			writeThresholdsFile(t, path, tc.content)
+			p.reloadThresholdsFile()
+			assert.Equal(t, 20.0, p.config.MetricThresholds["process.cpu.utilization"], "previous thresholds kept")
+			assert.Equal(t, 20.0, p.dynamicCustomThresholds["process.cpu.utilization"])
+
+			entries := logs.FilterMessage("Rejected thresholds file update, keeping the previous thresholds").All()
+			require.Len(t, entries, 1)
+			assert.Contains(t, entries[0].ContextMap()["error"], tc.errorString)
+
+			// The same invalid content is reported once
+			p.reloadThresholdsFile()
+			assert.Equal(t, 1, logs.FilterMessage("Rejected thresholds file update, keeping the previous thresholds").Len())
+		})
+	}
+}
+
+func TestThresholdsFileMissing(t *testing.T) {
+	p, path, logs := newReloadTestProcessor(t, "metric_thresholds:\n  process.cpu.utilization: 20\n", &Config{})
+
+	require.NoError(t, os.Remove(path))
+	p.reloadThresholdsFile()
+	assert.Equal(t, 20.0, p.config.MetricThresholds["process.cpu.utilization"])
+	assert.Equal(t, 1, logs.FilterMessage("Failed to read thresholds file, keeping the previous thresholds").Len())
+}
+
+func TestThresholdsFileReloadDuringBatches(t *testing.T) {
+	p, path, _ := newReloadTestProcessor(t, "{}", &Config{EnableDynamicThresholds: true})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/app", 10, 30))
+			assert.NoError(t, err)
+			p.shouldForwardSignalResource(createTestProcessMetrics("/usr/bin/app", 10, 30).ResourceMetrics().At(0).Resource())
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		writeThresholdsFile(t, path, "metric_thresholds:\n  process.cpu.utilization: "+[]string{"10", "90"}[i%2]+"\n")
+		p.reloadThresholdsFile()
+	}
+	wg.Wait()
+}
+
+func TestDiffThresholdMap(t *testing.T) {
+	assert.Equal(t, []string{
+		"weights.a: 1 -> 2",
+		"weights.c: added 3",
+		"weights.b: removed 1",
+	}, diffThresholdMap("weights", map[string]float64{"a": 1, "b": 1}, map[string]float64{"a": 2, "c": 3}))
+	assert.Empty(t, diffThresholdMap("weights", nil, map[string]float64{}))
+}
+
+func TestThresholdsFileCheckedByMaintenance(t *testing.T) {
+	p, _, _ := newReloadTestProcessor(t, "{}", &Config{})
+	assert.Equal(t, defaultThresholdsFileCheckInterval, p.config.ThresholdsFileCheckInterval)
+
+	p.startMaintenance()
+	defer p.stopMaintenance()
+	assert.Len(t, p.maintenanceTickers, 4)
+}
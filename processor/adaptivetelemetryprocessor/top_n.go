@@ -0,0 +1,129 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// topNCandidate is a process of the batch ranked by the top_n metrics
+type topNCandidate struct {
+	id       string
+	host     string
+	values   map[string]float64
+	included bool                    // Already included by another stage
+	rm       pmetric.ResourceMetrics // Excluded resource waiting for the selection
+}
+
+// topNSelection ranks the processes of a batch per host, and holds back the excluded ones until
+// the batch is evaluated, since any later process may push them out of the top N
+type topNSelection struct {
+	limits     map[string]int
+	candidates []*topNCandidate
+}
+
+// newTopNSelection returns the selection of a batch, nil unless top_n is configured
+func (p *processorImp) newTopNSelection() *topNSelection {
+	if len(p.config.TopN) == 0 {
+		return nil
+	}
+	return &topNSelection{limits: p.config.TopN}
+}
+
+// add ranks a process resource evaluated with values, and reports whether an excluded one is held back
+func (s *topNSelection) add(rm pmetric.ResourceMetrics, id string, values map[string]float64, included bool) bool {
+	if s == nil || getResourceType(rm.Resource().Attributes()) != resourceTypeProcess {
+		return false
+	}
+	ranked := false
+	for metric := range s.limits {
+		if _, ok := values[metric]; ok {
+			ranked = true
+			break
+		}
+	}
+	if !ranked {
+		return false
+	}
+
+	candidate := &topNCandidate{
+		id:       id,
+		host:     getHostName(rm.Resource().Attributes()),
+		values:   values,
+		included: included,
+	}
+	if !included {
+		candidate.rm = rm
+	}
+	s.candidates = append(s.candidates, candidate)
+	return !included
+}
+
+// selected returns the candidates among the top N of their host for any top_n metric
+func (s *topNSelection) selected() map[*topNCandidate]bool {
+	byHost := make(map[string][]*topNCandidate)
+	for _, c := range s.candidates {
+		byHost[c.host] = append(byHost[c.host], c)
+	}
+
+	selected := make(map[*topNCandidate]bool)
+	for metric, n := range s.limits {
+		for _, candidates := range byHost {
+			ranked := make([]*topNCandidate, 0, len(candidates))
+			for _, c := range candidates {
+				if _, ok := c.values[metric]; ok {
+					ranked = append(ranked, c)
+				}
+			}
+			sort.Slice(ranked, func(i, j int) bool {
+				if ranked[i].values[metric] != ranked[j].values[metric] {
+					return ranked[i].values[metric] > ranked[j].values[metric]
+				}
+				return ranked[i].id < ranked[j].id
+			})
+			for i := 0; i < n && i < len(ranked); i++ {
+				selected[ranked[i]] = true
+			}
+		}
+	}
+	return selected
+}
+
+// finishTopNSelection includes the held back processes among the top N of their host with the top_n stage,
+// and handles the others as excluded. Returns the number of resources forwarded.
+func (p *processorImp) finishTopNSelection(filtered *pmetric.Metrics, processCtx *processingContext) int {
+	s := processCtx.topN
+	if s == nil {
+		return 0
+	}
+
+	selected := s.selected()
+	forwarded := 0
+	for _, c := range s.candidates {
+		if c.included {
+			continue
+		}
+		if !selected[c] {
+			if p.handleNotIncludedResource(c.rm, c.id, filtered, processCtx) {
+				forwarded++
+			}
+			continue
+		}
+		setResourceFilterStage(c.rm.Resource(), stageTopN)
+		p.handleIncludedResource(c.rm, c.id, stageTopN, filtered)
+		processCtx.stageHits[stageTopN]++
+		p.recordForwarded(c.id, time.Now())
+		forwarded++
+	}
+
+	p.logger.Debug("Top N selection completed",
+		zap.Int("candidates", len(s.candidates)),
+		zap.Int("selected", len(selected)),
+		zap.Int("forwarded", forwarded))
+	return forwarded
+}
@@ -0,0 +1,118 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// addRankedProcess adds a process of host with CPU utilization and memory usage
+func addRankedProcess(md pmetric.Metrics, host string, pid int, cpu float64, memory int64) {
+	addProcessToMetrics(md, fmt.Sprintf("/usr/bin/app%d", pid), pid, cpu)
+	rm := md.ResourceMetrics().At(md.ResourceMetrics().Len() - 1)
+	rm.Resource().Attributes().PutStr("host.name", host)
+	m := rm.ScopeMetrics().At(0).Metrics().AppendEmpty()
+	m.SetName("process.memory.usage")
+	m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(memory)
+}
+
+// forwardedPIDs returns the process.pid of every forwarded resource, with the stage of each
+func forwardedPIDs(md pmetric.Metrics) map[int64]string {
+	pids := make(map[int64]string)
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		attrs := md.ResourceMetrics().At(i).Resource().Attributes()
+		pid, _ := attrs.Get("process.pid")
+		stage := ""
+		if atp, ok := attrs.Get(atpAttributeKey); ok {
+			stage = atp.AsString()
+		}
+		pids[pid.Int()] = stage
+	}
+	return pids
+}
+
+func TestTopNPerHost(t *testing.T) {
+	p := newTestProcessor(t, &Config{TopN: map[string]int{"process.cpu.utilization": 2}})
+
+	md := pmetric.NewMetrics()
+	for pid := 1; pid <= 5; pid++ {
+		addRankedProcess(md, "hosta", pid, float64(pid), 1)
+	}
+	addRankedProcess(md, "hostb", 11, 1, 1)
+	addRankedProcess(md, "hostb", 12, 2, 1)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	assert.Len(t, pids, 4)
+	for _, pid := range []int64{4, 5, 11, 12} {
+		assert.Contains(t, pids[pid], `"stage":"top_n"`)
+	}
+}
+
+func TestTopNCountsIncludedProcesses(t *testing.T) {
+	p := newTestProcessor(t, &Config{TopN: map[string]int{"process.cpu.utilization": 2}})
+
+	md := pmetric.NewMetrics()
+	addRankedProcess(md, "hosta", 1, 1, 1)
+	addRankedProcess(md, "hosta", 2, 80, 1) // included by its threshold
+	addRankedProcess(md, "hosta", 3, 3, 1)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	assert.Len(t, pids, 2)
+	assert.Contains(t, pids[2], `"stage":"static_threshold"`)
+	assert.Contains(t, pids[3], `"stage":"top_n"`)
+}
+
+func TestTopNPerMetric(t *testing.T) {
+	p := newTestProcessor(t, &Config{TopN: map[string]int{
+		"process.cpu.utilization": 1,
+		"process.memory.usage":    1,
+	}})
+
+	md := pmetric.NewMetrics()
+	addRankedProcess(md, "hosta", 1, 9, 1<<20)
+	addRankedProcess(md, "hosta", 2, 1, 1<<30)
+	addRankedProcess(md, "hosta", 3, 5, 1<<25)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	assert.Len(t, pids, 2, "top CPU and top memory")
+	assert.Contains(t, pids, int64(1))
+	assert.Contains(t, pids, int64(2))
+}
+
+func TestTopNDisabled(t *testing.T) {
+	p := newTestProcessor(t, &Config{})
+	assert.Nil(t, p.newTopNSelection())
+
+	md := pmetric.NewMetrics()
+	addRankedProcess(md, "hosta", 1, 9, 1)
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.ResourceMetrics().Len())
+}
+
+func TestTopNShadowMode(t *testing.T) {
+	p := newTestProcessor(t, &Config{TopN: map[string]int{"process.cpu.utilization": 1}, Mode: modeShadow})
+
+	md := pmetric.NewMetrics()
+	addRankedProcess(md, "hosta", 1, 1, 1)
+	addRankedProcess(md, "hosta", 2, 2, 1)
+
+	out, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	pids := forwardedPIDs(out)
+	require.Len(t, pids, 2)
+	assert.Contains(t, pids[2], `"decision":"keep"`)
+	assert.Contains(t, pids[1], `"decision":"drop"`)
+}
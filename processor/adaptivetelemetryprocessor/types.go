@@ -9,12 +9,23 @@ import "time"
 type trackedEntity struct {
 	Identity      string             `json:"identity"`
 	FirstSeen     time.Time          `json:"first_seen"`
-	LastExceeded  time.Time          `json:"last_exceeded"` // Used for threshold-based retention (static/dynamic/multi-metric)
+	LastExceeded  time.Time          `json:"last_exceeded"`       // Used for threshold-based retention (static/dynamic/multi-metric)
+	LastSeen      time.Time          `json:"last_seen,omitempty"` // Last evaluation, used by the lru eviction policy
 	CurrentValues map[string]float64 `json:"current_values"`
 	MaxValues     map[string]float64 `json:"max_values"`
 	Attributes    map[string]string  `json:"attributes,omitempty"`
+	Rule          string             `json:"rule,omitempty"` // Name of the rule the entity was last evaluated with
+	// Metrics that triggered the last inclusion by an evaluation stage, kept by metric-level filtering
+	TriggerMetrics []string `json:"trigger_metrics,omitempty"`
+
+	// Hysteresis of the threshold stages
+	ThresholdActive   bool `json:"threshold_active,omitempty"`   // Included by a threshold stage, exit thresholds apply
+	ThresholdBreaches int  `json:"threshold_breaches,omitempty"` // Evaluations in a row at or above a threshold, before entering
+	QuietIntervals    int  `json:"quiet_intervals,omitempty"`    // Evaluations in a row below every exit threshold, before leaving
 
 	// Anomaly detection fields - uses separate retention tracking
 	MetricHistory       map[string][]float64 `json:"metric_history,omitempty"`
 	LastAnomalyDetected time.Time            `json:"last_anomaly_detected,omitempty"` // Used for anomaly-based retention (independent)
+
+	lastAllMetrics time.Time // Last time metric-level filtering forwarded all metrics, not persisted
 }
@@ -0,0 +1,151 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor // import "github.com/newrelic/nrdot-collector-components/processor/adaptivetelemetryprocessor"
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// volumeBudgetWindow is how long output volume is observed before the thresholds are adjusted
+const volumeBudgetWindow = time.Minute
+
+// volumeWindow is the output volume observed since the thresholds were last adjusted
+type volumeWindow struct {
+	start           time.Time
+	inputResources  int
+	outputResources int
+	hostDataPoints  map[string]int // Forwarded data points by host.name
+}
+
+// volumeBudgetEnabled reports whether a volume budget adjusts the dynamic thresholds
+func (cfg *Config) volumeBudgetEnabled() bool {
+	return cfg.MaxDatapointsPerMinute > 0 || cfg.TargetOutputRatio > 0
+}
+
+// observeOutputVolume adds the output of a batch to the volume window, and adjusts the dynamic thresholds
+// once the window is complete. Only resources forwarded by a stage the thresholds decide are measured:
+// what anomaly detection, the include list and the other stages forward regardless of the thresholds
+// would not shrink by raising them, so it is left out of both the output and the input.
+func (p *processorImp) observeOutputVolume(processCtx *processingContext, filtered pmetric.Metrics, now time.Time) {
+	if p.volume == nil || processCtx.resourceCount == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w := p.volume
+	if w.start.IsZero() {
+		w.start = now
+	}
+	bypassed := 0
+	for i := 0; i < filtered.ResourceMetrics().Len(); i++ {
+		rm := filtered.ResourceMetrics().At(i)
+		// Shadow mode forwards everything, the budget applies to what would be kept
+		if isShadowDropped(rm.Resource()) {
+			continue
+		}
+		switch stage := forwardedStage(rm.Resource()); stage {
+		case stageStaticThreshold, stageDynamicThreshold, stageMultiMetric, stageStandardRetention:
+			w.outputResources++
+			w.hostDataPoints[getHostName(rm.Resource().Attributes())] += countDataPointsInResource(rm)
+		case stageAggregated:
+			// The _other rollup is not an input resource
+		default:
+			bypassed++
+		}
+	}
+	w.inputResources += processCtx.resourceCount - bypassed
+
+	elapsed := now.Sub(w.start)
+	if elapsed < volumeBudgetWindow {
+		return
+	}
+	observed, target := p.volumeAgainstBudget(elapsed)
+	p.adjustThresholdsForVolume(observed, target, now)
+	p.volume = &volumeWindow{hostDataPoints: make(map[string]int)}
+}
+
+// volumeAgainstBudget returns the volume of the window and its budget: the data points per minute of
+// the busiest host, or the share of resources forwarded. Callers hold p.mu.
+func (p *processorImp) volumeAgainstBudget(elapsed time.Duration) (observed, target float64) {
+	w := p.volume
+	if p.config.TargetOutputRatio > 0 {
+		// Without resources the thresholds decide there is no error to correct
+		if w.inputResources <= 0 {
+			return p.config.TargetOutputRatio, p.config.TargetOutputRatio
+		}
+		return float64(w.outputResources) / float64(w.inputResources), p.config.TargetOutputRatio
+	}
+
+	busiest := 0
+	for _, dataPoints := range w.hostDataPoints {
+		busiest = max(busiest, dataPoints)
+	}
+	return float64(busiest) * volumeBudgetWindow.Minutes() / elapsed.Minutes(), float64(p.config.MaxDatapointsPerMinute)
+}
+
+// adjustThresholdsForVolume scales the dynamic thresholds by part of the relative volume error, raising
+// them over budget and lowering them under it, within min_thresholds and max_thresholds. A threshold of 0
+// scales to 0, so it starts from its min_thresholds entry; without one, the metric is always included and
+// exempt from the budget. Callers hold p.mu.
+func (p *processorImp) adjustThresholdsForVolume(observed, target float64, now time.Time) {
+	ratio := observed / target
+	if ratio >= 1-volumeBudgetTolerance && ratio <= 1+volumeBudgetTolerance {
+		p.logger.Debug("Output volume within budget",
+			zap.Float64("observed", observed),
+			zap.Float64("target", target))
+		return
+	}
+
+	factor := 1 + volumeBudgetGain*(ratio-1)
+	factor = max(factor, 1/volumeBudgetMaxStep)
+	factor = min(factor, volumeBudgetMaxStep)
+	adjusted := 0
+	for metric, threshold := range p.dynamicCustomThresholds {
+		if threshold <= 0 {
+			if p.config.MinThresholds[metric] <= 0 {
+				continue
+			}
+			threshold = p.config.MinThresholds[metric]
+		}
+		p.dynamicCustomThresholds[metric] = p.applyThresholdConstraints(metric, threshold*factor)
+		p.recordThresholdUpdate(metric, 0, now)
+		adjusted++
+	}
+
+	p.logger.Info("Adjusted dynamic thresholds to the volume budget",
+		zap.Float64("observed", observed),
+		zap.Float64("target", target),
+		zap.Float64("factor", factor),
+		zap.Int("thresholds", adjusted))
+}
+
+// forwardedStage returns the stage recorded in the process.atp details of a forwarded resource, "" if none,
+// as for default inclusion
+func forwardedStage(resource pcommon.Resource) string {
+	val, ok := resource.Attributes().Get(atpAttributeKey)
+	if !ok || val.Type() != pcommon.ValueTypeMap {
+		return ""
+	}
+	stage, ok := val.Map().Get("stage")
+	if !ok {
+		return ""
+	}
+	return stage.Str()
+}
+
+// isShadowDropped reports whether shadow mode annotated a forwarded resource as dropped
+func isShadowDropped(resource pcommon.Resource) bool {
+	val, ok := resource.Attributes().Get(atpAttributeKey)
+	if !ok || val.Type() != pcommon.ValueTypeMap {
+		return false
+	}
+	decision, ok := val.Map().Get("decision")
+	return ok && decision.Str() == shadowDecisionDrop
+}
@@ -0,0 +1,125 @@
+// Copyright New Relic, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package adaptivetelemetryprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// processVolumeWindow processes a batch of processes with cpu values, then completes the volume window
+// with a second one
+func processVolumeWindow(t *testing.T, p *processorImp, cpuValues ...float64) {
+	md := pmetric.NewMetrics()
+	for i, cpu := range cpuValues {
+		addProcessToMetrics(md, "/usr/bin/app", 100+i, cpu)
+	}
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+
+	p.volume.start = p.volume.start.Add(-2 * volumeBudgetWindow)
+	_, err = p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/app", 100, cpuValues[0]))
+	require.NoError(t, err)
+}
+
+func TestVolumeBudgetRaisesThresholdsOverRatio(t *testing.T) {
+	p := newTestProcessor(t, &Config{TargetOutputRatio: 0.2}, withDynamicThresholds)
+
+	// Every process is forwarded, five times the target: the step is capped
+	processVolumeWindow(t, p, 80, 80, 80, 80)
+	assert.InDelta(t, 100.0, p.dynamicCustomThresholds["process.cpu.utilization"], 0.001)
+	assert.Zero(t, p.volume.inputResources, "a new window starts")
+}
+
+func TestVolumeBudgetRespectsMaxThresholds(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		TargetOutputRatio: 0.2,
+		MaxThresholds:     map[string]float64{"process.cpu.utilization": 70.0},
+	}, withDynamicThresholds)
+
+	processVolumeWindow(t, p, 80, 80, 80, 80)
+	assert.InDelta(t, 70.0, p.dynamicCustomThresholds["process.cpu.utilization"], 0.001)
+}
+
+func TestVolumeBudgetLowersThresholdsUnderDataPointBudget(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		MaxDatapointsPerMinute: 100,
+		MinThresholds:          map[string]float64{"process.cpu.utilization": 30.0},
+	}, withDynamicThresholds)
+
+	processVolumeWindow(t, p, 80)
+	assert.InDelta(t, 30.0, p.dynamicCustomThresholds["process.cpu.utilization"], 0.001)
+}
+
+func TestVolumeBudgetWithinTolerance(t *testing.T) {
+	p := newTestProcessor(t, &Config{TargetOutputRatio: 0.5}, withDynamicThresholds)
+
+	// Two of four resources forwarded over the window
+	processVolumeWindow(t, p, 80, 10, 10)
+	assert.InDelta(t, 50.0, p.dynamicCustomThresholds["process.cpu.utilization"], 0.001)
+}
+
+func TestVolumeBudgetReplacesAverageUpdates(t *testing.T) {
+	p := newTestProcessor(t, &Config{MaxDatapointsPerMinute: 100}, withDynamicThresholds)
+	p.lastThresholdUpdate = time.Now().Add(-time.Hour)
+
+	p.updateDynamicThresholdsIfNeeded(createTestProcessMetrics("/usr/bin/app", 100, 95))
+	assert.InDelta(t, 50.0, p.dynamicCustomThresholds["process.cpu.utilization"], 0.001)
+}
+
+func TestVolumeBudgetDisabled(t *testing.T) {
+	p := newTestProcessor(t, &Config{}, withDynamicThresholds)
+	assert.Nil(t, p.volume)
+
+	_, err := p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/app", 100, 80))
+	require.NoError(t, err)
+}
+
+func TestCountDataPointsInResource(t *testing.T) {
+	md := createTestProcessMetrics("/usr/bin/app", 100, 80)
+	rm := md.ResourceMetrics().At(0)
+	sum := rm.ScopeMetrics().At(0).Metrics().AppendEmpty().SetEmptySum()
+	sum.DataPoints().AppendEmpty()
+	sum.DataPoints().AppendEmpty()
+
+	assert.Equal(t, 3, countDataPointsInResource(rm))
+}
+
+func TestVolumeBudgetIgnoresBypassStages(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		TargetOutputRatio:  0.5,
+		IncludeProcessList: []string{"/usr/bin/pinned"},
+	}, withDynamicThresholds)
+
+	// The included process is left out: one of two evaluated resources forwarded by the threshold
+	md := createTestProcessMetrics("/usr/bin/pinned", 99, 1)
+	addProcessToMetrics(md, "/usr/bin/app", 100, 80)
+	addProcessToMetrics(md, "/usr/bin/app", 101, 10)
+	_, err := p.processMetrics(t.Context(), md)
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.volume.inputResources)
+	assert.Equal(t, 1, p.volume.outputResources)
+
+	p.volume.start = p.volume.start.Add(-2 * volumeBudgetWindow)
+	_, err = p.processMetrics(t.Context(), createTestProcessMetrics("/usr/bin/pinned", 99, 1))
+	require.NoError(t, err)
+	assert.InDelta(t, 50.0, p.dynamicCustomThresholds["process.cpu.utilization"], 0.001)
+}
+
+func TestVolumeBudgetZeroThreshold(t *testing.T) {
+	p := newTestProcessor(t, &Config{
+		TargetOutputRatio: 0.2,
+		MinThresholds:     map[string]float64{"process.memory.utilization": 5.0},
+	}, withDynamicThresholds)
+	p.dynamicCustomThresholds["process.disk.io"] = 0
+	p.dynamicCustomThresholds["process.memory.utilization"] = 0
+
+	processVolumeWindow(t, p, 80, 80, 80, 80)
+	assert.Zero(t, p.dynamicCustomThresholds["process.disk.io"], "exempt without a min threshold")
+	assert.InDelta(t, 10.0, p.dynamicCustomThresholds["process.memory.utilization"], 0.001, "scaled from its min threshold")
+}
@@ -27,7 +27,6 @@ func TestZombieProcessAlwaysIncluded(t *testing.T) {
 		logger:                   zap.NewNop(),
 		config:                   cfg,
 		trackedEntities:          make(map[string]*trackedEntity),
-		nextConsumer:             &mockMetricsConsumer{},
 		persistenceEnabled:       false,
 		dynamicThresholdsEnabled: false,
 		multiMetricEnabled:       false,
@@ -91,7 +90,6 @@ func TestNormalProcessFiltered(t *testing.T) {
 		logger:                   zap.NewNop(),
 		config:                   cfg,
 		trackedEntities:          make(map[string]*trackedEntity),
-		nextConsumer:             &mockMetricsConsumer{},
 		persistenceEnabled:       false,
 		dynamicThresholdsEnabled: false,
 		multiMetricEnabled:       false,